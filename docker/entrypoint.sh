@@ -29,10 +29,15 @@ if [ "$MCP_DIALECT" = "doltlite" ]; then
 
     if [ "$MCP_MODE" = "http" ]; then
         set -- "$@" --http
-        if [ -n "$MCP_PORT" ]; then
-            set -- "$@" --mcp-port "$MCP_PORT"
+        if [ -n "$MCP_SOCKET" ]; then
+            set -- "$@" --mcp-socket "$MCP_SOCKET"
+            echo "Starting Dolt MCP Server (DoltLite) in HTTP mode on socket $MCP_SOCKET" >&2
+        else
+            if [ -n "$MCP_PORT" ]; then
+                set -- "$@" --mcp-port "$MCP_PORT"
+            fi
+            echo "Starting Dolt MCP Server (DoltLite) in HTTP mode on port ${MCP_PORT:-8080}" >&2
         fi
-        echo "Starting Dolt MCP Server (DoltLite) in HTTP mode on port ${MCP_PORT:-8080}" >&2
     elif [ "$MCP_MODE" = "stdio" ]; then
         set -- "$@" --stdio
         echo "Starting Dolt MCP Server (DoltLite) in stdio mode" >&2
@@ -49,8 +54,8 @@ fi
 # Build command based on environment variables
 CMD_ARGS=""
 
-if [ -z "$DOLT_HOST" ]; then
-    echo "Error: DOLT_HOST environment variable is required"
+if [ -z "$DOLT_HOST" ] && [ -z "$DOLT_SOCKET" ]; then
+    echo "Error: DOLT_HOST or DOLT_SOCKET environment variable is required"
     exit 1
 fi
 
@@ -80,7 +85,11 @@ if [ -z "$DOLT_PORT" ]; then
 fi
 
 # Add required parameters
-CMD_ARGS="$CMD_ARGS --host $DOLT_HOST"
+if [ -n "$DOLT_SOCKET" ]; then
+    CMD_ARGS="$CMD_ARGS --socket $DOLT_SOCKET"
+else
+    CMD_ARGS="$CMD_ARGS --host $DOLT_HOST"
+fi
 CMD_ARGS="$CMD_ARGS --port $DOLT_PORT"
 CMD_ARGS="$CMD_ARGS --user $DOLT_USER"
 
@@ -97,10 +106,15 @@ fi
 # Determine server mode
 if [ "$MCP_MODE" = "http" ]; then
     CMD_ARGS="$CMD_ARGS --http"
-    if [ -n "$MCP_PORT" ]; then
-        CMD_ARGS="$CMD_ARGS --mcp-port $MCP_PORT"
+    if [ -n "$MCP_SOCKET" ]; then
+        CMD_ARGS="$CMD_ARGS --mcp-socket $MCP_SOCKET"
+        echo "Starting Dolt MCP Server in HTTP mode on socket $MCP_SOCKET"
+    else
+        if [ -n "$MCP_PORT" ]; then
+            CMD_ARGS="$CMD_ARGS --mcp-port $MCP_PORT"
+        fi
+        echo "Starting Dolt MCP Server in HTTP mode on port $MCP_PORT"
     fi
-    echo "Starting Dolt MCP Server in HTTP mode on port $MCP_PORT"
 elif [ "$MCP_MODE" = "stdio" ]; then
     CMD_ARGS="$CMD_ARGS --stdio"
     echo "Starting Dolt MCP Server in stdio mode"
@@ -109,7 +123,11 @@ else
     exit 1
 fi
 
-echo "Connecting to Dolt server at $DOLT_HOST:$DOLT_PORT"
+if [ -n "$DOLT_SOCKET" ]; then
+    echo "Connecting to Dolt server at $DOLT_SOCKET"
+else
+    echo "Connecting to Dolt server at $DOLT_HOST:$DOLT_PORT"
+fi
 
 if [ -n "$DOLT_DATABASE" ]; then
     echo "Database: $DOLT_DATABASE"
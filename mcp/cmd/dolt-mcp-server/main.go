@@ -14,6 +14,7 @@ import (
 
 	"github.com/dolthub/dolt-mcp/mcp/pkg"
 	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/tools"
 	"github.com/dolthub/dolt-mcp/mcp/pkg/toolsets"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
@@ -22,6 +23,7 @@ import (
 const (
 	// New flag names (preferred).
 	hostFlag     = "host"
+	socketFlag   = "socket"
 	portFlag     = "port"
 	userFlag     = "user"
 	passwordFlag = "password"
@@ -48,7 +50,9 @@ const (
 
 	// Unchanged flags.
 	mcpPortFlag    = "mcp-port"
+	mcpSocketFlag  = "mcp-socket"
 	serveHTTPFlag  = "http"
+	sseFlag        = "sse"
 	httpCertFlag   = "http-cert-file"
 	httpKeyFlag    = "http-key-file"
 	httpCAFlag     = "http-ca-file"
@@ -58,6 +62,18 @@ const (
 	versionFlag    = "version"
 	jwkClaimsFlag  = "jwk-claims"
 	jwkURLFlag     = "jwk-url"
+
+	confirmWritesFlag   = "confirm-writes"
+	applyTokenTTLFlag   = "apply-token-ttl"
+	configFlag          = "config"
+	execScriptFlag      = "exec-script"
+	httpClientAuthFlag  = "http-client-auth"
+	auditLogFlag        = "audit-log"
+	metricsFlag         = "metrics"
+	adminPortFlag       = "admin-port"
+	traceExporterFlag   = "trace-exporter"
+	traceFileFlag       = "trace-file"
+	shutdownTimeoutFlag = "shutdown-timeout"
 )
 
 // Default ports per dialect.
@@ -68,7 +84,8 @@ const (
 
 // New flags (preferred).
 var (
-	host     = flag.String(hostFlag, "", "The hostname for the database server.")
+	host     = flag.String(hostFlag, "", "The hostname for the database server. A comma-separated list of host or host:port members of a Dolt cluster connects to whichever is the primary, failing over when it changes.")
+	socket   = flag.String(socketFlag, "", "Path of the unix domain socket of the database server, used in place of --host. For DoltgreSQL, the directory holding the socket or the .s.PGSQL.<port> socket itself.")
 	port     = flag.Int(portFlag, 0, "The port for the database server. Defaults to 3306 for Dolt or 5432 for DoltgreSQL.")
 	user     = flag.String(userFlag, "", "The username for connecting to the database server.")
 	password = flag.String(passwordFlag, "", "The password for connecting to the database server.")
@@ -101,7 +118,9 @@ var (
 // Unchanged flags.
 var (
 	mcpPort      = flag.Int(mcpPortFlag, 8080, "The HTTP port to serve Dolt MCP server on, default is 8080.")
+	mcpSocket    = flag.String(mcpSocketFlag, "", "Path of a unix domain socket to serve Dolt MCP server on over HTTP, in place of --mcp-port.")
 	serveHTTP    = flag.Bool(serveHTTPFlag, false, "If true, serves Dolt MCP server over HTTP")
+	serveSSE     = flag.Bool(sseFlag, false, "If true, also serves the legacy HTTP+SSE transport at /sse and /message, next to streamable HTTP. Requires --http.")
 	serveStdio   = flag.Bool(serveStdioFlag, false, "If true, serves Dolt MCP server over stdio")
 	logLevel     = flag.String(logLevelFlag, "info", "Log level: debug, info, warn, error. Default is info.")
 	httpCertFile = flag.String(httpCertFlag, "", "Path to TLS certificate file for HTTPS. If provided, a key must also be provided.")
@@ -113,6 +132,20 @@ var (
 	version      = flag.Bool(versionFlag, false, "If true, prints the Dolt MCP server version.")
 )
 
+var (
+	confirmWrites   = flag.Bool(confirmWritesFlag, false, "If true, exec, alter_table, drop_table, and drop_database preview their changes and only commit once the user confirms, either through MCP elicitation or by passing back an apply token.")
+	applyTokenTTL   = flag.Duration(applyTokenTTLFlag, pkg.DefaultApplyTokenTTL, "How long an apply token issued by --confirm-writes stays valid.")
+	configFile      = flag.String(configFlag, "", "Path to a YAML configuration file, for settings such as the SQL policy. It is read again on SIGHUP.")
+	execScript      = flag.Bool(execScriptFlag, false, "If true, registers the exec_script tool, which runs a list of statements in a single transaction.")
+	httpClientAuth  = flag.String(httpClientAuthFlag, "", "Client certificate authentication for HTTPS: require or verify-if-given. Verified client certificates, checked against --http-ca-file, become the caller identity.")
+	auditLogPath    = flag.String(auditLogFlag, "", "Path of a file to append a JSON lines audit record of every tool call to, or stdout or stderr. Written regardless of --log-level.")
+	metricsEndpoint = flag.Bool(metricsFlag, false, "If true, serves Prometheus metrics at /metrics on the HTTP server, behind the same authentication as MCP.")
+	adminPort       = flag.Int(adminPortFlag, 0, "A port to serve admin endpoints such as /metrics on, separately from MCP and without authentication. Keep it private. Works with --stdio too.")
+	traceExporter   = flag.String(traceExporterFlag, "", "Export OpenTelemetry traces of HTTP requests, tool calls, and SQL statements: otlp, stdout, or file. otlp is configured by the OTEL_EXPORTER_OTLP_* environment variables.")
+	traceFile       = flag.String(traceFileFlag, "", "Path of the file the file trace exporter appends spans to.")
+	shutdownTimeout = flag.Duration(shutdownTimeoutFlag, pkg.DefaultShutdownTimeout, "How long running tool calls may take to finish on shutdown. Calls still running then are canceled and their transactions rolled back.")
+)
+
 // setFlags returns the set of flag names that were explicitly passed on the command line.
 func setFlags() map[string]bool {
 	s := map[string]bool{}
@@ -164,11 +197,22 @@ func resolveDialect(set map[string]bool) (db.DialectType, error) {
 	return db.DialectMySQL, nil
 }
 
-func getTLSConfig(cert, key, ca string) (*tls.Config, error) {
+func getTLSConfig(cert, key, ca, clientAuth string) (*tls.Config, error) {
 	if key == "" && cert == "" {
+		if clientAuth != "" {
+			return nil, fmt.Errorf("--%s requires --%s and --%s", httpClientAuthFlag, httpCertFlag, httpKeyFlag)
+		}
 		return nil, nil
 	}
 
+	clientAuthType, err := parseClientAuth(clientAuth)
+	if err != nil {
+		return nil, err
+	}
+	if clientAuth != "" && ca == "" {
+		return nil, fmt.Errorf("--%s requires --%s", httpClientAuthFlag, httpCAFlag)
+	}
+
 	c, err := tls.LoadX509KeyPair(cert, key)
 	if err != nil {
 		return nil, fmt.Errorf("tls.LoadX509KeyPair(%v, %v) failed: %w", cert, key, err)
@@ -189,12 +233,26 @@ func getTLSConfig(cert, key, ca string) (*tls.Config, error) {
 
 	return &tls.Config{
 		Certificates: []tls.Certificate{c},
-		ClientAuth:   tls.VerifyClientCertIfGiven,
+		ClientAuth:   clientAuthType,
 		ClientCAs:    caCertPool,
 		MinVersion:   tls.VersionTLS12,
 	}, nil
 }
 
+// parseClientAuth returns the tls.ClientAuthType for the --http-client-auth
+// value. Without one, client certificates are verified if given but do not
+// identify the caller.
+func parseClientAuth(clientAuth string) (tls.ClientAuthType, error) {
+	switch clientAuth {
+	case "", pkg.ClientAuthVerifyIfGiven:
+		return tls.VerifyClientCertIfGiven, nil
+	case pkg.ClientAuthRequire:
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return tls.NoClientCert, fmt.Errorf("invalid --%s %q: expected %s or %s", httpClientAuthFlag, clientAuth, pkg.ClientAuthRequire, pkg.ClientAuthVerifyIfGiven)
+	}
+}
+
 func main() {
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n", os.Args[0])
@@ -284,6 +342,7 @@ func main() {
 
 	config := db.Config{
 		Host:         hostVal,
+		Socket:       *socket,
 		Port:         portVal,
 		User:         userVal,
 		Password:     passwordVal,
@@ -296,8 +355,22 @@ func main() {
 		CommitEmail:  *commitEmail,
 		BusyTimeout:  *busyTimeout,
 	}
+	if hosts := strings.Split(hostVal, ","); len(hosts) > 1 {
+		config.Hosts = hosts
+		endpoints, err := config.Endpoints()
+		if err != nil {
+			logger.Fatal(fmt.Sprintf("invalid --%s", hostFlag), zap.Error(err))
+		}
+		config.Host, config.Port = endpoints[0].Host, endpoints[0].Port
+		failover, err := pkg.NewClusterFailover(logger, config, pkg.DefaultFailoverCheckInterval)
+		if err != nil {
+			logger.Fatal("failed to set up failover between database hosts", zap.Error(err))
+		}
+		defer failover.Close()
+		config.Primary = failover
+	}
 
-	tlsConfig, err := getTLSConfig(*httpCertFile, *httpKeyFile, *httpCAFile)
+	tlsConfig, err := getTLSConfig(*httpCertFile, *httpKeyFile, *httpCAFile, *httpClientAuth)
 	if err != nil {
 		logger.Fatal("failed to get TLS configuration", zap.Error(err))
 	}
@@ -307,6 +380,130 @@ func main() {
 		logger.Fatal("failed to parse JWK claims", zap.Stringp("jwk_claims", jwkClaims), zap.Error(err))
 	}
 
+	serverOpts := []pkg.Option{}
+	var serverConfig *pkg.Config
+	if *configFile != "" {
+		serverConfig, err = pkg.LoadConfig(*configFile)
+		if err != nil {
+			logger.Fatal("failed to load config file", zap.Error(err))
+		}
+		if err := serverConfig.ApplyDBConfig(&config); err != nil {
+			logger.Fatal("invalid config file", zap.String("config", *configFile), zap.Error(err))
+		}
+		flagLevel := cfg.Level.Level()
+		if level, ok := serverConfig.Level(); ok {
+			cfg.Level.SetLevel(level)
+		}
+		serverOpts = append(serverOpts, serverConfig.Options()...)
+		serverOpts = append(serverOpts, pkg.WithConfigFile(*configFile), pkg.WithLogLevel(cfg.Level, flagLevel))
+	}
+	if *confirmWrites {
+		serverOpts = append(serverOpts, pkg.WithWriteConfirmation(*applyTokenTTL))
+	}
+	if *httpClientAuth != "" {
+		serverOpts = append(serverOpts, pkg.WithClientCertificateIdentity())
+	}
+	if *shutdownTimeout <= 0 {
+		logger.Fatal(fmt.Sprintf("--%s must be positive", shutdownTimeoutFlag))
+	}
+	serverOpts = append(serverOpts, pkg.WithShutdownTimeout(*shutdownTimeout))
+	var auditSinks []pkg.AuditSink
+	if *auditLogPath != "" {
+		if *serveStdio && *auditLogPath == pkg.AuditLogStdout {
+			logger.Fatal("the audit log cannot be written to stdout when serving over stdio", zap.String(auditLogFlag, *auditLogPath))
+		}
+		sink, err := pkg.OpenAuditFile(*auditLogPath)
+		if err != nil {
+			logger.Fatal("failed to open audit log", zap.Error(err))
+		}
+		auditSinks = append(auditSinks, sink)
+	}
+	if serverConfig != nil && serverConfig.AuditTable != nil {
+		sink, err := pkg.NewAuditTableSink(logger, config, *serverConfig.AuditTable)
+		if err != nil {
+			logger.Fatal("failed to set up audit table", zap.Error(err))
+		}
+		auditSinks = append(auditSinks, sink)
+		serverOpts = append(serverOpts, pkg.WithAuditTable(serverConfig.AuditTable))
+	}
+	if len(auditSinks) > 0 {
+		auditLog := pkg.NewAuditLog(logger, auditSinks...)
+		defer func() {
+			if err := auditLog.Close(); err != nil {
+				logger.Error("failed to close audit log", zap.Error(err))
+			}
+		}()
+		serverOpts = append(serverOpts, pkg.WithAuditLog(auditLog))
+	}
+	if *mcpSocket != "" {
+		serverOpts = append(serverOpts, pkg.WithUnixSocket(*mcpSocket))
+	}
+	if *serveSSE {
+		if !*serveHTTP {
+			logger.Fatal(fmt.Sprintf("--%s serves over HTTP; use it with --%s", sseFlag, serveHTTPFlag))
+		}
+		serverOpts = append(serverOpts, pkg.WithSSE())
+	}
+	if *metricsEndpoint {
+		if !*serveHTTP {
+			logger.Fatal(fmt.Sprintf("--%s serves metrics on the HTTP server; use --%s with --%s", metricsFlag, adminPortFlag, serveStdioFlag))
+		}
+		serverOpts = append(serverOpts, pkg.WithMetricsEndpoint())
+	}
+	if serverConfig != nil && serverConfig.ReadReplicas != nil {
+		router, err := pkg.NewReplicaRouter(logger, config, *serverConfig.ReadReplicas)
+		if err != nil {
+			logger.Fatal("failed to set up read replicas", zap.Error(err))
+		}
+		defer func() {
+			if err := router.Close(); err != nil {
+				logger.Error("failed to close read replica connections", zap.Error(err))
+			}
+		}()
+		serverOpts = append(serverOpts, pkg.WithReadReplicas(router))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if *traceExporter != "" {
+		if *serveStdio && *traceExporter == pkg.TraceExporterStdout {
+			logger.Fatal("traces cannot be written to stdout when serving over stdio", zap.String(traceExporterFlag, *traceExporter))
+		}
+		shutdownTracing, err := pkg.SetupTracing(ctx, *traceExporter, *traceFile)
+		if err != nil {
+			logger.Fatal("failed to set up tracing", zap.Error(err))
+		}
+		defer func() {
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err := shutdownTracing(shutdownCtx); err != nil {
+				logger.Error("failed to flush traces", zap.Error(err))
+			}
+		}()
+	}
+	if *adminPort != 0 {
+		go func() {
+			if err := pkg.ServeAdmin(ctx, logger, *adminPort); err != nil {
+				logger.Error("error serving Dolt MCP admin server", zap.Error(err))
+			}
+		}()
+	}
+
+	toolSet := &toolsets.PrimitiveToolSetV1{}
+	if *execScript {
+		toolSet.OptionalTools = append(toolSet.OptionalTools, tools.ExecScriptToolName)
+	}
+	serverOpts = append(serverOpts, toolsets.WithToolSet(toolSet))
+	if serverConfig != nil && len(serverConfig.Backends) > 0 {
+		if *serveHTTP {
+			for _, backend := range serverConfig.Backends {
+				serverOpts = append(serverOpts, pkg.WithBackend(backend, serverConfig.ColumnMasking, toolsets.WithToolSet(toolSet)))
+			}
+		} else {
+			logger.Warn("backends are only served over HTTP, serving the --database only")
+		}
+	}
+
 	if *serveHTTP {
 		srv, err := pkg.NewMCPHTTPServer(
 			logger,
@@ -315,22 +512,22 @@ func main() {
 			jwkClaimsMap,
 			*jwkURL,
 			tlsConfig,
-			toolsets.WithToolSet(&toolsets.PrimitiveToolSetV1{}))
+			serverOpts...)
 		if err != nil {
 			logger.Fatal("failed to create Dolt MCP HTTP server", zap.Error(err))
 		}
 
-		srv.ListenAndServe(context.Background())
+		srv.ListenAndServe(ctx)
 	} else if *serveStdio {
 		srv, err := pkg.NewMCPStdioServer(
 			logger,
 			config,
-			toolsets.WithToolSet(&toolsets.PrimitiveToolSetV1{}),
+			serverOpts...,
 		)
 		if err != nil {
 			logger.Fatal("failed to create Dolt MCP stdio server", zap.Error(err))
 		}
-		srv.ServeStdio(context.Background())
+		srv.ServeStdio(ctx)
 	} else {
 		flag.Usage()
 		os.Exit(1)
@@ -347,7 +544,10 @@ func validateArgs(dialectType db.DialectType, host, user string, port int, dbFil
 			return mustSupplyError(dbFileFlag)
 		}
 	} else {
-		if host == "" {
+		if host != "" && *socket != "" {
+			return fmt.Errorf("--%s and --%s are mutually exclusive", hostFlag, socketFlag)
+		}
+		if host == "" && *socket == "" {
 			return mustSupplyError(hostFlag)
 		}
 		if port == 0 {
@@ -358,9 +558,11 @@ func validateArgs(dialectType db.DialectType, host, user string, port int, dbFil
 		}
 	}
 	if *serveHTTP {
-		if *mcpPort == 0 {
+		if *mcpPort == 0 && *mcpSocket == "" {
 			return mustSupplyError(mcpPortFlag)
 		}
+	} else if *mcpSocket != "" {
+		return fmt.Errorf("--%s serves over HTTP; use it with --%s", mcpSocketFlag, serveHTTPFlag)
 	}
 	return nil
 }
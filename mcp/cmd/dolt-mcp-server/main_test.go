@@ -1,8 +1,10 @@
 package main
 
 import (
+	"crypto/tls"
 	"testing"
 
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
 	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
 )
 
@@ -10,7 +12,7 @@ func TestGetTLSConfigWithValidCertAndKey(t *testing.T) {
 	certFile := "testdata/server.crt"
 	keyFile := "testdata/server.key"
 
-	tlsConfig, err := getTLSConfig(certFile, keyFile, "")
+	tlsConfig, err := getTLSConfig(certFile, keyFile, "", "")
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -25,19 +27,19 @@ func TestGetTLSConfigWithValidCertAndKey(t *testing.T) {
 }
 
 func TestGetTLSConfigWithMissingCertOrKey(t *testing.T) {
-	_, err := getTLSConfig("", "testdata/server.key", "")
+	_, err := getTLSConfig("", "testdata/server.key", "", "")
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
 
-	_, err = getTLSConfig("testdata/server.crt", "", "")
+	_, err = getTLSConfig("testdata/server.crt", "", "", "")
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
 }
 
 func TestGetTLSConfigWithInvalidCertOrKey(t *testing.T) {
-	_, err := getTLSConfig("invalid.crt", "invalid.key", "")
+	_, err := getTLSConfig("invalid.crt", "invalid.key", "", "")
 	if err == nil {
 		t.Fatal("expected error, got nil")
 	}
@@ -48,7 +50,7 @@ func TestGetTLSConfigWithValidCA(t *testing.T) {
 	keyFile := "testdata/server.key"
 	caFile := "testdata/ca.crt"
 
-	tlsConfig, err := getTLSConfig(certFile, keyFile, caFile)
+	tlsConfig, err := getTLSConfig(certFile, keyFile, caFile, "")
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -58,6 +60,26 @@ func TestGetTLSConfigWithValidCA(t *testing.T) {
 	}
 }
 
+func TestGetTLSConfigWithClientAuth(t *testing.T) {
+	tlsConfig, err := getTLSConfig("testdata/server.crt", "testdata/server.key", "testdata/ca.crt", pkg.ClientAuthRequire)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if tlsConfig.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Fatalf("expected RequireAndVerifyClientCert, got %v", tlsConfig.ClientAuth)
+	}
+
+	if _, err := getTLSConfig("testdata/server.crt", "testdata/server.key", "", pkg.ClientAuthRequire); err == nil {
+		t.Fatal("expected error without a CA, got nil")
+	}
+	if _, err := getTLSConfig("", "", "testdata/ca.crt", pkg.ClientAuthVerifyIfGiven); err == nil {
+		t.Fatal("expected error without a cert and key, got nil")
+	}
+	if _, err := getTLSConfig("testdata/server.crt", "testdata/server.key", "testdata/ca.crt", "sometimes"); err == nil {
+		t.Fatal("expected error for an invalid client auth mode, got nil")
+	}
+}
+
 func TestParseClaimsMapWithValidInput(t *testing.T) {
 	input := "key1=value1,key2=value2"
 	expected := map[string]string{"key1": "value1", "key2": "value2"}
@@ -193,3 +215,29 @@ func intPtr(i int) *int {
 func boolPtr(b bool) *bool {
 	return &b
 }
+
+func TestValidateArgsWithSocket(t *testing.T) {
+	serveHTTP = boolPtr(true)
+	mcpPort = intPtr(8080)
+	t.Cleanup(func() {
+		*socket, *mcpSocket = "", ""
+	})
+
+	*socket = "/var/run/dolt/mysql.sock"
+	if err := validateArgs(db.DialectMySQL, "", "user", 3306, ""); err != nil {
+		t.Fatalf("expected a socket to replace the host, got %v", err)
+	}
+	if err := validateArgs(db.DialectMySQL, "localhost", "user", 3306, ""); err == nil {
+		t.Fatal("expected an error for both a host and a socket")
+	}
+
+	mcpPort = intPtr(0)
+	*mcpSocket = "/var/run/dolt-mcp.sock"
+	if err := validateArgs(db.DialectMySQL, "", "user", 3306, ""); err != nil {
+		t.Fatalf("expected an MCP socket to replace the MCP port, got %v", err)
+	}
+	serveHTTP = boolPtr(false)
+	if err := validateArgs(db.DialectMySQL, "", "user", 3306, ""); err == nil {
+		t.Fatal("expected an error for an MCP socket without --http")
+	}
+}
@@ -134,6 +134,21 @@ func testCreateDoltCommitToolInvalidArguments(s *testSuite, testBranchName strin
 				},
 			},
 		},
+		{
+			description:   "Invalid author argument",
+			errorExpected: true,
+			request: mcp.CallToolRequest{
+				Params: mcp.CallToolParams{
+					Name: tools.CreateDoltCommitToolName,
+					Arguments: map[string]any{
+						tools.MessageCallToolArgumentName:         "commit table commitme",
+						tools.AuthorCallToolArgumentName:          "no email",
+						tools.WorkingBranchCallToolArgumentName:   testBranchName,
+						tools.WorkingDatabaseCallToolArgumentName: mcpTestDatabaseName,
+					},
+				},
+			},
+		},
 		{
 			description:   "Empty message argument",
 			errorExpected: true,
@@ -228,3 +243,39 @@ func testCreateDoltCommitToolSuccess(s *testSuite, testBranchName string) {
 	require.NoError(s.t, err)
 	require.NotEqual(s.t, preCommitSha, postCommitSha)
 }
+
+func testCreateDoltCommitToolSuccessWithAuthor(s *testSuite, testBranchName string) {
+	ctx := context.Background()
+
+	client, err := NewMCPHTTPTestClient(testSuiteHTTPURL)
+	require.NoError(s.t, err)
+	require.NotNil(s.t, client)
+
+	serverInfo, err := client.Initialize(ctx)
+	require.NoError(s.t, err)
+	require.NotNil(s.t, serverInfo)
+
+	requireToolExists(s, ctx, client, serverInfo, tools.CreateDoltCommitToolName)
+
+	createDoltCommitCallToolRequest := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: tools.CreateDoltCommitToolName,
+			Arguments: map[string]any{
+				tools.MessageCallToolArgumentName:         "commit table commitme",
+				tools.AuthorCallToolArgumentName:          "Test Author <test.author@example.com>",
+				tools.WorkingBranchCallToolArgumentName:   testBranchName,
+				tools.WorkingDatabaseCallToolArgumentName: mcpTestDatabaseName,
+			},
+		},
+	}
+
+	createDoltCommitCallToolResult, err := client.CallTool(ctx, createDoltCommitCallToolRequest)
+	require.NoError(s.t, err)
+	require.NotNil(s.t, createDoltCommitCallToolResult)
+	require.False(s.t, createDoltCommitCallToolResult.IsError)
+
+	name, email, err := getLastCommitAuthor(s, ctx)
+	require.NoError(s.t, err)
+	require.Equal(s.t, "Test Author", name)
+	require.Equal(s.t, "test.author@example.com", email)
+}
@@ -0,0 +1,187 @@
+package integration_tests
+
+import (
+	"context"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/tools"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+)
+
+func testExecScriptToolInvalidArguments(s *testSuite, testBranchName string) {
+	ctx := context.Background()
+
+	client, err := NewMCPHTTPTestClient(testSuiteHTTPURL)
+	require.NoError(s.t, err)
+	require.NotNil(s.t, client)
+
+	serverInfo, err := client.Initialize(ctx)
+	require.NoError(s.t, err)
+	require.NotNil(s.t, serverInfo)
+
+	requireToolExists(s, ctx, client, serverInfo, tools.ExecScriptToolName)
+
+	requests := []struct {
+		description string
+		request     mcp.CallToolRequest
+	}{
+		{
+			description: "Missing statements argument",
+			request: mcp.CallToolRequest{
+				Params: mcp.CallToolParams{
+					Name: tools.ExecScriptToolName,
+					Arguments: map[string]any{
+						tools.WorkingBranchCallToolArgumentName:   testBranchName,
+						tools.WorkingDatabaseCallToolArgumentName: mcpTestDatabaseName,
+					},
+				},
+			},
+		},
+		{
+			description: "Empty statements argument",
+			request: mcp.CallToolRequest{
+				Params: mcp.CallToolParams{
+					Name: tools.ExecScriptToolName,
+					Arguments: map[string]any{
+						tools.WorkingBranchCallToolArgumentName:   testBranchName,
+						tools.WorkingDatabaseCallToolArgumentName: mcpTestDatabaseName,
+						tools.StatementsCallToolArgumentName:      []any{},
+					},
+				},
+			},
+		},
+		{
+			description: "Multiple statements in one entry",
+			request: mcp.CallToolRequest{
+				Params: mcp.CallToolParams{
+					Name: tools.ExecScriptToolName,
+					Arguments: map[string]any{
+						tools.WorkingBranchCallToolArgumentName:   testBranchName,
+						tools.WorkingDatabaseCallToolArgumentName: mcpTestDatabaseName,
+						tools.StatementsCallToolArgumentName:      []any{"SELECT 1; DELETE FROM people;"},
+					},
+				},
+			},
+		},
+		{
+			description: "Invalid SQL statement",
+			request: mcp.CallToolRequest{
+				Params: mcp.CallToolParams{
+					Name: tools.ExecScriptToolName,
+					Arguments: map[string]any{
+						tools.WorkingBranchCallToolArgumentName:   testBranchName,
+						tools.WorkingDatabaseCallToolArgumentName: mcpTestDatabaseName,
+						tools.StatementsCallToolArgumentName:      []any{"this is not sql"},
+					},
+				},
+			},
+		},
+	}
+
+	for _, request := range requests {
+		if shouldSkipCallToolCase(s, request.description) {
+			continue
+		}
+		execScriptCallToolResult, err := client.CallTool(ctx, request.request)
+		require.NoError(s.t, err)
+		require.NotNil(s.t, execScriptCallToolResult)
+		require.True(s.t, execScriptCallToolResult.IsError, request.description)
+		require.NotEmpty(s.t, execScriptCallToolResult.Content)
+	}
+}
+
+func testExecScriptToolSuccess(s *testSuite, testBranchName string) {
+	ctx := context.Background()
+
+	client, err := NewMCPHTTPTestClient(testSuiteHTTPURL)
+	require.NoError(s.t, err)
+	require.NotNil(s.t, client)
+
+	serverInfo, err := client.Initialize(ctx)
+	require.NoError(s.t, err)
+	require.NotNil(s.t, serverInfo)
+
+	requireToolExists(s, ctx, client, serverInfo, tools.ExecScriptToolName)
+
+	execScriptToolCallRequest := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: tools.ExecScriptToolName,
+			Arguments: map[string]any{
+				tools.WorkingBranchCallToolArgumentName:   testBranchName,
+				tools.WorkingDatabaseCallToolArgumentName: mcpTestDatabaseName,
+				tools.StatementsCallToolArgumentName: []any{
+					testExecToolQuery.Get(s.dialectType),
+					"SELECT COUNT(*) AS homers FROM people WHERE first_name = 'homer';",
+				},
+			},
+		},
+	}
+
+	execScriptCallToolResult, err := client.CallTool(ctx, execScriptToolCallRequest)
+	require.NoError(s.t, err)
+	require.False(s.t, execScriptCallToolResult.IsError)
+	resultStr, err := resultToString(execScriptCallToolResult)
+	require.NoError(s.t, err)
+	require.Contains(s.t, resultStr, tools.ExecScriptToolCallSuccessMessage)
+	require.Contains(s.t, resultStr, "statement 1:")
+	require.Contains(s.t, resultStr, "rows affected: 1")
+	require.Contains(s.t, resultStr, "statement 2:")
+	require.Contains(s.t, resultStr, "homers")
+}
+
+func testExecScriptToolRollsBackOnFailure(s *testSuite, testBranchName string) {
+	ctx := context.Background()
+
+	client, err := NewMCPHTTPTestClient(testSuiteHTTPURL)
+	require.NoError(s.t, err)
+	require.NotNil(s.t, client)
+
+	serverInfo, err := client.Initialize(ctx)
+	require.NoError(s.t, err)
+	require.NotNil(s.t, serverInfo)
+
+	countRequest := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: tools.QueryToolName,
+			Arguments: map[string]any{
+				tools.QueryCallToolArgumentName:           "SELECT COUNT(*) AS homers FROM people WHERE first_name = 'homer';",
+				tools.WorkingBranchCallToolArgumentName:   testBranchName,
+				tools.WorkingDatabaseCallToolArgumentName: mcpTestDatabaseName,
+			},
+		},
+	}
+
+	beforeResult, err := client.CallTool(ctx, countRequest)
+	require.NoError(s.t, err)
+	require.False(s.t, beforeResult.IsError)
+	before, err := resultToString(beforeResult)
+	require.NoError(s.t, err)
+
+	execScriptToolCallRequest := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: tools.ExecScriptToolName,
+			Arguments: map[string]any{
+				tools.WorkingBranchCallToolArgumentName:   testBranchName,
+				tools.WorkingDatabaseCallToolArgumentName: mcpTestDatabaseName,
+				tools.StatementsCallToolArgumentName: []any{
+					testExecToolQuery.Get(s.dialectType),
+					"INSERT INTO doesnotexist VALUES (1);",
+				},
+			},
+		},
+	}
+
+	execScriptCallToolResult, err := client.CallTool(ctx, execScriptToolCallRequest)
+	require.NoError(s.t, err)
+	require.True(s.t, execScriptCallToolResult.IsError)
+	resultStr, err := resultToString(execScriptCallToolResult)
+	require.NoError(s.t, err)
+	require.Contains(s.t, resultStr, "statement 2 failed")
+
+	afterResult, err := client.CallTool(ctx, countRequest)
+	require.NoError(s.t, err)
+	require.False(s.t, afterResult.IsError)
+	after, err := resultToString(afterResult)
+	require.NoError(s.t, err)
+	require.Equal(s.t, before, after)
+}
@@ -209,3 +209,87 @@ func testExecToolSuccess(s *testSuite, testBranchName string) {
 	require.NoError(s.t, err)
 	require.Contains(s.t, resultStr, "successfully executed write")
 }
+
+func testExecToolDryRun(s *testSuite, testBranchName string) {
+	ctx := context.Background()
+
+	client, err := NewMCPHTTPTestClient(testSuiteHTTPURL)
+	require.NoError(s.t, err)
+	require.NotNil(s.t, client)
+
+	serverInfo, err := client.Initialize(ctx)
+	require.NoError(s.t, err)
+	require.NotNil(s.t, serverInfo)
+
+	requireToolExists(s, ctx, client, serverInfo, tools.ExecToolName)
+
+	countRequest := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: tools.QueryToolName,
+			Arguments: map[string]any{
+				tools.QueryCallToolArgumentName:           "SELECT COUNT(*) AS homers FROM people WHERE first_name = 'homer';",
+				tools.WorkingBranchCallToolArgumentName:   testBranchName,
+				tools.WorkingDatabaseCallToolArgumentName: mcpTestDatabaseName,
+			},
+		},
+	}
+
+	beforeResult, err := client.CallTool(ctx, countRequest)
+	require.NoError(s.t, err)
+	require.False(s.t, beforeResult.IsError)
+	before, err := resultToString(beforeResult)
+	require.NoError(s.t, err)
+
+	execToolCallRequest := mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: tools.ExecToolName,
+			Arguments: map[string]any{
+				tools.QueryCallToolArgumentName:           testExecToolQuery.Get(s.dialectType),
+				tools.WorkingBranchCallToolArgumentName:   testBranchName,
+				tools.WorkingDatabaseCallToolArgumentName: mcpTestDatabaseName,
+				tools.DryRunCallToolArgumentName:          true,
+			},
+		},
+	}
+
+	execCallToolResult, err := client.CallTool(ctx, execToolCallRequest)
+	require.NoError(s.t, err)
+	require.False(s.t, execCallToolResult.IsError)
+	resultStr, err := resultToString(execCallToolResult)
+	require.NoError(s.t, err)
+	require.Contains(s.t, resultStr, "dry run: no changes were committed")
+	require.Contains(s.t, resultStr, "people")
+
+	afterResult, err := client.CallTool(ctx, countRequest)
+	require.NoError(s.t, err)
+	require.False(s.t, afterResult.IsError)
+	after, err := resultToString(afterResult)
+	require.NoError(s.t, err)
+	require.Equal(s.t, before, after)
+}
+
+func testCreateDoltCommitToolRejectsDryRun(s *testSuite, testBranchName string) {
+	ctx := context.Background()
+
+	client, err := NewMCPHTTPTestClient(testSuiteHTTPURL)
+	require.NoError(s.t, err)
+	_, err = client.Initialize(ctx)
+	require.NoError(s.t, err)
+
+	result, err := client.CallTool(ctx, mcp.CallToolRequest{
+		Params: mcp.CallToolParams{
+			Name: tools.CreateDoltCommitToolName,
+			Arguments: map[string]any{
+				tools.WorkingBranchCallToolArgumentName:   testBranchName,
+				tools.WorkingDatabaseCallToolArgumentName: mcpTestDatabaseName,
+				tools.MessageCallToolArgumentName:         "dry run commit",
+				tools.DryRunCallToolArgumentName:          true,
+			},
+		},
+	})
+	require.NoError(s.t, err)
+	require.True(s.t, result.IsError)
+	resultStr, err := resultToString(result)
+	require.NoError(s.t, err)
+	require.Contains(s.t, resultStr, "does not support dry_run")
+}
@@ -40,6 +40,10 @@ var selectLastCommitHashSQL = DialectSQL{
 	db.DialectDoltLite: "SELECT commit_hash FROM dolt_log ORDER BY date DESC LIMIT 1;",
 }
 
+// selectLastCommitAuthorSQL selects the author of the most recent commit in
+// dolt_log. Identical in every dialect.
+var selectLastCommitAuthorSQL = "SELECT committer, email FROM dolt_log ORDER BY date DESC LIMIT 1;"
+
 // selectCommitHashesSQL selects every commit hash reachable from the current
 // HEAD, most recent first. Identical in both dialects.
 var selectCommitHashesSQL = "SELECT commit_hash FROM dolt_log ORDER BY date DESC;"
@@ -163,6 +167,19 @@ func getCommitHashes(s *testSuite, ctx context.Context) ([]string, error) {
 	return hashes, nil
 }
 
+func getLastCommitAuthor(s *testSuite, ctx context.Context) (string, string, error) {
+	var name, email string
+	if err := s.refreshDoltLiteSession(); err != nil {
+		return "", "", err
+	}
+
+	row := s.testDb.QueryRowContext(ctx, selectLastCommitAuthorSQL)
+	if err := row.Scan(&name, &email); err != nil {
+		return "", "", err
+	}
+	return name, email, row.Err()
+}
+
 func getLastCommitHash(s *testSuite, ctx context.Context) (string, error) {
 	var hash string
 	if err := s.refreshDoltLiteSession(); err != nil {
@@ -17,6 +17,7 @@ import (
 
 	"github.com/dolthub/dolt-mcp/mcp/pkg"
 	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/tools"
 	"github.com/dolthub/dolt-mcp/mcp/pkg/toolsets"
 	"github.com/dolthub/dolt/go/performance/utils/benchmark_runner"
 	"github.com/dolthub/dolt/go/store/constants"
@@ -578,7 +579,7 @@ func seedDatabase(ctx context.Context, testDb *sql.DB, dialect db.Dialect, diale
 func finishTestSuite(ctx context.Context, dialect db.Dialect, dialectType db.DialectType, binPath, databaseParentDir, databaseDir, dsn string, testDb *sql.DB, server serverProcess, mcpConfig db.Config) (*testSuite, error) {
 	logger := zap.NewNop()
 
-	mcpServer, err := pkg.NewMCPHTTPServer(logger, mcpConfig, mcpServerPort, nil, "", nil, toolsets.WithToolSet(&toolsets.PrimitiveToolSetV1{}))
+	mcpServer, err := pkg.NewMCPHTTPServer(logger, mcpConfig, mcpServerPort, nil, "", nil, toolsets.WithToolSet(&toolsets.PrimitiveToolSetV1{OptionalTools: []string{tools.ExecScriptToolName}}))
 	if err != nil {
 		server.Stop()
 		testDb.Close()
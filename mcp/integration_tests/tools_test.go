@@ -87,6 +87,17 @@ func TestTools(t *testing.T) {
 	t.Run("TestExecTool", func(t *testing.T) {
 		RunTest(t, "TestInvalidArguments", testExecToolInvalidArguments)
 		RunTest(t, "TestSuccess", testExecToolSuccess)
+		RunTest(t, "TestDryRun", testExecToolDryRun)
+		RunTest(t, "TestDryRunRejectedForCommits", testCreateDoltCommitToolRejectsDryRun)
+	})
+	t.Run("TestWriteConfirmation", func(t *testing.T) {
+		RunTest(t, "TestPlanConfirmApply", testWriteConfirmationPlanConfirmApply)
+		RunTest(t, "TestDoesNotRunDDL", testWriteConfirmationDoesNotRunDDL)
+	})
+	t.Run("TestExecScriptTool", func(t *testing.T) {
+		RunTest(t, "TestInvalidArguments", testExecScriptToolInvalidArguments)
+		RunTest(t, "TestSuccess", testExecScriptToolSuccess)
+		RunTest(t, "TestRollsBackOnFailure", testExecScriptToolRollsBackOnFailure)
 	})
 	t.Run("TestCreateDoltBranchFromHeadTool", func(t *testing.T) {
 		RunTest(t, "TestInvalidArguments", testCreateDoltBranchFromHeadToolInvalidArguments)
@@ -123,6 +134,7 @@ func TestTools(t *testing.T) {
 	t.Run("TestCreateDoltCommitTool", func(t *testing.T) {
 		RunTest(t, "TestInvalidArguments", testCreateDoltCommitToolInvalidArguments)
 		RunTestWithSetupSQLSkipDoltCommit(t, "TestSuccess", testCreateDoltCommitSetupSQL, testCreateDoltCommitToolSuccess)
+		RunTestWithSetupSQLSkipDoltCommit(t, "TestSuccessWithAuthor", testCreateDoltCommitSetupSQL, testCreateDoltCommitToolSuccessWithAuthor)
 	})
 	t.Run("TestDoltResetSoftTool", func(t *testing.T) {
 		RunTest(t, "TestInvalidArguments", testDoltResetSoftToolInvalidArguments)
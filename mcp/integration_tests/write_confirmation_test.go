@@ -0,0 +1,157 @@
+package integration_tests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/tools"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/toolsets"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const writeConfirmationServerPort = 8082
+
+var testWriteConfirmationAlterTableQuery = DialectSQL{
+	db.DialectMySQL:    "ALTER TABLE people ADD COLUMN confirmed_age INT;",
+	db.DialectPostgres: "ALTER TABLE people ADD COLUMN confirmed_age INT;",
+}
+
+// startWriteConfirmationServer serves the suite's database with write
+// confirmation on a port of its own, until the returned function is called.
+func startWriteConfirmationServer(s *testSuite) (string, func()) {
+	srv, err := pkg.NewMCPHTTPServer(zap.NewNop(), s.mcpServer.DBConfig(), writeConfirmationServerPort, nil, "", nil,
+		toolsets.WithToolSet(&toolsets.PrimitiveToolSetV1{}),
+		pkg.WithWriteConfirmation(time.Minute),
+	)
+	require.NoError(s.t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		srv.ListenAndServe(ctx)
+	}()
+
+	healthURL := fmt.Sprintf("http://0.0.0.0:%d%s", writeConfirmationServerPort, pkg.HealthzPath)
+	require.Eventually(s.t, func() bool {
+		resp, err := http.Get(healthURL)
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, 10*time.Second, 50*time.Millisecond)
+
+	return fmt.Sprintf("http://0.0.0.0:%d/mcp", writeConfirmationServerPort), func() {
+		cancel()
+		<-done
+	}
+}
+
+// applyTokenFromPreview returns the apply token in the result of a planned
+// write.
+func applyTokenFromPreview(s *testSuite, preview string) string {
+	marker := tools.ApplyTokenCallToolArgumentName + "=\""
+	start := strings.Index(preview, marker)
+	require.GreaterOrEqual(s.t, start, 0, "expected an apply token in %q", preview)
+	token := preview[start+len(marker):]
+	return token[:strings.Index(token, "\"")]
+}
+
+func testWriteConfirmationPlanConfirmApply(s *testSuite, testBranchName string) {
+	if s.dialectType == db.DialectDoltLite {
+		// The confirmation server would close the DoltLite database the
+		// suite shares with it when it stops.
+		s.t.Skip("write confirmation is tested against sql-servers")
+	}
+	ctx := context.Background()
+	url, stop := startWriteConfirmationServer(s)
+	defer stop()
+
+	client, err := NewMCPHTTPTestClient(url)
+	require.NoError(s.t, err)
+	_, err = client.Initialize(ctx)
+	require.NoError(s.t, err)
+
+	requireTableHasNRows(s, ctx, "people", 3)
+
+	arguments := map[string]any{
+		tools.QueryCallToolArgumentName:           testExecToolQuery.Get(s.dialectType),
+		tools.WorkingBranchCallToolArgumentName:   testBranchName,
+		tools.WorkingDatabaseCallToolArgumentName: mcpTestDatabaseName,
+	}
+	planResult, err := client.CallTool(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Name: tools.ExecToolName, Arguments: arguments}})
+	require.NoError(s.t, err)
+	preview, err := resultToString(planResult)
+	require.NoError(s.t, err)
+	require.Contains(s.t, preview, "rows affected: 1")
+	require.Contains(s.t, preview, "people")
+	requireTableHasNRows(s, ctx, "people", 3)
+
+	token := applyTokenFromPreview(s, preview)
+	applyArguments := map[string]any{tools.ApplyTokenCallToolArgumentName: token}
+	for name, value := range arguments {
+		applyArguments[name] = value
+	}
+	applyResult, err := client.CallTool(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Name: tools.ExecToolName, Arguments: applyArguments}})
+	require.NoError(s.t, err)
+	applied, err := resultToString(applyResult)
+	require.NoError(s.t, err)
+	require.Contains(s.t, applied, "successfully executed write")
+	requireTableHasNRows(s, ctx, "people", 4)
+
+	reusedResult, err := client.CallTool(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{Name: tools.ExecToolName, Arguments: applyArguments}})
+	require.NoError(s.t, err)
+	require.True(s.t, reusedResult.IsError)
+	requireTableHasNRows(s, ctx, "people", 4)
+}
+
+func testWriteConfirmationDoesNotRunDDL(s *testSuite, testBranchName string) {
+	if s.dialectType == db.DialectDoltLite {
+		s.t.Skip("write confirmation is tested against sql-servers")
+	}
+	ctx := context.Background()
+	url, stop := startWriteConfirmationServer(s)
+	defer stop()
+
+	client, err := NewMCPHTTPTestClient(url)
+	require.NoError(s.t, err)
+	_, err = client.Initialize(ctx)
+	require.NoError(s.t, err)
+
+	describe := func() string {
+		result, err := client.CallTool(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{
+			Name: tools.DescribeTableToolName,
+			Arguments: map[string]any{
+				tools.TableCallToolArgumentName:           "people",
+				tools.WorkingBranchCallToolArgumentName:   testBranchName,
+				tools.WorkingDatabaseCallToolArgumentName: mcpTestDatabaseName,
+			},
+		}})
+		require.NoError(s.t, err)
+		text, err := resultToString(result)
+		require.NoError(s.t, err)
+		return text
+	}
+
+	planResult, err := client.CallTool(ctx, mcp.CallToolRequest{Params: mcp.CallToolParams{
+		Name: tools.AlterTableToolName,
+		Arguments: map[string]any{
+			tools.QueryCallToolArgumentName:           testWriteConfirmationAlterTableQuery.Get(s.dialectType),
+			tools.WorkingBranchCallToolArgumentName:   testBranchName,
+			tools.WorkingDatabaseCallToolArgumentName: mcpTestDatabaseName,
+		},
+	}})
+	require.NoError(s.t, err)
+	preview, err := resultToString(planResult)
+	require.NoError(s.t, err)
+	require.Contains(s.t, preview, "not previewed")
+	require.NotContains(s.t, describe(), "confirmed_age")
+}
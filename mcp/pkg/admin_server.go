@@ -0,0 +1,41 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const adminShutdownTimeout = 5 * time.Second
+
+// ServeAdmin serves the admin endpoints, such as metrics, over HTTP on port
+// until ctx is done. It runs alongside either transport, so that a server
+// serving MCP over stdio can still be monitored.
+func ServeAdmin(ctx context.Context, logger *zap.Logger, port int) error {
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, MetricsHandler())
+
+	srv := &http.Server{
+		Addr:              fmt.Sprintf(":%d", port),
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), adminShutdownTimeout)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			logger.Error("failed to shutdown admin server", zap.Error(err))
+		}
+	}()
+
+	logger.Info("Dolt MCP admin server ready. Accepting HTTP connections.", zap.String("addr", srv.Addr))
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package pkg
+
+import (
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// withAPIKeyAuth authenticates requests carrying an API key, either in the
+// X-API-Key header or as a bearer token that is not a JWT. Requests without
+// one are passed to fallback, such as the JWT middleware, or rejected when
+// fallback is nil.
+func withAPIKeyAuth(logger *zap.Logger, next http.Handler, keys *APIKeys, fallback http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := apiKeyFromRequest(r)
+		if key == "" {
+			// The caller may already be identified by a client certificate.
+			if IdentityFromContext(r.Context()) != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if fallback != nil {
+				fallback.ServeHTTP(w, r)
+				return
+			}
+			observeAuthFailure(AuthMethodAPIKey, authFailureMissing)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		if err := keys.Reload(); err != nil {
+			logger.Warn("failed to reload api keys, using the keys read before", zap.Error(err))
+		}
+		identity := keys.Authenticate(key)
+		if identity == nil {
+			logger.Info("unable to authorize api key")
+			observeAuthFailure(AuthMethodAPIKey, authFailureInvalid)
+			if fallback != nil {
+				// Clients that cannot use the key can get a token instead.
+				writeBearerChallenge(w, r, "invalid_token", "The API key is invalid")
+				return
+			}
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		logger.Info("MCP Auth with API key", zap.String("name", identity.Subject))
+		next.ServeHTTP(w, r.WithContext(ContextWithIdentity(r.Context(), identity)))
+	})
+}
+
+func apiKeyFromRequest(r *http.Request) string {
+	if key := strings.TrimSpace(r.Header.Get(APIKeyHeader)); key != "" {
+		return key
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	token = strings.TrimSpace(token)
+	// A JWT is three dot separated parts; anything else is an API key.
+	if !ok || strings.Count(token, ".") == 2 {
+		return ""
+	}
+	return token
+}
@@ -0,0 +1,207 @@
+package pkg
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// APIKeyHeader is the header clients send their API key in. Keys can
+	// also be sent as a bearer token.
+	APIKeyHeader = "X-API-Key"
+	// APIKeyIssuer is the issuer of the identities of API key callers.
+	APIKeyIssuer = "api-key"
+
+	apiKeyHashPrefix = "sha256:"
+)
+
+// APIKeys authenticates HTTP callers by static keys, and by HMAC tokens
+// signed by HMACKeys. Only hashes of the static keys are stored.
+type APIKeys struct {
+	Keys []APIKey `yaml:"keys" json:"keys"`
+	// HMACKeys sign tokens that name a subject, scopes, and an expiry, as
+	// made by SignHMACToken.
+	HMACKeys []HMACKey `yaml:"hmac_keys" json:"hmac_keys"`
+	// File names a YAML file with more keys, under top-level keys and
+	// hmac_keys entries. It is read again whenever it changes, so that keys
+	// can be added, rotated and revoked without restarting the server.
+	File string `yaml:"file" json:"file"`
+
+	mu           sync.Mutex
+	fileKeys     []APIKey
+	fileHMACKeys []HMACKey
+	fileModTime  time.Time
+	fileSize     int64
+}
+
+// APIKey is a key that authenticates a caller named Name.
+type APIKey struct {
+	Name string `yaml:"name" json:"name"`
+	// Hash is "sha256:" followed by the hex encoded SHA-256 digest of the
+	// key.
+	Hash string `yaml:"hash" json:"hash"`
+	// Scopes limits the key to the tools matching one of these globs. An
+	// empty list allows every tool.
+	Scopes []string `yaml:"scopes" json:"scopes"`
+}
+
+type apiKeysFile struct {
+	Keys     []APIKey  `yaml:"keys"`
+	HMACKeys []HMACKey `yaml:"hmac_keys"`
+}
+
+// HashAPIKey returns the hash of key in the form APIKey.Hash expects.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return apiKeyHashPrefix + hex.EncodeToString(sum[:])
+}
+
+func (k *APIKey) Validate() error {
+	if k.Name == "" {
+		return errors.New("api key has no name")
+	}
+	digest, ok := strings.CutPrefix(k.Hash, apiKeyHashPrefix)
+	if decoded, err := hex.DecodeString(digest); !ok || err != nil || len(decoded) != sha256.Size {
+		return fmt.Errorf("invalid hash for api key %s: expected %s followed by a hex encoded SHA-256 digest", k.Name, apiKeyHashPrefix)
+	}
+	for _, scope := range k.Scopes {
+		if _, err := path.Match(scope, ""); err != nil {
+			return fmt.Errorf("invalid scope %q for api key %s: %w", scope, k.Name, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks every key and loads File.
+func (a *APIKeys) Validate() error {
+	if err := validateAPIKeys(a.Keys); err != nil {
+		return err
+	}
+	if err := validateHMACKeys(a.HMACKeys); err != nil {
+		return err
+	}
+	if a.File == "" {
+		return nil
+	}
+	if err := a.Reload(); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := validateAPIKeys(append(a.Keys[:len(a.Keys):len(a.Keys)], a.fileKeys...)); err != nil {
+		return err
+	}
+	return validateHMACKeys(append(a.HMACKeys[:len(a.HMACKeys):len(a.HMACKeys)], a.fileHMACKeys...))
+}
+
+func validateAPIKeys(keys []APIKey) error {
+	names := map[string]bool{}
+	for _, key := range keys {
+		if err := key.Validate(); err != nil {
+			return err
+		}
+		if names[key.Name] {
+			return fmt.Errorf("duplicate api key name %s", key.Name)
+		}
+		names[key.Name] = true
+	}
+	return nil
+}
+
+// Reload reads File again if it has changed since it was last read. When
+// the file cannot be read or is invalid, the keys read before are kept.
+func (a *APIKeys) Reload() error {
+	if a.File == "" {
+		return nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	info, err := os.Stat(a.File)
+	if err != nil {
+		return fmt.Errorf("failed to read api keys file %s: %w", a.File, err)
+	}
+	if info.ModTime().Equal(a.fileModTime) && info.Size() == a.fileSize {
+		return nil
+	}
+
+	data, err := os.ReadFile(a.File)
+	if err != nil {
+		return fmt.Errorf("failed to read api keys file %s: %w", a.File, err)
+	}
+	file := apiKeysFile{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse api keys file %s: %w", a.File, err)
+	}
+	if err := validateAPIKeys(append(a.Keys[:len(a.Keys):len(a.Keys)], file.Keys...)); err != nil {
+		return fmt.Errorf("invalid api keys file %s: %w", a.File, err)
+	}
+	if err := validateHMACKeys(append(a.HMACKeys[:len(a.HMACKeys):len(a.HMACKeys)], file.HMACKeys...)); err != nil {
+		return fmt.Errorf("invalid api keys file %s: %w", a.File, err)
+	}
+
+	a.fileKeys = file.Keys
+	a.fileHMACKeys = file.HMACKeys
+	a.fileModTime = info.ModTime()
+	a.fileSize = info.Size()
+	return nil
+}
+
+// Authenticate returns the identity of the caller presenting key, or nil
+// when key is neither a known key nor an unexpired HMAC token signed by a
+// known HMAC key.
+func (a *APIKeys) Authenticate(key string) *Identity {
+	if isHMACToken(key) {
+		a.mu.Lock()
+		hmacKeys := append(a.HMACKeys[:len(a.HMACKeys):len(a.HMACKeys)], a.fileHMACKeys...)
+		a.mu.Unlock()
+		return authenticateHMACToken(hmacKeys, key, time.Now())
+	}
+
+	sum := sha256.Sum256([]byte(key))
+
+	a.mu.Lock()
+	keys := append(a.Keys[:len(a.Keys):len(a.Keys)], a.fileKeys...)
+	a.mu.Unlock()
+
+	var match *APIKey
+	for i := range keys {
+		digest, _ := hex.DecodeString(strings.TrimPrefix(keys[i].Hash, apiKeyHashPrefix))
+		// Every key is compared so that the time taken does not reveal
+		// which key matched.
+		if subtle.ConstantTimeCompare(digest, sum[:]) == 1 && match == nil {
+			match = &keys[i]
+		}
+	}
+	if match == nil {
+		return nil
+	}
+
+	var scopes []string
+	if len(match.Scopes) > 0 {
+		scopes = match.Scopes
+	}
+	return &Identity{
+		Subject: match.Name,
+		Issuer:  APIKeyIssuer,
+		Scopes:  scopes,
+		Claims: map[string]any{
+			"sub": match.Name,
+			"iss": APIKeyIssuer,
+		},
+	}
+}
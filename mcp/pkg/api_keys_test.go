@@ -0,0 +1,149 @@
+package pkg
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestAPIKeysAuthenticate(t *testing.T) {
+	keys := &APIKeys{Keys: []APIKey{
+		{Name: "ci", Hash: HashAPIKey("ci-secret"), Scopes: []string{"query", "list_*"}},
+		{Name: "admin", Hash: HashAPIKey("admin-secret")},
+	}}
+	if err := keys.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	identity := keys.Authenticate("ci-secret")
+	if identity == nil || identity.Subject != "ci" || identity.Issuer != APIKeyIssuer {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+	if !identity.AllowsTool("list_databases") || identity.AllowsTool("exec") {
+		t.Fatalf("expected ci to be limited to its scopes, got %v", identity.Scopes)
+	}
+
+	identity = keys.Authenticate("admin-secret")
+	if identity == nil || identity.Scopes != nil || !identity.AllowsTool("exec") {
+		t.Fatalf("expected admin to be unlimited, got %+v", identity)
+	}
+
+	if identity := keys.Authenticate("wrong"); identity != nil {
+		t.Fatalf("expected no identity for an unknown key, got %+v", identity)
+	}
+}
+
+func TestAPIKeysReloadsFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "keys.yaml")
+	writeKeys := func(contents string, modTime time.Time) {
+		t.Helper()
+		if err := os.WriteFile(file, []byte(contents), 0o600); err != nil {
+			t.Fatalf("failed to write keys file: %v", err)
+		}
+		if err := os.Chtimes(file, modTime, modTime); err != nil {
+			t.Fatalf("failed to set keys file time: %v", err)
+		}
+	}
+	now := time.Now()
+	writeKeys("keys:\n  - name: old\n    hash: "+HashAPIKey("old-secret")+"\n", now.Add(-time.Minute))
+
+	keys := &APIKeys{File: file}
+	if err := keys.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	if keys.Authenticate("old-secret") == nil {
+		t.Fatalf("expected the key from the file to authenticate")
+	}
+
+	writeKeys("keys:\n  - name: new\n    hash: "+HashAPIKey("new-secret")+"\n", now)
+	if err := keys.Reload(); err != nil {
+		t.Fatalf("unexpected reload error: %v", err)
+	}
+	if keys.Authenticate("old-secret") != nil || keys.Authenticate("new-secret") == nil {
+		t.Fatalf("expected the rotated key to replace the old one")
+	}
+
+	writeKeys("keys:\n  - name: broken\n    hash: md5:abc\n", now.Add(time.Minute))
+	if err := keys.Reload(); err == nil {
+		t.Fatalf("expected an error reloading an invalid file")
+	}
+	if keys.Authenticate("new-secret") == nil {
+		t.Fatalf("expected the keys read before to be kept after an invalid reload")
+	}
+}
+
+func TestAPIKeysValidate(t *testing.T) {
+	invalid := []*APIKeys{
+		{Keys: []APIKey{{Hash: HashAPIKey("secret")}}},
+		{Keys: []APIKey{{Name: "ci", Hash: "secret"}}},
+		{Keys: []APIKey{{Name: "ci", Hash: "sha256:abc"}}},
+		{Keys: []APIKey{{Name: "ci", Hash: HashAPIKey("a")}, {Name: "ci", Hash: HashAPIKey("b")}}},
+		{Keys: []APIKey{{Name: "ci", Hash: HashAPIKey("a"), Scopes: []string{"[oops"}}}},
+		{File: filepath.Join(t.TempDir(), "missing.yaml")},
+	}
+	for _, keys := range invalid {
+		if err := keys.Validate(); err == nil {
+			t.Fatalf("expected an error for %+v", keys.Keys)
+		}
+	}
+}
+
+func TestWithAPIKeyAuth(t *testing.T) {
+	keys := &APIKeys{Keys: []APIKey{{Name: "ci", Hash: HashAPIKey("ci-secret")}}, HMACKeys: []HMACKey{testHMACKey}}
+	token, err := SignHMACToken(testHMACKey, "ci", nil, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var identity *Identity
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity = IdentityFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	fallbackCalled := false
+	fallback := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fallbackCalled = true
+		w.WriteHeader(http.StatusTeapot)
+	})
+
+	tests := []struct {
+		name     string
+		header   string
+		value    string
+		fallback http.Handler
+		status   int
+	}{
+		{"api key header", APIKeyHeader, "ci-secret", nil, http.StatusOK},
+		{"bearer api key", "Authorization", "Bearer ci-secret", nil, http.StatusOK},
+		{"bearer hmac token", "Authorization", "Bearer " + token, fallback, http.StatusOK},
+		{"unknown api key", APIKeyHeader, "wrong", fallback, http.StatusUnauthorized},
+		{"missing api key", "", "", nil, http.StatusUnauthorized},
+		{"jwt passed to fallback", "Authorization", "Bearer a.b.c", fallback, http.StatusTeapot},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			identity, fallbackCalled = nil, false
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/mcp", nil)
+			if test.header != "" {
+				req.Header.Set(test.header, test.value)
+			}
+			withAPIKeyAuth(zap.NewNop(), next, keys, test.fallback).ServeHTTP(rec, req)
+
+			if rec.Code != test.status {
+				t.Fatalf("expected status %d, got %d", test.status, rec.Code)
+			}
+			if test.status == http.StatusOK && (identity == nil || identity.Subject != "ci") {
+				t.Fatalf("unexpected identity: %+v", identity)
+			}
+			if fallbackCalled != (test.status == http.StatusTeapot) {
+				t.Fatalf("unexpected fallback call: %v", fallbackCalled)
+			}
+		})
+	}
+}
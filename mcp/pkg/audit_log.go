@@ -0,0 +1,188 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"go.uber.org/zap"
+)
+
+const (
+	// AuditLogStdout and AuditLogStderr are the --audit-log values that
+	// write the audit log to the standard streams instead of a file.
+	AuditLogStdout = "stdout"
+	AuditLogStderr = "stderr"
+
+	redactedValue = "[REDACTED]"
+)
+
+// redactedArgumentPattern matches the names of tool arguments whose values
+// are left out of audit records.
+var redactedArgumentPattern = regexp.MustCompile(`(?i)password|secret|token|credential|api_?key`)
+
+// AuditRecord describes one tool call.
+type AuditRecord struct {
+	Time   time.Time    `json:"time"`
+	Caller *AuditCaller `json:"caller,omitempty"`
+	Tool   string       `json:"tool"`
+	// Arguments are the arguments of the call, with secrets redacted.
+	Arguments map[string]any `json:"arguments,omitempty"`
+	Database  string         `json:"database,omitempty"`
+	Branch    string         `json:"branch,omitempty"`
+	// Statements are the SQL statements the call ran, with URL credentials
+	// redacted.
+	Statements   []db.StatementRecord `json:"statements,omitempty"`
+	RowsAffected int64                `json:"rows_affected"`
+	// CommitHash is the last commit the call created.
+	CommitHash string  `json:"commit_hash,omitempty"`
+	DurationMS float64 `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// AuditCaller is the authenticated caller of a tool call.
+type AuditCaller struct {
+	Subject string `json:"subject"`
+	Issuer  string `json:"issuer,omitempty"`
+}
+
+// AuditSink stores audit records.
+type AuditSink interface {
+	WriteAuditRecord(record AuditRecord) error
+	Close() error
+}
+
+// AuditLog writes an AuditRecord for every tool call to each of its sinks.
+// It is written regardless of the log level.
+type AuditLog struct {
+	sinks  []AuditSink
+	logger *zap.Logger
+}
+
+// NewAuditLog returns an audit log writing to sinks. Failures to write are
+// logged to logger.
+func NewAuditLog(logger *zap.Logger, sinks ...AuditSink) *AuditLog {
+	return &AuditLog{sinks: sinks, logger: logger}
+}
+
+// Write writes record to every sink. Failures are logged, since they must
+// not fail the tool call being audited.
+func (a *AuditLog) Write(record AuditRecord) {
+	for _, sink := range a.sinks {
+		if err := sink.WriteAuditRecord(record); err != nil && a.logger != nil {
+			a.logger.Error("failed to write audit record", zap.String("tool", record.Tool), zap.Error(err))
+		}
+	}
+}
+
+// Close closes every sink, writing out the records they hold.
+func (a *AuditLog) Close() error {
+	var errs []error
+	for _, sink := range a.sinks {
+		errs = append(errs, sink.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// auditWriter writes audit records as lines of JSON.
+type auditWriter struct {
+	mu     sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+// OpenAuditFile returns a sink appending to the file at path, or writing to
+// standard output or standard error for AuditLogStdout and AuditLogStderr.
+func OpenAuditFile(path string) (AuditSink, error) {
+	switch path {
+	case AuditLogStdout:
+		return NewAuditWriter(os.Stdout), nil
+	case AuditLogStderr:
+		return NewAuditWriter(os.Stderr), nil
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log %s: %w", path, err)
+	}
+	return &auditWriter{w: file, closer: file}, nil
+}
+
+// NewAuditWriter returns a sink writing to w.
+func NewAuditWriter(w io.Writer) AuditSink {
+	return &auditWriter{w: w}
+}
+
+func (a *auditWriter) WriteAuditRecord(record AuditRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err = a.w.Write(append(data, '\n'))
+	return err
+}
+
+func (a *auditWriter) Close() error {
+	if a.closer == nil {
+		return nil
+	}
+	return a.closer.Close()
+}
+
+// NewAuditCaller returns the caller of an audit record, or nil for a caller
+// that is not authenticated.
+func NewAuditCaller(identity *Identity) *AuditCaller {
+	if identity == nil {
+		return nil
+	}
+	return &AuditCaller{Subject: identity.Subject, Issuer: identity.Issuer}
+}
+
+// RedactAuditArguments returns a copy of arguments with the values of
+// secret arguments replaced, and credentials removed from URLs and SQL.
+func RedactAuditArguments(arguments map[string]any) map[string]any {
+	if len(arguments) == 0 {
+		return nil
+	}
+	redacted := make(map[string]any, len(arguments))
+	for name, value := range arguments {
+		switch v := value.(type) {
+		case string:
+			if redactedArgumentPattern.MatchString(name) {
+				redacted[name] = redactedValue
+			} else {
+				redacted[name] = RedactAuditSQL(v)
+			}
+		case []any:
+			items := make([]any, len(v))
+			for i, item := range v {
+				if s, ok := item.(string); ok {
+					items[i] = RedactAuditSQL(s)
+				} else {
+					items[i] = item
+				}
+			}
+			redacted[name] = items
+		default:
+			if redactedArgumentPattern.MatchString(name) {
+				redacted[name] = redactedValue
+			} else {
+				redacted[name] = value
+			}
+		}
+	}
+	return redacted
+}
+
+// RedactAuditSQL removes the credentials of URLs in s, and the passwords
+// set by statements such as CREATE USER ... IDENTIFIED BY '...'.
+func RedactAuditSQL(s string) string {
+	return db.RedactCredentials(s)
+}
@@ -0,0 +1,388 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	DefaultAuditTableName      = "mcp_audit"
+	DefaultAuditBranch         = "mcp_audit"
+	DefaultAuditCommitInterval = time.Minute
+	DefaultAuditCommitMessage  = "Record MCP tool calls"
+
+	// auditTableBatchSize is the number of pending records that are written
+	// without waiting for the commit interval.
+	auditTableBatchSize = 500
+	// maxPendingAuditRecords bounds the records held while the database
+	// cannot be written. The oldest records are dropped beyond it.
+	maxPendingAuditRecords = 10000
+	auditTableWriteTimeout = 30 * time.Second
+)
+
+var ErrAuditRecordsDropped = errors.New("audit records dropped")
+
+// AuditTable appends audit records to a table of a Dolt database and commits
+// them periodically, so that the audit log is versioned along with the data.
+// Client SQL may not reference the table, and neither tools nor client SQL
+// may change the audit branch.
+type AuditTable struct {
+	// Database is the database holding the table. Defaults to the database
+	// the server connects to.
+	Database string `yaml:"database" json:"database"`
+	// Branch is the branch the records are committed to. Defaults to
+	// mcp_audit, which is created from the default branch when missing. A
+	// branch of its own keeps the audit commits out of the history of the
+	// data, and out of reach of callers writing to the default branch.
+	// Callers may not write to the branch, in any database, so it should
+	// not be a branch they write to.
+	Branch string `yaml:"branch" json:"branch"`
+	// Table is created when it does not exist. Defaults to mcp_audit.
+	Table string `yaml:"table" json:"table"`
+	// CommitInterval is how often pending records are written and committed.
+	// Defaults to a minute.
+	CommitInterval time.Duration `yaml:"commit_interval" json:"commit_interval"`
+	CommitMessage  string        `yaml:"commit_message" json:"commit_message"`
+}
+
+func (t *AuditTable) Validate() error {
+	if t.CommitInterval < 0 {
+		return fmt.Errorf("invalid audit table commit interval %s", t.CommitInterval)
+	}
+	return nil
+}
+
+// ValidateDBConfig checks that the records have a database to go to.
+func (t *AuditTable) ValidateDBConfig(config db.Config) error {
+	if t.Database == "" && config.DatabaseName == "" && config.DialectType != db.DialectDoltLite {
+		return errors.New("the audit table needs a database, set in the audit_table section or with --database")
+	}
+	return nil
+}
+
+func (t *AuditTable) tableName() string {
+	if t.Table == "" {
+		return DefaultAuditTableName
+	}
+	return t.Table
+}
+
+func (t *AuditTable) branch() string {
+	if t.Branch == "" {
+		return DefaultAuditBranch
+	}
+	return t.Branch
+}
+
+// policyPattern is the SQL policy pattern of the table, denied to client
+// SQL. Without a database of its own, the table is denied in every database.
+func (t *AuditTable) policyPattern() string {
+	if t.Database == "" {
+		return t.tableName()
+	}
+	return t.Database + "." + t.tableName()
+}
+
+func (t *AuditTable) commitInterval() time.Duration {
+	if t.CommitInterval == 0 {
+		return DefaultAuditCommitInterval
+	}
+	return t.CommitInterval
+}
+
+func (t *AuditTable) commitMessage() string {
+	if t.CommitMessage == "" {
+		return DefaultAuditCommitMessage
+	}
+	return t.CommitMessage
+}
+
+// auditTableSink writes audit records to an AuditTable in the background.
+type auditTableSink struct {
+	table   AuditTable
+	config  db.Config
+	dialect db.Dialect
+	logger  *zap.Logger
+
+	mu      sync.Mutex
+	pending []AuditRecord
+	created bool
+
+	flush     chan struct{}
+	done      chan struct{}
+	stopped   chan struct{}
+	closeOnce sync.Once
+}
+
+// NewAuditTableSink returns a sink writing to table over connections made
+// with config. It commits pending records every commit interval and when it
+// is closed.
+func NewAuditTableSink(logger *zap.Logger, config db.Config, table AuditTable) (AuditSink, error) {
+	if err := table.Validate(); err != nil {
+		return nil, err
+	}
+	if err := table.ValidateDBConfig(config); err != nil {
+		return nil, err
+	}
+	s := &auditTableSink{
+		table:   table,
+		config:  config,
+		dialect: db.NewDialect(config.DialectType),
+		logger:  logger,
+		flush:   make(chan struct{}, 1),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+func (s *auditTableSink) WriteAuditRecord(record AuditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = append(s.pending, record)
+	if len(s.pending) >= auditTableBatchSize {
+		select {
+		case s.flush <- struct{}{}:
+		default:
+		}
+	}
+	if dropped := len(s.pending) - maxPendingAuditRecords; dropped > 0 {
+		s.pending = s.pending[dropped:]
+		return fmt.Errorf("%w: %d records could not be written to the audit table", ErrAuditRecordsDropped, dropped)
+	}
+	return nil
+}
+
+// Close writes and commits the pending records.
+func (s *auditTableSink) Close() error {
+	s.closeOnce.Do(func() { close(s.done) })
+	<-s.stopped
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) > 0 {
+		return fmt.Errorf("%w: %d records could not be written to the audit table", ErrAuditRecordsDropped, len(s.pending))
+	}
+	return nil
+}
+
+func (s *auditTableSink) run() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(s.table.commitInterval())
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.writePending()
+		case <-s.flush:
+			s.writePending()
+		case <-s.done:
+			s.writePending()
+			return
+		}
+	}
+}
+
+// writePending writes the pending records in one commit. When that fails
+// the records are kept for the next attempt.
+func (s *auditTableSink) writePending() {
+	s.mu.Lock()
+	records := s.pending
+	s.pending = nil
+	s.mu.Unlock()
+	if len(records) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), auditTableWriteTimeout)
+	defer cancel()
+	if err := s.write(ctx, records); err != nil {
+		s.logger.Error("failed to write audit table", zap.String("table", s.table.tableName()), zap.Int("records", len(records)), zap.Error(err))
+		s.mu.Lock()
+		s.pending = append(records, s.pending...)
+		if dropped := len(s.pending) - maxPendingAuditRecords; dropped > 0 {
+			s.pending = s.pending[dropped:]
+		}
+		s.mu.Unlock()
+	}
+}
+
+func (s *auditTableSink) write(ctx context.Context, records []AuditRecord) (err error) {
+	tx, err := db.NewDatabaseTransaction(ctx, s.config)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback(ctx)
+			return
+		}
+		err = tx.Commit(ctx)
+	}()
+
+	if s.table.Database != "" {
+		if useStmt := s.dialect.UseDatabase(s.table.Database); useStmt != "" {
+			if err = tx.ExecContext(ctx, useStmt); err != nil {
+				return err
+			}
+		}
+	}
+	if !s.created {
+		if err = s.createBranch(ctx, tx); err != nil {
+			return err
+		}
+	}
+	if err = tx.ExecContext(ctx, s.dialect.CallProcedure(db.DoltCheckout, s.table.branch())); err != nil {
+		return err
+	}
+	if !s.created {
+		if err = tx.ExecContext(ctx, s.createTableSQL()); err != nil {
+			return err
+		}
+	}
+	insert, err := s.insertSQL(records)
+	if err != nil {
+		return err
+	}
+	if err = tx.ExecContext(ctx, insert); err != nil {
+		return err
+	}
+	if err = tx.ExecContext(ctx, s.dialect.CallProcedure(db.DoltAdd, s.table.tableName())); err != nil {
+		return err
+	}
+	if err = tx.ExecContext(ctx, s.dialect.CallProcedure(db.DoltCommit, "-m", s.table.commitMessage())); err != nil {
+		return err
+	}
+	s.created = true
+	return nil
+}
+
+// createBranch creates the audit branch from the default branch when it
+// does not exist.
+func (s *auditTableSink) createBranch(ctx context.Context, tx db.DatabaseTransaction) error {
+	branches, err := tx.QueryContext(ctx, fmt.Sprintf("SELECT COUNT(*) AS branches FROM dolt_branches WHERE name = %s;", s.sqlString(s.table.branch())), db.ResultFormatCSV)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(strings.TrimPrefix(branches, "branches")) != "0" {
+		return nil
+	}
+	return tx.ExecContext(ctx, s.dialect.CallProcedure(db.DoltBranch, s.table.branch()))
+}
+
+// auditTableColumns are the columns of the audit table. The record column
+// holds the whole record as JSON, including the arguments and statements.
+var auditTableColumns = []string{
+	"id",
+	"called_at",
+	"subject",
+	"issuer",
+	"tool",
+	"database_name",
+	"branch_name",
+	"rows_affected",
+	"commit_hash",
+	"duration_ms",
+	"error",
+	"record",
+}
+
+func (s *auditTableSink) createTableSQL() string {
+	timestampType := "DATETIME(6)"
+	switch s.config.DialectType {
+	case db.DialectPostgres:
+		timestampType = "TIMESTAMP"
+	case db.DialectDoltLite:
+		timestampType = "TEXT"
+	}
+	q := s.dialect.QuoteIdentifier
+	return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+  %s VARCHAR(36) PRIMARY KEY,
+  %s %s NOT NULL,
+  %s VARCHAR(255),
+  %s VARCHAR(255),
+  %s VARCHAR(255) NOT NULL,
+  %s VARCHAR(255),
+  %s VARCHAR(255),
+  %s BIGINT NOT NULL,
+  %s VARCHAR(64),
+  %s DOUBLE PRECISION NOT NULL,
+  %s TEXT,
+  %s TEXT NOT NULL
+);`,
+		q(s.table.tableName()),
+		q("id"),
+		q("called_at"), timestampType,
+		q("subject"),
+		q("issuer"),
+		q("tool"),
+		q("database_name"),
+		q("branch_name"),
+		q("rows_affected"),
+		q("commit_hash"),
+		q("duration_ms"),
+		q("error"),
+		q("record"),
+	)
+}
+
+func (s *auditTableSink) insertSQL(records []AuditRecord) (string, error) {
+	columns := make([]string, len(auditTableColumns))
+	for i, column := range auditTableColumns {
+		columns[i] = s.dialect.QuoteIdentifier(column)
+	}
+
+	rows := make([]string, len(records))
+	for i, record := range records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return "", err
+		}
+		var subject, issuer string
+		if record.Caller != nil {
+			subject, issuer = record.Caller.Subject, record.Caller.Issuer
+		}
+		values := []string{
+			s.sqlString(uuid.NewString()),
+			s.sqlString(record.Time.UTC().Format("2006-01-02 15:04:05.000000")),
+			s.sqlNullString(subject),
+			s.sqlNullString(issuer),
+			s.sqlString(record.Tool),
+			s.sqlNullString(record.Database),
+			s.sqlNullString(record.Branch),
+			strconv.FormatInt(record.RowsAffected, 10),
+			s.sqlNullString(record.CommitHash),
+			strconv.FormatFloat(record.DurationMS, 'f', -1, 64),
+			s.sqlNullString(record.Error),
+			s.sqlString(string(data)),
+		}
+		rows[i] = "(" + strings.Join(values, ", ") + ")"
+	}
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES %s;", s.dialect.QuoteIdentifier(s.table.tableName()), strings.Join(columns, ", "), strings.Join(rows, ", ")), nil
+}
+
+// sqlString quotes v as a string literal. Dolt reads backslashes in string
+// literals as escapes, so they are escaped too.
+func (s *auditTableSink) sqlString(v string) string {
+	if s.config.DialectType != db.DialectPostgres && s.config.DialectType != db.DialectDoltLite {
+		v = strings.ReplaceAll(v, `\`, `\\`)
+	}
+	return "'" + strings.ReplaceAll(v, "'", "''") + "'"
+}
+
+func (s *auditTableSink) sqlNullString(v string) string {
+	if v == "" {
+		return "NULL"
+	}
+	return s.sqlString(v)
+}
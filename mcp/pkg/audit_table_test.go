@@ -0,0 +1,89 @@
+package pkg
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"go.uber.org/zap"
+)
+
+func TestAuditTableInsertSQL(t *testing.T) {
+	record := AuditRecord{
+		Time:         time.Date(2026, 1, 5, 10, 15, 2, 0, time.UTC),
+		Caller:       &AuditCaller{Subject: "o'brien"},
+		Tool:         "exec",
+		Database:     "mydb",
+		RowsAffected: 2,
+		DurationMS:   1.5,
+		Error:        `bad \' value`,
+	}
+
+	for _, tc := range []struct {
+		dialect db.DialectType
+		want    []string
+	}{
+		{
+			dialect: db.DialectMySQL,
+			want:    []string{"INSERT INTO `mcp_audit` (`id`, `called_at`,", "'2026-01-05 10:15:02.000000', 'o''brien', NULL, 'exec', 'mydb', NULL, 2, NULL, 1.5, 'bad \\\\'' value'"},
+		},
+		{
+			dialect: db.DialectPostgres,
+			want:    []string{`INSERT INTO "mcp_audit" ("id", "called_at",`, `'2026-01-05 10:15:02.000000', 'o''brien', NULL, 'exec', 'mydb', NULL, 2, NULL, 1.5, 'bad \'' value'`},
+		},
+	} {
+		sink := &auditTableSink{config: db.Config{DialectType: tc.dialect}, dialect: db.NewDialect(tc.dialect)}
+		insert, err := sink.insertSQL([]AuditRecord{record})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, want := range tc.want {
+			if !strings.Contains(insert, want) {
+				t.Errorf("%s: expected %q in %s", tc.dialect, want, insert)
+			}
+		}
+	}
+}
+
+func TestAuditTableWritesPendingRecordsOnClose(t *testing.T) {
+	// An unreachable database keeps the records pending, and closing the
+	// sink reports them as dropped.
+	sink, err := NewAuditTableSink(zap.NewNop(), db.Config{Host: "127.0.0.1", Port: 1, User: "root", DatabaseName: "mydb"}, AuditTable{CommitInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := sink.WriteAuditRecord(AuditRecord{Tool: "query"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := sink.Close(); err == nil || !strings.Contains(err.Error(), "1 records") {
+		t.Fatalf("expected the pending record to be reported, got %v", err)
+	}
+}
+
+func TestAuditTableDeniedToClientSQL(t *testing.T) {
+	configured := &db.SQLPolicy{DeniedTables: []string{"secrets"}}
+	s := &serverSettings{sqlPolicy: configured, auditTable: &AuditTable{}}
+
+	policy := s.ToolSettings().SQLPolicy
+	for _, table := range []string{"mcp_audit", "dolt_diff_mcp_audit", "secrets"} {
+		if err := policy.CheckTable("mydb", table, false); !errors.Is(err, db.ErrSQLPolicyViolation) {
+			t.Errorf("expected table %s to be denied, got %v", table, err)
+		}
+	}
+	if err := policy.CheckTable("mydb", "people", true); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(configured.DeniedTables) != 1 {
+		t.Fatalf("expected the configured policy to be left alone, got %v", configured.DeniedTables)
+	}
+
+	s = &serverSettings{auditTable: &AuditTable{Database: "auditdb", Table: "calls"}}
+	if err := s.ToolSettings().SQLPolicy.CheckTable("auditdb", "calls", false); !errors.Is(err, db.ErrSQLPolicyViolation) {
+		t.Errorf("expected the audit table to be denied without a configured policy, got %v", err)
+	}
+	if err := s.ToolSettings().SQLPolicy.CheckTable("mydb", "calls", false); err != nil {
+		t.Errorf("expected tables of other databases to be allowed, got %v", err)
+	}
+}
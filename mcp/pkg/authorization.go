@@ -0,0 +1,190 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+const DefaultRoleClaim = "roles"
+
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Authorization maps the roles of a caller to the tools it may call and the
+// databases and branches it may call them on.
+type Authorization struct {
+	// RoleClaim names the token claim listing the caller's roles. Nested
+	// claims are named with dots. Defaults to "roles".
+	RoleClaim string `yaml:"role_claim" json:"role_claim"`
+	// DefaultRoles are granted to every caller, including callers that are
+	// not authenticated, such as stdio clients.
+	DefaultRoles []string `yaml:"default_roles" json:"default_roles"`
+	// Roles maps a role name to its grants. A call is authorized when a
+	// grant of one of the caller's roles covers it.
+	Roles map[string][]AuthorizationGrant `yaml:"roles" json:"roles"`
+	// AuthorOverrideRoles are the roles that may set the author of the
+	// commits they make, instead of committing as themselves.
+	AuthorOverrideRoles []string `yaml:"author_override_roles" json:"author_override_roles"`
+}
+
+// AuthorizationGrant allows calling tools on databases and branches. Every
+// list holds case-sensitive globs in the syntax of path.Match, and an empty
+// list matches everything.
+type AuthorizationGrant struct {
+	Tools     []string `yaml:"tools" json:"tools"`
+	Databases []string `yaml:"databases" json:"databases"`
+	Branches  []string `yaml:"branches" json:"branches"`
+	// ReadOnly limits the grant to tools annotated as read-only.
+	ReadOnly bool `yaml:"read_only" json:"read_only"`
+}
+
+// ToolAccess describes what a tool call touches.
+type ToolAccess struct {
+	Tool     string
+	ReadOnly bool
+	// Databases are the databases the call runs against.
+	Databases []string
+	// Branches are the branches the call runs on or changes.
+	Branches []string
+	// ReadBranches are the branches the call only reads from, such as the
+	// branch merged into the working branch. Reading them needs any grant on
+	// the branch, not one for the tool.
+	ReadBranches []string
+	// AnyBranch is true when the call may run on, change or read branches it
+	// does not name, such as SQL calling DOLT_CHECKOUT or dolt_diff(). Only
+	// grants on every branch cover it.
+	AnyBranch bool
+}
+
+func (a *Authorization) Validate() error {
+	for role, grants := range a.Roles {
+		for _, grant := range grants {
+			for _, patterns := range [][]string{grant.Tools, grant.Databases, grant.Branches} {
+				for _, pattern := range patterns {
+					if _, err := path.Match(pattern, ""); err != nil {
+						return fmt.Errorf("invalid pattern %q in role %s: %w", pattern, role, err)
+					}
+				}
+			}
+		}
+	}
+	for _, role := range a.DefaultRoles {
+		if _, ok := a.Roles[role]; !ok {
+			return fmt.Errorf("default role %s is not defined", role)
+		}
+	}
+	return nil
+}
+
+// CallerRoles returns the roles of identity, which may be nil.
+func (a *Authorization) CallerRoles(identity *Identity) []string {
+	claim := a.RoleClaim
+	if claim == "" {
+		claim = DefaultRoleClaim
+	}
+	roles := slices.Clone(a.DefaultRoles)
+	for _, role := range identity.ClaimValues(claim) {
+		if !slices.Contains(roles, role) {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// CanOverrideAuthor reports whether identity has one of the
+// AuthorOverrideRoles.
+func (a *Authorization) CanOverrideAuthor(identity *Identity) bool {
+	for _, role := range a.CallerRoles(identity) {
+		if slices.Contains(a.AuthorOverrideRoles, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize returns an error wrapping ErrUnauthorized when no grant of the
+// caller's roles covers access.
+func (a *Authorization) Authorize(identity *Identity, access ToolAccess) error {
+	roles := a.CallerRoles(identity)
+	caller := "anonymous caller"
+	if identity != nil && identity.Subject != "" {
+		caller = identity.Subject
+	}
+
+	databases := access.Databases
+	if len(databases) == 0 {
+		databases = []string{""}
+	}
+	branches := access.Branches
+	if len(branches) == 0 {
+		branches = []string{""}
+	}
+
+	for _, database := range databases {
+		for _, branch := range branches {
+			if !a.allows(roles, access, database, branch, true) {
+				action := "call " + access.Tool
+				if access.AnyBranch {
+					action += " with SQL reaching branches it does not name"
+				}
+				return unauthorizedError(caller, roles, action, database, branch)
+			}
+		}
+		for _, branch := range access.ReadBranches {
+			if !a.allows(roles, access, database, branch, false) {
+				return unauthorizedError(caller, roles, "read", database, branch)
+			}
+		}
+	}
+	return nil
+}
+
+func (a *Authorization) allows(roles []string, access ToolAccess, database, branch string, checkTool bool) bool {
+	for _, role := range roles {
+		for _, grant := range a.Roles[role] {
+			if checkTool && (!matchesGrant(grant.Tools, access.Tool) || (grant.ReadOnly && !access.ReadOnly)) {
+				continue
+			}
+			if database != "" && !matchesGrant(grant.Databases, database) {
+				continue
+			}
+			if branch != "" && !matchesGrant(grant.Branches, branch) {
+				continue
+			}
+			if access.AnyBranch && len(grant.Branches) > 0 {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
+
+func matchesGrant(patterns []string, name string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func unauthorizedError(caller string, roles []string, action, database, branch string) error {
+	target := ""
+	if database != "" {
+		target += " on database " + database
+	}
+	if branch != "" {
+		target += " on branch " + branch
+	}
+	rolesText := "no roles"
+	if len(roles) > 0 {
+		rolesText = "roles " + strings.Join(roles, ", ")
+	}
+	return fmt.Errorf("%w: %s with %s may not %s%s", ErrUnauthorized, caller, rolesText, action, target)
+}
@@ -0,0 +1,104 @@
+package pkg
+
+import (
+	"errors"
+	"testing"
+)
+
+var testAuthorization = &Authorization{
+	Roles: map[string][]AuthorizationGrant{
+		"analyst": {
+			{Databases: []string{"analytics"}, ReadOnly: true},
+		},
+		"etl": {
+			{Databases: []string{"analytics"}, ReadOnly: true},
+			{Databases: []string{"staging"}, Branches: []string{"staging/*"}},
+		},
+		"auditor": {
+			{Tools: []string{"list_*", "get_*"}},
+		},
+	},
+}
+
+func testIdentity(roles ...any) *Identity {
+	return &Identity{Subject: "user", Claims: map[string]any{"roles": roles}}
+}
+
+func TestAuthorization_Authorize(t *testing.T) {
+	tests := []struct {
+		description string
+		identity    *Identity
+		access      ToolAccess
+		allowed     bool
+	}{
+		{"analyst reads analytics", testIdentity("analyst"), ToolAccess{Tool: "query", ReadOnly: true, Databases: []string{"analytics"}, Branches: []string{"main"}}, true},
+		{"analyst writes analytics", testIdentity("analyst"), ToolAccess{Tool: "exec", Databases: []string{"analytics"}, Branches: []string{"main"}}, false},
+		{"analyst reads staging", testIdentity("analyst"), ToolAccess{Tool: "query", ReadOnly: true, Databases: []string{"staging"}}, false},
+		{"etl writes staging branch", testIdentity("etl"), ToolAccess{Tool: "exec", Databases: []string{"staging"}, Branches: []string{"staging/load"}}, true},
+		{"etl writes staging main", testIdentity("etl"), ToolAccess{Tool: "exec", Databases: []string{"staging"}, Branches: []string{"main"}}, false},
+		{"etl merges from readable branch", testIdentity("etl"), ToolAccess{Tool: "merge_dolt_branch", Databases: []string{"staging"}, Branches: []string{"staging/load"}, ReadBranches: []string{"staging/other"}}, true},
+		{"etl merges from unreadable branch", testIdentity("etl"), ToolAccess{Tool: "merge_dolt_branch", Databases: []string{"staging"}, Branches: []string{"staging/load"}, ReadBranches: []string{"main"}}, false},
+		{"etl reaches unnamed staging branches", testIdentity("etl"), ToolAccess{Tool: "exec", Databases: []string{"staging"}, Branches: []string{"staging/load"}, AnyBranch: true}, false},
+		{"analyst reaches unnamed analytics branches", testIdentity("analyst"), ToolAccess{Tool: "query", ReadOnly: true, Databases: []string{"analytics"}, Branches: []string{"main"}, AnyBranch: true}, true},
+		{"auditor lists anything", testIdentity("auditor"), ToolAccess{Tool: "list_dolt_commits", ReadOnly: true, Databases: []string{"staging"}}, true},
+		{"auditor queries", testIdentity("auditor"), ToolAccess{Tool: "query", ReadOnly: true, Databases: []string{"staging"}}, false},
+		{"unknown role", testIdentity("intern"), ToolAccess{Tool: "query", ReadOnly: true}, false},
+		{"anonymous", nil, ToolAccess{Tool: "query", ReadOnly: true}, false},
+	}
+	for _, test := range tests {
+		err := testAuthorization.Authorize(test.identity, test.access)
+		if test.allowed && err != nil {
+			t.Fatalf("%s: expected call to be authorized, got %v", test.description, err)
+		}
+		if !test.allowed && !errors.Is(err, ErrUnauthorized) {
+			t.Fatalf("%s: expected ErrUnauthorized, got %v", test.description, err)
+		}
+	}
+}
+
+func TestAuthorization_DefaultRolesAndRoleClaim(t *testing.T) {
+	authorization := &Authorization{
+		RoleClaim:    "realm_access.roles",
+		DefaultRoles: []string{"reader"},
+		Roles: map[string][]AuthorizationGrant{
+			"reader": {{ReadOnly: true}},
+			"writer": {{}},
+		},
+	}
+	if err := authorization.Authorize(nil, ToolAccess{Tool: "query", ReadOnly: true}); err != nil {
+		t.Fatalf("expected default role to authorize anonymous reads: %v", err)
+	}
+	if err := authorization.Authorize(nil, ToolAccess{Tool: "exec"}); !errors.Is(err, ErrUnauthorized) {
+		t.Fatalf("expected anonymous write to be unauthorized, got %v", err)
+	}
+
+	identity := &Identity{Claims: map[string]any{"realm_access": map[string]any{"roles": []any{"writer"}}}}
+	if err := authorization.Authorize(identity, ToolAccess{Tool: "exec"}); err != nil {
+		t.Fatalf("expected nested role claim to authorize write: %v", err)
+	}
+}
+
+func TestAuthorization_Validate(t *testing.T) {
+	if err := testAuthorization.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := (&Authorization{DefaultRoles: []string{"missing"}}).Validate(); err == nil {
+		t.Fatalf("expected an error for an undefined default role")
+	}
+	if err := (&Authorization{Roles: map[string][]AuthorizationGrant{"r": {{Tools: []string{"[oops"}}}}}).Validate(); err == nil {
+		t.Fatalf("expected an error for an invalid pattern")
+	}
+}
+
+func TestIdentity_ClaimValues(t *testing.T) {
+	identity := &Identity{Claims: map[string]any{"scope": "read write", "roles": []any{"a", 1, "b"}}}
+	if got := identity.ClaimValues("scope"); len(got) != 2 || got[1] != "write" {
+		t.Fatalf("unexpected scope values: %v", got)
+	}
+	if got := identity.ClaimValues("roles"); len(got) != 2 || got[1] != "b" {
+		t.Fatalf("unexpected role values: %v", got)
+	}
+	if got := (*Identity)(nil).ClaimValues("roles"); got != nil {
+		t.Fatalf("expected no values for a nil identity, got %v", got)
+	}
+}
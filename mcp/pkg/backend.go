@@ -0,0 +1,203 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// MCPPath is where the MCP endpoint of the server's own database is
+	// conventionally served. Backends are served under it.
+	MCPPath = "/mcp"
+
+	defaultMySQLPort    = 3306
+	defaultPostgresPort = 5432
+)
+
+var backendNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// BackendPath returns the path the MCP endpoint of the backend named name is
+// served at.
+func BackendPath(name string) string {
+	return MCPPath + "/" + name
+}
+
+// Backend is a database served alongside the one given by the command line
+// flags, with a dialect and tools of its own. Its MCP endpoint is
+// BackendPath(Name) on the HTTP server.
+type Backend struct {
+	Name string `yaml:"name" json:"name"`
+	// Config is how the backend connects. dialect_type is mysql, the
+	// default, postgres or doltlite, and the port defaults to the port of
+	// the dialect. Without its own column_masking, the backend masks the
+	// columns of the top-level column_masking section.
+	db.Config `yaml:",inline"`
+}
+
+func (b *Backend) Validate() error {
+	if !backendNamePattern.MatchString(b.Name) {
+		return fmt.Errorf("invalid backend name %q: expected letters, digits, '-' or '_'", b.Name)
+	}
+	switch b.DialectType {
+	case "", db.DialectMySQL, db.DialectPostgres, db.DialectDoltLite:
+	default:
+		return fmt.Errorf("invalid dialect_type %q for backend %s: expected %s, %s or %s", b.DialectType, b.Name, db.DialectMySQL, db.DialectPostgres, db.DialectDoltLite)
+	}
+	if len(b.Hosts) > 0 {
+		return fmt.Errorf("backend %s: hosts is only supported for the database given by the command line flags", b.Name)
+	}
+	if b.ColumnMasking != nil {
+		if err := b.ColumnMasking.Validate(); err != nil {
+			return fmt.Errorf("backend %s: %w", b.Name, err)
+		}
+	}
+	config := b.DBConfig(nil)
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("backend %s: %w", b.Name, err)
+	}
+	return nil
+}
+
+// DBConfig returns how the backend connects, with the defaults applied.
+// masking is used when the backend has no column masking of its own.
+func (b *Backend) DBConfig(masking *db.ColumnMasking) db.Config {
+	config := b.Config
+	if config.DialectType == "" {
+		config.DialectType = db.DialectMySQL
+	}
+	if config.Port == 0 && config.DSN == "" {
+		switch config.DialectType {
+		case db.DialectMySQL:
+			config.Port = defaultMySQLPort
+		case db.DialectPostgres:
+			config.Port = defaultPostgresPort
+		}
+	}
+	if config.DialectType == db.DialectDoltLite && config.BusyTimeout == 0 {
+		config.BusyTimeout = db.DefaultDoltLiteBusyTimeout
+	}
+	if config.ColumnMasking == nil {
+		config.ColumnMasking = masking
+	}
+	return config
+}
+
+func validateBackends(backends []Backend) error {
+	names := map[string]bool{}
+	for i := range backends {
+		if err := backends[i].Validate(); err != nil {
+			return err
+		}
+		if names[backends[i].Name] {
+			return fmt.Errorf("duplicate backend name %s", backends[i].Name)
+		}
+		names[backends[i].Name] = true
+	}
+	return nil
+}
+
+// backendServer serves the tools of a backend. It shares the settings of the
+// server it belongs to, such as authorization and the audit log, but has a
+// database and MCP server of its own.
+type backendServer struct {
+	name     string
+	mcp      *server.MCPServer
+	dbConfig db.Config
+	dialect  db.Dialect
+	// inheritsMasking is true when the backend masks the columns of the
+	// server's column masking, which a config reload replaces.
+	inheritsMasking bool
+
+	*serverSettings
+}
+
+var _ Server = &backendServer{}
+
+func (b *backendServer) MCP() *server.MCPServer {
+	return b.mcp
+}
+
+func (b *backendServer) DBConfig() db.Config {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.dbConfig
+}
+
+func (b *backendServer) Dialect() db.Dialect {
+	return b.dialect
+}
+
+// ToolSettings leaves out the server's read replicas, which are replicas of
+// the server's own database, and names the backend.
+func (b *backendServer) ToolSettings() ToolSettings {
+	settings := b.serverSettings.ToolSettings()
+	settings.ReplicaRouter = nil
+	settings.Backend = b.name
+	return settings
+}
+
+// WithBackend serves backend alongside the server's own database. opts are
+// applied to the backend, such as to register its tools, and run with the
+// settings of the server, so WithBackend must come after the options that
+// configure those. Backends are served by HTTP servers only.
+func WithBackend(backend Backend, masking *db.ColumnMasking, opts ...Option) Option {
+	return func(s Server) {
+		cs, ok := s.(configurableServer)
+		if !ok {
+			return
+		}
+		settings := cs.settings()
+		config := backend.DBConfig(masking)
+		if err := db.PrepareDatabase(&config); err != nil {
+			settings.optionErr = errors.Join(settings.optionErr, fmt.Errorf("failed to prepare database of backend %s: %w", backend.Name, err))
+			return
+		}
+		b := &backendServer{
+			name: backend.Name,
+			mcp: server.NewMCPServer(
+				DoltMCPServerName,
+				DoltMCPServerVersion,
+				server.WithToolCapabilities(true),
+				server.WithLogging(),
+			),
+			dbConfig:        config,
+			dialect:         db.NewDialect(config.DialectType),
+			inheritsMasking: backend.ColumnMasking == nil,
+			serverSettings:  settings,
+		}
+		for _, opt := range opts {
+			opt(b)
+		}
+		settings.backends = append(settings.backends, b)
+	}
+}
+
+// closeBackends closes the embedded databases of the backends.
+func (s *serverSettings) closeBackends() error {
+	var errs []error
+	for _, b := range s.backends {
+		if err := db.CloseDatabase(b.dbConfig); err != nil {
+			errs = append(errs, fmt.Errorf("backend %s: %w", b.name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// withBackendRoutes serves the MCP endpoint of each backend at its
+// BackendPath, and every other path with next.
+func withBackendRoutes(next http.Handler, backends map[string]http.Handler) http.Handler {
+	if len(backends) == 0 {
+		return next
+	}
+	mux := http.NewServeMux()
+	for name, handler := range backends {
+		mux.Handle(BackendPath(name), handler)
+	}
+	mux.Handle("/", next)
+	return mux
+}
@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+func TestLoadConfig_Backends(t *testing.T) {
+	config, err := LoadConfig(writeConfigFile(t, `
+backends:
+  - name: doltgres
+    dialect_type: postgres
+    host: localhost
+    user: postgres
+    database_name: mydb
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(config.Backends) != 1 {
+		t.Fatalf("expected one backend, got %+v", config.Backends)
+	}
+	dbConfig := config.Backends[0].DBConfig(nil)
+	if dbConfig.DialectType != db.DialectPostgres || dbConfig.Port != 5432 || dbConfig.DatabaseName != "mydb" {
+		t.Fatalf("unexpected backend config %+v", dbConfig)
+	}
+
+	for _, contents := range []string{
+		"backends:\n  - name: bad/name\n    host: localhost\n    user: root\n",
+		"backends:\n  - name: a\n    host: localhost\n    user: root\n  - name: a\n    host: localhost\n    user: root\n",
+		"backends:\n  - name: a\n    dialect_type: oracle\n    host: localhost\n    user: root\n",
+		"backends:\n  - name: a\n    host: localhost\n",
+	} {
+		if _, err := LoadConfig(writeConfigFile(t, contents)); err == nil {
+			t.Fatalf("expected an error for %q", contents)
+		}
+	}
+}
+
+func TestBackendRoutes(t *testing.T) {
+	config := db.Config{Host: "127.0.0.1", Port: 1, User: "root", DatabaseName: "mydb", DialectType: db.DialectMySQL}
+	backend := Backend{Name: "pg", Config: db.Config{Host: "127.0.0.1", Port: 1, User: "postgres", DialectType: db.DialectPostgres}}
+	addTool := func(name string) Option {
+		return func(s Server) {
+			s.MCP().AddTool(mcp.NewTool(name), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultText("ok"), nil
+			})
+		}
+	}
+	srv, err := NewMCPHTTPServer(zap.NewNop(), config, 0, nil, "", nil, addTool("default_tool"), WithBackend(backend, nil, addTool("pg_tool")))
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+	handler := srv.(*httpServerImpl).handler
+
+	listTools := func(path string) []string {
+		t.Helper()
+		post := func(sessionID, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Accept", "application/json, text/event-stream")
+			if sessionID != "" {
+				req.Header.Set(server.HeaderKeySessionID, sessionID)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec
+		}
+		rec := post("", `{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"test","version":"0"}}}`)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("failed to initialize a session at %s: %d %s", path, rec.Code, rec.Body.String())
+		}
+		rec = post(rec.Header().Get(server.HeaderKeySessionID), `{"jsonrpc":"2.0","id":2,"method":"tools/list"}`)
+		var response struct {
+			Result mcp.ListToolsResult `json:"result"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("failed to decode tools of %s: %v: %s", path, err, rec.Body.String())
+		}
+		var names []string
+		for _, tool := range response.Result.Tools {
+			names = append(names, tool.Name)
+		}
+		return names
+	}
+
+	if tools := listTools(MCPPath); len(tools) != 1 || tools[0] != "default_tool" {
+		t.Fatalf("expected the default tools at %s, got %v", MCPPath, tools)
+	}
+	if tools := listTools(BackendPath("pg")); len(tools) != 1 || tools[0] != "pg_tool" {
+		t.Fatalf("expected the backend tools at %s, got %v", BackendPath("pg"), tools)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, InfoPath, nil))
+	var info infoResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("failed to decode info: %v", err)
+	}
+	if len(info.Backends) != 1 || info.Backends[0].Dialect != string(db.DialectPostgres) || info.Backends[0].Path != BackendPath("pg") {
+		t.Fatalf("unexpected backends in info %+v", info.Backends)
+	}
+}
@@ -0,0 +1,86 @@
+package pkg
+
+import (
+	"crypto/x509"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// ClientAuthRequire makes HTTPS clients present a certificate signed by
+	// the client CA.
+	ClientAuthRequire = "require"
+	// ClientAuthVerifyIfGiven verifies client certificates against the
+	// client CA when clients present one.
+	ClientAuthVerifyIfGiven = "verify-if-given"
+
+	// ClientCertificateIssuer is the issuer of the identities of callers
+	// authenticated by a client certificate.
+	ClientCertificateIssuer = "client-certificate"
+)
+
+// withClientCertificateIdentity makes the verified client certificate of a
+// request the caller identity. Requests without one are passed on as they
+// are, to be authenticated by a token.
+func withClientCertificateIdentity(logger *zap.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		identity := newClientCertificateIdentity(r.TLS.VerifiedChains[0][0])
+		logger.Info("MCP Auth with client certificate", zap.String("sub", identity.Subject), zap.String("issuer", r.TLS.VerifiedChains[0][0].Issuer.String()))
+		next.ServeHTTP(w, r.WithContext(ContextWithIdentity(r.Context(), identity)))
+	})
+}
+
+// newClientCertificateIdentity builds the identity of a verified client
+// certificate. The subject is the certificate's common name, or its first
+// subject alternative name when it has none. The claims expose the rest of
+// the certificate in the shape of JWT claims, so that name and email
+// attribute commits and a claim such as ou can hold roles.
+func newClientCertificateIdentity(cert *x509.Certificate) *Identity {
+	var uris []string
+	for _, uri := range cert.URIs {
+		uris = append(uris, uri.String())
+	}
+
+	subject := cert.Subject.CommonName
+	for _, sans := range [][]string{uris, cert.EmailAddresses, cert.DNSNames} {
+		if subject == "" && len(sans) > 0 {
+			subject = sans[0]
+		}
+	}
+
+	claims := map[string]any{
+		"sub": subject,
+		"iss": ClientCertificateIssuer,
+	}
+	if cert.Subject.CommonName != "" {
+		claims["name"] = cert.Subject.CommonName
+	}
+	if len(cert.EmailAddresses) > 0 {
+		claims["email"] = cert.EmailAddresses[0]
+		claims["emails"] = cert.EmailAddresses
+	}
+	if len(cert.DNSNames) > 0 {
+		claims["dns"] = cert.DNSNames
+	}
+	if len(uris) > 0 {
+		claims["uris"] = uris
+	}
+	if len(cert.Subject.Organization) > 0 {
+		claims["o"] = cert.Subject.Organization
+	}
+	if len(cert.Subject.OrganizationalUnit) > 0 {
+		claims["ou"] = cert.Subject.OrganizationalUnit
+	}
+
+	return &Identity{
+		Subject: subject,
+		Issuer:  ClientCertificateIssuer,
+		Claims:  claims,
+	}
+}
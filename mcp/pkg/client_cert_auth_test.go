@@ -0,0 +1,78 @@
+package pkg
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestNewClientCertificateIdentity(t *testing.T) {
+	identity := newClientCertificateIdentity(&x509.Certificate{
+		Subject:        pkix.Name{CommonName: "Alice Smith", OrganizationalUnit: []string{"analyst"}},
+		EmailAddresses: []string{"alice@example.com"},
+	})
+	if identity.Subject != "Alice Smith" || identity.Issuer != ClientCertificateIssuer {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+	if email := identity.ClaimValues("email"); len(email) != 1 || email[0] != "alice@example.com" {
+		t.Fatalf("unexpected email claim: %v", email)
+	}
+	if roles := identity.ClaimValues("ou"); len(roles) != 1 || roles[0] != "analyst" {
+		t.Fatalf("unexpected ou claim: %v", roles)
+	}
+
+	spiffe, _ := url.Parse("spiffe://example.com/etl")
+	identity = newClientCertificateIdentity(&x509.Certificate{URIs: []*url.URL{spiffe}, DNSNames: []string{"etl.example.com"}})
+	if identity.Subject != "spiffe://example.com/etl" {
+		t.Fatalf("expected the first SAN as the subject, got %q", identity.Subject)
+	}
+}
+
+func TestWithClientCertificateIdentity(t *testing.T) {
+	var identity *Identity
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity = IdentityFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := withClientCertificateIdentity(zap.NewNop(), next)
+
+	req := httptest.NewRequest(http.MethodGet, "/mcp", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "alice"}}}}}
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if identity == nil || identity.Subject != "alice" {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+
+	identity = nil
+	req = httptest.NewRequest(http.MethodGet, "/mcp", nil)
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "mallory"}}}}
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if identity != nil {
+		t.Fatalf("expected an unverified certificate not to identify the caller, got %+v", identity)
+	}
+}
+
+func TestWithJWTAuth_ClientCertificateIdentityNeedsNoToken(t *testing.T) {
+	nextCalled := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nextCalled = true
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := newTestJWTAuth(t, next, map[string]string{"iss": "test-issuer"}, "http://127.0.0.1:1/jwks")
+	handler = withClientCertificateIdentity(zap.NewNop(), handler)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/mcp", nil)
+	req.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: "alice"}}}}}
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || !nextCalled {
+		t.Fatalf("expected a caller with a verified certificate to pass, got status %d", rec.Code)
+	}
+}
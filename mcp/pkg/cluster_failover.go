@@ -0,0 +1,158 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultFailoverCheckInterval is how often the members of a Dolt
+	// cluster are asked for their role.
+	DefaultFailoverCheckInterval = 5 * time.Second
+
+	clusterRoleCheckTimeout = 5 * time.Second
+)
+
+// ErrNoClusterPrimary is returned when no member of the cluster reports
+// itself as the primary.
+var ErrNoClusterPrimary = errors.New("no database host is the primary of its Dolt cluster")
+
+// ClusterFailover follows the primary of a Dolt cluster. It asks every
+// member for its @@dolt_cluster_role in the background, and connections of
+// the db.Config it resolves for are made to the member that is currently the
+// primary.
+type ClusterFailover struct {
+	logger    *zap.Logger
+	config    db.Config
+	endpoints []db.Endpoint
+	interval  time.Duration
+
+	// refreshMu makes concurrent refreshes, such as a background check and
+	// a retrying tool call, ask the members one at a time.
+	refreshMu sync.Mutex
+
+	mu      sync.RWMutex
+	primary db.Endpoint
+	found   bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+var _ db.EndpointResolver = &ClusterFailover{}
+
+// NewClusterFailover returns a failover between the Hosts of config, which
+// must use the mysql dialect. It looks for the primary before returning, so
+// that the first connections go to it, and then every interval. Close stops
+// the checks.
+func NewClusterFailover(logger *zap.Logger, config db.Config, interval time.Duration) (*ClusterFailover, error) {
+	if config.DialectType != "" && config.DialectType != db.DialectMySQL {
+		return nil, fmt.Errorf("failover between hosts is not supported with the %s dialect, which has no @@dolt_cluster_role", config.DialectType)
+	}
+	endpoints, err := config.Endpoints()
+	if err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		interval = DefaultFailoverCheckInterval
+	}
+	config.Primary = nil
+
+	f := &ClusterFailover{
+		logger:    logger,
+		config:    config,
+		endpoints: endpoints,
+		interval:  interval,
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), clusterRoleCheckTimeout*time.Duration(len(endpoints)))
+	defer cancel()
+	if _, err := f.Refresh(ctx); err != nil {
+		logger.Warn("no database host is the cluster primary yet, connecting to the first host", zap.Error(err))
+	}
+	go f.run()
+	return f, nil
+}
+
+// Endpoint returns the current primary. ok is false while no member is the
+// primary.
+func (f *ClusterFailover) Endpoint() (db.Endpoint, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.primary, f.found
+}
+
+// Refresh asks every member for its role now, rather than at the next
+// check, and returns the primary.
+func (f *ClusterFailover) Refresh(ctx context.Context) (db.Endpoint, error) {
+	f.refreshMu.Lock()
+	defer f.refreshMu.Unlock()
+
+	var errs []error
+	for _, endpoint := range f.endpoints {
+		config, err := f.config.WithEndpoint(endpoint)
+		if err != nil {
+			return db.Endpoint{}, err
+		}
+		role, err := db.ClusterRole(ctx, config)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", endpoint, err))
+			continue
+		}
+		if role == db.ClusterRolePrimary {
+			f.setPrimary(endpoint, true)
+			return endpoint, nil
+		}
+		f.logger.Debug("database host is not the cluster primary", zap.String("host", endpoint.String()), zap.String("role", role))
+	}
+	f.setPrimary(db.Endpoint{}, false)
+	return db.Endpoint{}, errors.Join(append([]error{ErrNoClusterPrimary}, errs...)...)
+}
+
+// Close stops the checks.
+func (f *ClusterFailover) Close() {
+	close(f.stop)
+	<-f.done
+}
+
+func (f *ClusterFailover) run() {
+	defer close(f.done)
+	ticker := time.NewTicker(f.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), clusterRoleCheckTimeout*time.Duration(len(f.endpoints)))
+			_, err := f.Refresh(ctx)
+			cancel()
+			if err != nil {
+				f.logger.Debug("failed to find the cluster primary", zap.Error(err))
+			}
+		case <-f.stop:
+			return
+		}
+	}
+}
+
+// setPrimary records the result of a refresh, logging when the primary
+// changes.
+func (f *ClusterFailover) setPrimary(endpoint db.Endpoint, found bool) {
+	f.mu.Lock()
+	previous, wasFound := f.primary, f.found
+	f.primary, f.found = endpoint, found
+	f.mu.Unlock()
+
+	switch {
+	case found && (!wasFound || previous != endpoint):
+		f.logger.Info("connecting to the cluster primary", zap.String("host", endpoint.String()))
+	case !found && wasFound:
+		f.logger.Error("lost the cluster primary", zap.String("previous", previous.String()))
+	}
+}
@@ -0,0 +1,195 @@
+package pkg
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the YAML configuration file passed with --config. It holds the
+// settings that are too structured for command line flags. On SIGHUP the
+// file is read again and every setting but AuditTable, Backends and
+// ReadReplicas is replaced.
+type Config struct {
+	SQLPolicy     *db.SQLPolicy     `yaml:"sql_policy" json:"sql_policy"`
+	ColumnMasking *db.ColumnMasking `yaml:"column_masking" json:"column_masking"`
+	Authorization *Authorization    `yaml:"authorization" json:"authorization"`
+	// DatabaseCredentials maps callers to their own database users.
+	DatabaseCredentials *DatabaseCredentials `yaml:"database_credentials" json:"database_credentials"`
+	// APIKeys authenticates HTTP callers by static keys and HMAC tokens.
+	APIKeys *APIKeys `yaml:"api_keys" json:"api_keys"`
+	// JWT adds trusted token issuers and maps token scopes to tools.
+	JWT *JWTConfig `yaml:"jwt" json:"jwt"`
+	// AuditTable persists the audit log into a table of a Dolt database.
+	AuditTable *AuditTable `yaml:"audit_table" json:"audit_table"`
+	// LogLevel overrides --log-level: debug, info, warn or error.
+	LogLevel string `yaml:"log_level" json:"log_level"`
+	// Backends are more databases to serve over HTTP, each at its own MCP
+	// endpoint. They are not replaced when the file is reloaded.
+	Backends []Backend `yaml:"backends" json:"backends"`
+	// ReadReplicas run the read-only tools of the server's own database.
+	// They are not replaced when the file is reloaded.
+	ReadReplicas *ReadReplicas `yaml:"read_replicas" json:"read_replicas"`
+	// RateLimits caps the tool calls of each caller. A reload applies the
+	// new limits to the calls each caller has running.
+	RateLimits *RateLimits `yaml:"rate_limits" json:"rate_limits"`
+}
+
+// LoadConfig reads and validates the configuration file at path. Unknown
+// keys are rejected so that a misspelled setting is not silently ignored.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	config := &Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return config, nil
+}
+
+func (c *Config) Validate() error {
+	if c.SQLPolicy != nil {
+		if err := c.SQLPolicy.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.ColumnMasking != nil {
+		if err := c.ColumnMasking.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Authorization != nil {
+		if err := c.Authorization.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.DatabaseCredentials != nil {
+		if err := c.DatabaseCredentials.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.APIKeys != nil {
+		if err := c.APIKeys.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.JWT != nil {
+		if err := c.JWT.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.AuditTable != nil {
+		if err := c.AuditTable.Validate(); err != nil {
+			return err
+		}
+	}
+	if _, _, err := c.level(); err != nil {
+		return err
+	}
+	if err := validateBackends(c.Backends); err != nil {
+		return err
+	}
+	if c.ReadReplicas != nil {
+		if err := c.ReadReplicas.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.RateLimits != nil {
+		if err := c.RateLimits.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Level returns the log level set by LogLevel, if any.
+func (c *Config) Level() (zapcore.Level, bool) {
+	level, ok, _ := c.level()
+	return level, ok
+}
+
+func (c *Config) level() (zapcore.Level, bool, error) {
+	switch c.LogLevel {
+	case "":
+		return zapcore.InfoLevel, false, nil
+	case "debug":
+		return zapcore.DebugLevel, true, nil
+	case "info":
+		return zapcore.InfoLevel, true, nil
+	case "warn", "warning":
+		return zapcore.WarnLevel, true, nil
+	case "error":
+		return zapcore.ErrorLevel, true, nil
+	default:
+		return zapcore.InfoLevel, false, fmt.Errorf("invalid log level %q: expected debug, info, warn or error", c.LogLevel)
+	}
+}
+
+// ApplyDBConfig copies the settings in the file that apply to every
+// database connection into dbConfig, and checks the settings that depend on
+// how dbConfig connects.
+func (c *Config) ApplyDBConfig(dbConfig *db.Config) error {
+	if c.ColumnMasking != nil {
+		dbConfig.ColumnMasking = c.ColumnMasking
+	}
+	if c.DatabaseCredentials != nil {
+		if err := c.DatabaseCredentials.ValidateDBConfig(*dbConfig); err != nil {
+			return err
+		}
+		for i := range c.Backends {
+			if err := c.DatabaseCredentials.ValidateDBConfig(c.Backends[i].DBConfig(nil)); err != nil {
+				return fmt.Errorf("backend %s: %w", c.Backends[i].Name, err)
+			}
+		}
+	}
+	if c.AuditTable != nil {
+		if err := c.AuditTable.ValidateDBConfig(*dbConfig); err != nil {
+			return err
+		}
+	}
+	if c.ReadReplicas != nil {
+		if err := c.ReadReplicas.ValidateDBConfig(*dbConfig); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Options returns the server options for the settings in the file.
+func (c *Config) Options() []Option {
+	var opts []Option
+	if c.SQLPolicy != nil {
+		opts = append(opts, WithSQLPolicy(c.SQLPolicy))
+	}
+	if c.Authorization != nil {
+		opts = append(opts, WithAuthorization(c.Authorization))
+	}
+	if c.DatabaseCredentials != nil {
+		opts = append(opts, WithDatabaseCredentials(c.DatabaseCredentials))
+	}
+	if c.APIKeys != nil {
+		opts = append(opts, WithAPIKeys(c.APIKeys))
+	}
+	if c.JWT != nil {
+		opts = append(opts, WithJWTConfig(c.JWT))
+	}
+	if c.RateLimits != nil {
+		opts = append(opts, WithRateLimits(c.RateLimits))
+	}
+	return opts
+}
@@ -0,0 +1,129 @@
+package pkg
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"go.uber.org/zap"
+)
+
+var errNoConfigFile = errors.New("the server was started without a config file")
+
+// reloadedConfig holds every setting a reload of the config file replaces,
+// built in full before any of them is applied, so that a reload that fails
+// part way leaves the server as it was.
+type reloadedConfig struct {
+	config   *Config
+	dbConfig db.Config
+	// settings holds the settings the options of config set.
+	settings *serverSettings
+}
+
+// reloadTarget is the server srv with settings of its own, which the
+// options of a reloaded config file are applied to.
+type reloadTarget struct {
+	Server
+	target *serverSettings
+}
+
+func (t reloadTarget) settings() *serverSettings {
+	return t.target
+}
+
+// loadConfig reads the config file again and builds the settings it holds
+// for srv, the server s belongs to, which connects with dbConfig. Nothing
+// is replaced yet.
+func (s *serverSettings) loadConfig(srv Server, dbConfig db.Config) (*reloadedConfig, error) {
+	if s.configFile == "" {
+		return nil, errNoConfigFile
+	}
+	config, err := LoadConfig(s.configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	reloaded := &reloadedConfig{config: config, dbConfig: dbConfig, settings: &serverSettings{}}
+	reloaded.dbConfig.ColumnMasking = nil
+	if err := config.ApplyDBConfig(&reloaded.dbConfig); err != nil {
+		return nil, err
+	}
+	for _, opt := range config.Options() {
+		opt(reloadTarget{Server: srv, target: reloaded.settings})
+	}
+	return reloaded, nil
+}
+
+// applyConfig replaces the settings of s and dbConfig with the reloaded
+// ones. The caller holds s.mu. Callers keep the calls they have running,
+// and the tokens they have spent, when the rate limits are replaced.
+func (s *serverSettings) applyConfig(reloaded *reloadedConfig, dbConfig *db.Config) {
+	*dbConfig = reloaded.dbConfig
+	for _, b := range s.backends {
+		if b.inheritsMasking {
+			b.dbConfig.ColumnMasking = reloaded.dbConfig.ColumnMasking
+		}
+	}
+
+	s.sqlPolicy = reloaded.settings.sqlPolicy
+	s.authorization = reloaded.settings.authorization
+	s.credentials = reloaded.settings.credentials
+	s.apiKeys = reloaded.settings.apiKeys
+	s.jwtConfig = reloaded.settings.jwtConfig
+	if limiter := reloaded.settings.rateLimiter; limiter != nil && s.rateLimiter != nil {
+		limiter.carryOver(s.rateLimiter)
+	}
+	s.rateLimiter = reloaded.settings.rateLimiter
+
+	if s.logLevel != nil {
+		level, ok := reloaded.config.Level()
+		if !ok {
+			level = s.defaultLogLevel
+		}
+		s.logLevel.SetLevel(level)
+	}
+}
+
+// reloadConfig reads the config file again and replaces the settings it
+// holds in s and dbConfig. srv is the server s belongs to. The new settings
+// apply to the requests and tool calls that start afterwards; sessions and
+// running tool calls are not interrupted. When the file cannot be read or is
+// invalid, every setting is kept.
+func (s *serverSettings) reloadConfig(srv Server, dbConfig *db.Config) error {
+	s.mu.RLock()
+	current := *dbConfig
+	s.mu.RUnlock()
+	reloaded, err := s.loadConfig(srv, current)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.applyConfig(reloaded, dbConfig)
+	return nil
+}
+
+// reloadOnSIGHUP calls reload whenever the process receives SIGHUP, until
+// stop is closed. Failures are logged and the server keeps its settings.
+func reloadOnSIGHUP(logger *zap.Logger, reload func() error, stop <-chan struct{}) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-hup:
+				if err := reload(); err != nil {
+					logger.Error("failed to reload config file, keeping the current settings", zap.Error(err))
+					continue
+				}
+				logger.Info("reloaded config file")
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
@@ -0,0 +1,159 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestReloadConfigReplacesAuthentication(t *testing.T) {
+	apiKeysConfig := func(name, key string) string {
+		return "api_keys:\n  keys:\n    - name: " + name + "\n      hash: " + HashAPIKey(key) + "\n"
+	}
+	path := writeConfigFile(t, apiKeysConfig("old", "old-key"))
+	serverConfig, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading config: %v", err)
+	}
+	level := zap.NewAtomicLevelAt(zapcore.WarnLevel)
+	config := db.Config{Host: "127.0.0.1", Port: 1, User: "root", DatabaseName: "mydb", DialectType: db.DialectMySQL}
+	opts := append(serverConfig.Options(), WithConfigFile(path), WithLogLevel(level, zapcore.WarnLevel))
+	srv, err := NewMCPHTTPServer(zap.NewNop(), config, 0, nil, "", nil, opts...)
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+	impl := srv.(*httpServerImpl)
+
+	status := func(key string) int {
+		req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+		req.Header.Set(APIKeyHeader, key)
+		rec := httptest.NewRecorder()
+		impl.handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	if status("old-key") == http.StatusUnauthorized {
+		t.Fatal("expected the old key to be accepted before the reload")
+	}
+
+	if err := os.WriteFile(path, []byte(apiKeysConfig("new", "new-key")+"log_level: debug\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	if err := impl.reload(); err != nil {
+		t.Fatalf("unexpected error reloading config: %v", err)
+	}
+	if status("old-key") != http.StatusUnauthorized {
+		t.Fatal("expected the old key to be rejected after the reload")
+	}
+	if status("new-key") == http.StatusUnauthorized {
+		t.Fatal("expected the new key to be accepted after the reload")
+	}
+	if level.Level() != zapcore.DebugLevel {
+		t.Fatalf("expected the log level to be reloaded, got %s", level.Level())
+	}
+
+	if err := os.WriteFile(path, []byte(apiKeysConfig("new", "new-key")), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	if err := impl.reload(); err != nil {
+		t.Fatalf("unexpected error reloading config: %v", err)
+	}
+	if level.Level() != zapcore.WarnLevel {
+		t.Fatalf("expected the --log-level level once log_level is removed, got %s", level.Level())
+	}
+
+	if err := os.WriteFile(path, []byte("sql_policy: [\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	if err := impl.reload(); err == nil {
+		t.Fatal("expected an invalid config file to fail the reload")
+	}
+	if status("new-key") == http.StatusUnauthorized {
+		t.Fatal("expected a failed reload to keep the settings")
+	}
+}
+
+func TestReloadConfigReplacesToolSettings(t *testing.T) {
+	path := writeConfigFile(t, "column_masking:\n  rules:\n    - column: ssn\n      action: mask\n")
+	s := &stdioServerImpl{dbConfig: db.Config{DialectType: db.DialectMySQL}}
+	s.configFile = path
+	if err := s.reloadConfig(s, &s.dbConfig); err != nil {
+		t.Fatalf("unexpected error reloading config: %v", err)
+	}
+	if s.DBConfig().ColumnMasking == nil || s.ToolSettings().SQLPolicy != nil {
+		t.Fatalf("expected column masking and no sql policy, got %+v and %+v", s.DBConfig().ColumnMasking, s.ToolSettings().SQLPolicy)
+	}
+
+	if err := os.WriteFile(path, []byte("sql_policy:\n  denied_statements: [DROP]\nauthorization:\n  roles:\n    reader:\n      - read_only: true\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	if err := s.reloadConfig(s, &s.dbConfig); err != nil {
+		t.Fatalf("unexpected error reloading config: %v", err)
+	}
+	if s.DBConfig().ColumnMasking != nil || s.ToolSettings().SQLPolicy == nil || s.ToolSettings().Authorization == nil {
+		t.Fatal("expected the reload to remove column masking and add the sql policy and authorization")
+	}
+}
+
+func TestReloadConfigIsAtomic(t *testing.T) {
+	path := writeConfigFile(t, "sql_policy:\n  denied_statements: [DROP]\n")
+	serverConfig, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading config: %v", err)
+	}
+	config := db.Config{Host: "127.0.0.1", Port: 1, User: "root", DatabaseName: "mydb", DialectType: db.DialectMySQL}
+	opts := append(serverConfig.Options(), WithConfigFile(path))
+	srv, err := NewMCPHTTPServer(zap.NewNop(), config, 0, nil, "", nil, opts...)
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+	impl := srv.(*httpServerImpl)
+	policy := impl.ToolSettings().SQLPolicy
+
+	// Claims without a JWKS URL make the handler fail to build.
+	impl.jwkClaimsMap = map[string]string{"iss": "test-issuer"}
+	if err := os.WriteFile(path, []byte("column_masking:\n  rules:\n    - column: ssn\n      action: mask\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	if err := impl.reload(); err == nil {
+		t.Fatal("expected the reload to fail when the handler cannot be built")
+	}
+	if impl.ToolSettings().SQLPolicy != policy || impl.DBConfig().ColumnMasking != nil {
+		t.Fatal("expected a failed reload to keep every setting")
+	}
+}
+
+func TestReloadConfigKeepsRunningCalls(t *testing.T) {
+	path := writeConfigFile(t, "rate_limits:\n  tool_calls:\n    max_concurrent: 1\n")
+	s := &stdioServerImpl{dbConfig: db.Config{DialectType: db.DialectMySQL}}
+	s.configFile = path
+	if err := s.reloadConfig(s, &s.dbConfig); err != nil {
+		t.Fatalf("unexpected error reloading config: %v", err)
+	}
+	release, err := s.ToolSettings().RateLimiter.Acquire(context.Background(), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte("rate_limits:\n  tool_calls:\n    max_concurrent: 1\n    rate: 100\n"), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	if err := s.reloadConfig(s, &s.dbConfig); err != nil {
+		t.Fatalf("unexpected error reloading config: %v", err)
+	}
+	if _, err := s.ToolSettings().RateLimiter.Acquire(context.Background(), false); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected the call running before the reload to count against the new limits, got %v", err)
+	}
+	release()
+	release, err = s.ToolSettings().RateLimiter.Acquire(context.Background(), false)
+	if err != nil {
+		t.Fatalf("expected a call to be allowed once the earlier call returned, got %v", err)
+	}
+	release()
+}
@@ -0,0 +1,209 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+)
+
+func writeConfigFile(t *testing.T, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(contents), 0o600); err != nil {
+		t.Fatalf("failed to write config file: %v", err)
+	}
+	return path
+}
+
+func TestLoadConfig_SQLPolicy(t *testing.T) {
+	path := writeConfigFile(t, `
+sql_policy:
+  denied_statements: ["DROP DATABASE", "CALL DOLT_PUSH"]
+  denied_tables: ["secrets"]
+  denied_columns: ["people.ssn"]
+  block_cross_database: true
+`)
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading config: %v", err)
+	}
+	if config.SQLPolicy == nil || !config.SQLPolicy.BlockCrossDatabase || len(config.SQLPolicy.DeniedStatements) != 2 {
+		t.Fatalf("unexpected sql policy: %+v", config.SQLPolicy)
+	}
+	if len(config.Options()) != 1 {
+		t.Fatalf("expected one server option, got %d", len(config.Options()))
+	}
+}
+
+func TestLoadConfig_Empty(t *testing.T) {
+	config, err := LoadConfig(writeConfigFile(t, ""))
+	if err != nil {
+		t.Fatalf("unexpected error loading empty config: %v", err)
+	}
+	if len(config.Options()) != 0 {
+		t.Fatalf("expected no server options, got %d", len(config.Options()))
+	}
+}
+
+func TestLoadConfig_RejectsUnknownKeys(t *testing.T) {
+	if _, err := LoadConfig(writeConfigFile(t, "sql_polcy: {}\n")); err == nil {
+		t.Fatalf("expected an error for a misspelled key")
+	}
+}
+
+func TestLoadConfig_RejectsInvalidPatterns(t *testing.T) {
+	if _, err := LoadConfig(writeConfigFile(t, "sql_policy:\n  denied_tables: [\"[oops\"]\n")); err == nil {
+		t.Fatalf("expected an error for an invalid pattern")
+	}
+}
+
+func TestLoadConfig_ColumnMasking(t *testing.T) {
+	path := writeConfigFile(t, `
+column_masking:
+  hash_salt: secret
+  rules:
+    - column: people.ssn
+      action: mask
+    - column: "*.email"
+      action: hash
+`)
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading config: %v", err)
+	}
+	dbConfig := db.Config{}
+	if err := config.ApplyDBConfig(&dbConfig); err != nil {
+		t.Fatalf("unexpected error applying config: %v", err)
+	}
+	if dbConfig.ColumnMasking == nil || len(dbConfig.ColumnMasking.Rules) != 2 || dbConfig.ColumnMasking.HashSalt != "secret" {
+		t.Fatalf("unexpected column masking: %+v", dbConfig.ColumnMasking)
+	}
+}
+
+func TestLoadConfig_RejectsInvalidMaskAction(t *testing.T) {
+	if _, err := LoadConfig(writeConfigFile(t, "column_masking:\n  rules:\n    - column: ssn\n      action: redact\n")); err == nil {
+		t.Fatalf("expected an error for an invalid mask action")
+	}
+}
+
+func TestLoadConfig_Authorization(t *testing.T) {
+	path := writeConfigFile(t, `
+authorization:
+  role_claim: roles
+  roles:
+    analyst:
+      - databases: [analytics]
+        read_only: true
+    etl:
+      - databases: [analytics]
+        read_only: true
+      - databases: [staging]
+        branches: ["staging/*"]
+`)
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading config: %v", err)
+	}
+	if config.Authorization == nil || len(config.Authorization.Roles["etl"]) != 2 || !config.Authorization.Roles["analyst"][0].ReadOnly {
+		t.Fatalf("unexpected authorization: %+v", config.Authorization)
+	}
+	if len(config.Options()) != 1 {
+		t.Fatalf("expected one server option, got %d", len(config.Options()))
+	}
+}
+
+func TestLoadConfig_DatabaseCredentials(t *testing.T) {
+	path := writeConfigFile(t, `
+database_credentials:
+  user_claim: db_user
+  required: true
+  users:
+    alice@example.com:
+      issuer: https://idp.example.com
+      user: alice
+      password: secret
+`)
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading config: %v", err)
+	}
+	if config.DatabaseCredentials == nil || config.DatabaseCredentials.Users["alice@example.com"].Issuer != "https://idp.example.com" {
+		t.Fatalf("unexpected database credentials: %+v", config.DatabaseCredentials)
+	}
+	if len(config.Options()) != 1 {
+		t.Fatalf("expected one server option, got %d", len(config.Options()))
+	}
+	if err := config.ApplyDBConfig(&db.Config{DSN: "root@tcp(localhost:3306)/"}); err == nil {
+		t.Fatalf("expected an error applying database credentials to a DSN")
+	}
+}
+
+func TestLoadConfig_APIKeys(t *testing.T) {
+	path := writeConfigFile(t, `
+api_keys:
+  keys:
+    - name: ci
+      hash: `+HashAPIKey("ci-secret")+`
+      scopes: [query]
+`)
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading config: %v", err)
+	}
+	if config.APIKeys == nil || config.APIKeys.Authenticate("ci-secret") == nil {
+		t.Fatalf("unexpected api keys: %+v", config.APIKeys)
+	}
+	if len(config.Options()) != 1 {
+		t.Fatalf("expected one server option, got %d", len(config.Options()))
+	}
+}
+
+func TestLoadConfig_JWT(t *testing.T) {
+	path := writeConfigFile(t, `
+jwt:
+  issuers:
+    - issuer: https://auth.example.com
+      jwks_url: https://auth.example.com/.well-known/jwks.json
+      refresh_interval: 10m
+      audiences: [dolt-mcp]
+  scopes:
+    dolt:read: [query, "list_*"]
+`)
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading config: %v", err)
+	}
+	if config.JWT == nil || len(config.JWT.Issuers) != 1 || config.JWT.Issuers[0].RefreshInterval != 10*time.Minute {
+		t.Fatalf("unexpected jwt config: %+v", config.JWT)
+	}
+	if len(config.Options()) != 1 {
+		t.Fatalf("expected one server option, got %d", len(config.Options()))
+	}
+}
+
+func TestLoadConfig_AuditTable(t *testing.T) {
+	path := writeConfigFile(t, `
+audit_table:
+  database: mydb
+  branch: audit
+  commit_interval: 30s
+`)
+	config, err := LoadConfig(path)
+	if err != nil {
+		t.Fatalf("unexpected error loading config: %v", err)
+	}
+	if config.AuditTable == nil || config.AuditTable.Branch != "audit" || config.AuditTable.CommitInterval != 30*time.Second {
+		t.Fatalf("unexpected audit table config: %+v", config.AuditTable)
+	}
+	if err := config.ApplyDBConfig(&db.Config{DialectType: db.DialectMySQL}); err != nil {
+		t.Fatalf("unexpected error applying db config: %v", err)
+	}
+
+	config.AuditTable.Database = ""
+	if err := config.ApplyDBConfig(&db.Config{DialectType: db.DialectMySQL}); err == nil {
+		t.Fatal("expected an audit table without a database to be rejected")
+	}
+}
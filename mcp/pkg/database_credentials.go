@@ -0,0 +1,106 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+)
+
+var ErrNoDatabaseCredentials = errors.New("no database credentials")
+
+// ErrUserClaimWithoutTLS is returned for a user_claim of a database that
+// does not require TLS, which would send callers' tokens in cleartext.
+var ErrUserClaimWithoutTLS = errors.New("database_credentials user_claim requires a database connection that requires TLS (--tls true or skip-verify, or --tls-ca), since callers' tokens are sent as cleartext passwords")
+
+// DatabaseCredentials maps authenticated callers to the database users they
+// connect as, so that the grants and branch permissions of the database
+// apply to each caller rather than to the server's shared user.
+type DatabaseCredentials struct {
+	// Users maps the subject of a caller's identity to its database user.
+	// Subjects of different issuers may be the same, so a user only applies
+	// to the subject of its issuer.
+	Users map[string]DatabaseUser `yaml:"users" json:"users"`
+	// UserClaim names a token claim holding the database user of the
+	// caller. Nested claims are named with dots. The caller's token is sent
+	// as the password, for users identified with Dolt's
+	// authentication_dolt_jwt plugin. Users takes precedence over it. The
+	// token is sent in cleartext, so it requires TLS to the database.
+	UserClaim string `yaml:"user_claim" json:"user_claim"`
+	// Required refuses callers without a database user, including callers
+	// that are not authenticated, instead of connecting them as the shared
+	// user.
+	Required bool `yaml:"required" json:"required"`
+}
+
+// DatabaseUser is the database user and password of a caller.
+type DatabaseUser struct {
+	// Issuer is the issuer of the caller's identity: the iss claim of its
+	// JWT, "api-key" for an API key, "hmac-token" for an HMAC token, or
+	// "client-certificate".
+	Issuer   string `yaml:"issuer" json:"issuer"`
+	User     string `yaml:"user" json:"user"`
+	Password string `yaml:"password" json:"password"`
+	// PasswordEnv names an environment variable holding the password, to
+	// keep it out of the file.
+	PasswordEnv string `yaml:"password_env" json:"password_env"`
+}
+
+func (c *DatabaseCredentials) Validate() error {
+	for subject, user := range c.Users {
+		if user.Issuer == "" {
+			return fmt.Errorf("database user for %s has no issuer", subject)
+		}
+		if user.User == "" {
+			return fmt.Errorf("database user for %s has no user", subject)
+		}
+		if user.Password != "" && user.PasswordEnv != "" {
+			return fmt.Errorf("database user for %s sets both password and password_env", subject)
+		}
+		if _, ok := os.LookupEnv(user.PasswordEnv); user.PasswordEnv != "" && !ok {
+			return fmt.Errorf("password_env %s of database user for %s is not set", user.PasswordEnv, subject)
+		}
+	}
+	return nil
+}
+
+// ValidateDBConfig checks that dbConfig connects in a way that allows the
+// user to vary per caller, and encrypts the tokens of user_claim.
+func (c *DatabaseCredentials) ValidateDBConfig(dbConfig db.Config) error {
+	if dbConfig.DialectType == db.DialectDoltLite {
+		return errors.New("database credentials are not supported with --doltlite, which has no database users")
+	}
+	if dbConfig.DSN != "" {
+		return db.ErrCredentialsWithDSN
+	}
+	if c.UserClaim != "" && !dbConfig.RequiresTLS() {
+		return ErrUserClaimWithoutTLS
+	}
+	return nil
+}
+
+// Credentials returns the database credentials of identity, which may be
+// nil. ok is false when the caller connects as the shared user.
+func (c *DatabaseCredentials) Credentials(identity *Identity) (credentials db.Credentials, ok bool, err error) {
+	caller := "anonymous caller"
+	if identity != nil {
+		if user, ok := c.Users[identity.Subject]; ok && user.Issuer == identity.Issuer {
+			password := user.Password
+			if user.PasswordEnv != "" {
+				password = os.Getenv(user.PasswordEnv)
+			}
+			return db.Credentials{User: user.User, Password: password}, true, nil
+		}
+		if values := identity.ClaimValues(c.UserClaim); c.UserClaim != "" && len(values) == 1 {
+			return db.Credentials{User: values[0], Password: identity.Token, Cleartext: true}, true, nil
+		}
+		if identity.Subject != "" {
+			caller = identity.Subject
+		}
+	}
+	if c.Required {
+		return db.Credentials{}, false, fmt.Errorf("%w for %s", ErrNoDatabaseCredentials, caller)
+	}
+	return db.Credentials{}, false, nil
+}
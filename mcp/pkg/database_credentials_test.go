@@ -0,0 +1,102 @@
+package pkg
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+)
+
+func TestDatabaseCredentials(t *testing.T) {
+	t.Setenv("BOB_DB_PASSWORD", "bob-secret")
+	credentials := &DatabaseCredentials{
+		Users: map[string]DatabaseUser{
+			"alice@example.com": {Issuer: "https://idp.example.com", User: "alice", Password: "alice-secret"},
+			"bob@example.com":   {Issuer: APIKeyIssuer, User: "bob", PasswordEnv: "BOB_DB_PASSWORD"},
+		},
+		UserClaim: "db_user",
+	}
+	if err := credentials.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		identity *Identity
+		expected db.Credentials
+		ok       bool
+	}{
+		{"mapped subject", &Identity{Subject: "alice@example.com", Issuer: "https://idp.example.com"}, db.Credentials{User: "alice", Password: "alice-secret"}, true},
+		{"password from environment", &Identity{Subject: "bob@example.com", Issuer: APIKeyIssuer}, db.Credentials{User: "bob", Password: "bob-secret"}, true},
+		{"subject of another issuer", &Identity{Subject: "alice@example.com", Issuer: "https://other.example.com"}, db.Credentials{}, false},
+		{"user claim", &Identity{Subject: "carol@example.com", Token: "token", Claims: map[string]any{"db_user": "carol"}}, db.Credentials{User: "carol", Password: "token", Cleartext: true}, true},
+		{"unmapped subject", &Identity{Subject: "dave@example.com"}, db.Credentials{}, false},
+		{"anonymous", nil, db.Credentials{}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			actual, ok, err := credentials.Credentials(test.identity)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if ok != test.ok || actual != test.expected {
+				t.Fatalf("expected %+v (%v), got %+v (%v)", test.expected, test.ok, actual, ok)
+			}
+		})
+	}
+}
+
+func TestDatabaseCredentials_Required(t *testing.T) {
+	credentials := &DatabaseCredentials{Required: true}
+	for _, identity := range []*Identity{nil, {Subject: "dave@example.com"}} {
+		if _, _, err := credentials.Credentials(identity); !errors.Is(err, ErrNoDatabaseCredentials) {
+			t.Fatalf("expected ErrNoDatabaseCredentials for %+v, got %v", identity, err)
+		}
+	}
+}
+
+func TestDatabaseCredentials_Validate(t *testing.T) {
+	invalid := []DatabaseUser{
+		{Issuer: APIKeyIssuer, Password: "secret"},
+		{User: "alice", Password: "secret"},
+		{Issuer: APIKeyIssuer, User: "alice", Password: "secret", PasswordEnv: "ALICE_DB_PASSWORD"},
+		{Issuer: APIKeyIssuer, User: "alice", PasswordEnv: "DOLT_MCP_TEST_UNSET_PASSWORD"},
+	}
+	for _, user := range invalid {
+		credentials := &DatabaseCredentials{Users: map[string]DatabaseUser{"alice@example.com": user}}
+		if err := credentials.Validate(); err == nil {
+			t.Fatalf("expected an error for %+v", user)
+		}
+	}
+
+	credentials := &DatabaseCredentials{}
+	if err := credentials.ValidateDBConfig(db.Config{DSN: "root@tcp(localhost:3306)/"}); !errors.Is(err, db.ErrCredentialsWithDSN) {
+		t.Fatalf("expected ErrCredentialsWithDSN, got %v", err)
+	}
+	if err := credentials.ValidateDBConfig(db.Config{DialectType: db.DialectDoltLite, Path: "test.db"}); err == nil {
+		t.Fatalf("expected an error for DoltLite")
+	}
+	if err := credentials.ValidateDBConfig(db.Config{Host: "localhost", Port: 3306, User: "root"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	credentials.UserClaim = "db_user"
+	for _, dbConfig := range []db.Config{
+		{Host: "localhost", Port: 3306, User: "root"},
+		{Host: "localhost", Port: 3306, User: "root", TLS: "preferred"},
+		{Host: "localhost", Port: 5432, User: "root", TLS: "preferred", TLSCAFile: "ca.pem", DialectType: db.DialectPostgres},
+	} {
+		if err := credentials.ValidateDBConfig(dbConfig); !errors.Is(err, ErrUserClaimWithoutTLS) {
+			t.Fatalf("expected ErrUserClaimWithoutTLS for TLS %q, got %v", dbConfig.TLS, err)
+		}
+	}
+	for _, dbConfig := range []db.Config{
+		{Host: "localhost", Port: 3306, User: "root", TLS: "true"},
+		{Host: "localhost", Port: 3306, User: "root", TLSCAFile: "ca.pem"},
+		{Host: "localhost", Port: 5432, User: "root", TLS: "skip-verify", DialectType: db.DialectPostgres},
+	} {
+		if err := credentials.ValidateDBConfig(dbConfig); err != nil {
+			t.Fatalf("expected user_claim to be allowed with TLS %q, got %v", dbConfig.TLS, err)
+		}
+	}
+}
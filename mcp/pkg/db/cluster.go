@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ClusterRolePrimary is the @@dolt_cluster_role of the member of a Dolt
+// cluster that accepts writes.
+const ClusterRolePrimary = "primary"
+
+var ErrHostsWithDSN = errors.New("cluster hosts cannot be used with a DSN")
+
+// EndpointResolver picks the server connections are made to among the Hosts
+// of a Config, such as the current primary of a Dolt cluster.
+type EndpointResolver interface {
+	// Endpoint returns the server to connect to. ok is false when no server
+	// is known, and the configured Host and Port are used.
+	Endpoint() (endpoint Endpoint, ok bool)
+}
+
+// Endpoints returns the servers c may connect to: every member of Hosts, or
+// Host and Port when there are none.
+func (c Config) Endpoints() ([]Endpoint, error) {
+	if len(c.Hosts) == 0 {
+		return []Endpoint{{Host: c.Host, Port: c.Port}}, nil
+	}
+	if c.DSN != "" {
+		return nil, ErrHostsWithDSN
+	}
+	endpoints := make([]Endpoint, 0, len(c.Hosts))
+	for _, hostPort := range c.Hosts {
+		endpoint, err := parseEndpoint(hostPort, c.Port)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	return endpoints, nil
+}
+
+func parseEndpoint(hostPort string, defaultPort int) (Endpoint, error) {
+	hostPort = strings.TrimSpace(hostPort)
+	host, portStr, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		// A host without a port, which SplitHostPort rejects.
+		host, portStr = strings.Trim(hostPort, "[]"), ""
+	}
+	if host == "" {
+		return Endpoint{}, fmt.Errorf("invalid host %q: no hostname", hostPort)
+	}
+	port := defaultPort
+	if portStr != "" {
+		port, err = strconv.Atoi(portStr)
+		if err != nil || port <= 0 || port > 65535 {
+			return Endpoint{}, fmt.Errorf("invalid host %q: bad port", hostPort)
+		}
+	}
+	if port == 0 {
+		return Endpoint{}, fmt.Errorf("invalid host %q: %w", hostPort, ErrNoPortDefined)
+	}
+	return Endpoint{Host: host, Port: port}, nil
+}
+
+// withPrimary returns a copy of c that connects to the endpoint its Primary
+// resolver picks, if any.
+func (c Config) withPrimary() Config {
+	if c.Primary == nil {
+		return c
+	}
+	if endpoint, ok := c.Primary.Endpoint(); ok {
+		c.Host = endpoint.Host
+		c.Port = endpoint.Port
+	}
+	c.Primary = nil
+	return c
+}
+
+// ClusterRole returns the @@dolt_cluster_role of the server config connects
+// to, such as ClusterRolePrimary or "standby".
+func ClusterRole(ctx context.Context, config Config) (string, error) {
+	db, err := newDB(config)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	var role string
+	if err := db.QueryRowContext(ctx, "SELECT @@dolt_cluster_role").Scan(&role); err != nil {
+		return "", fmt.Errorf("failed to read @@dolt_cluster_role: %w", err)
+	}
+	return role, nil
+}
+
+// clusterRoleChangeMessages are in the errors Dolt returns for writes to a
+// server that is no longer, or is not yet, the primary of its cluster.
+var clusterRoleChangeMessages = []string{
+	"transitioned cluster roles",
+	"is read-only",
+	"read only mode",
+}
+
+// IsClusterRoleChange reports whether message is the error of a write that
+// failed because the server it ran on is not the primary of its cluster.
+func IsClusterRoleChange(message string) bool {
+	message = strings.ToLower(message)
+	for _, m := range clusterRoleChangeMessages {
+		if strings.Contains(message, m) {
+			return true
+		}
+	}
+	return false
+}
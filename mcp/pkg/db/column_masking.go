@@ -0,0 +1,277 @@
+package db
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ErrTableFunctionReference is returned by Dialect.ReferencedTables for
+// queries that read from a table function, such as dolt_diff(), whose
+// tables are named by its arguments.
+var ErrTableFunctionReference = errors.New("query reads from a table function")
+
+// ErrMaskedColumnRenamed is returned for queries that may return a masked
+// column under a name other than its own, such as through an alias, an
+// expression or a subquery. Masking matches result columns by name, so the
+// values could not be masked.
+var ErrMaskedColumnRenamed = errors.New("query may return a masked column under another name")
+
+// MaskAction is what column masking does to the values of a matching column.
+type MaskAction string
+
+const (
+	// MaskActionMask replaces values with a fixed placeholder.
+	MaskActionMask MaskAction = "mask"
+	// MaskActionHash replaces values with a keyed hash, so that equal values
+	// still compare equal without revealing them.
+	MaskActionHash MaskAction = "hash"
+	// MaskActionDrop removes the column from results.
+	MaskActionDrop MaskAction = "drop"
+)
+
+const (
+	maskedValuePlaceholder = "****"
+	maskedValueHashLength  = 16
+)
+
+// maskedDoltTablePrefixes are the Dolt system tables whose rows carry the
+// columns of a user table, such as dolt_diff_people for people.
+var maskedDoltTablePrefixes = []string{
+	"dolt_commit_diff_",
+	"dolt_diff_",
+	"dolt_history_",
+	"dolt_conflicts_",
+	"dolt_constraint_violations_",
+	"dolt_workspace_",
+}
+
+// maskedColumnPrefixes are the prefixes Dolt system tables put in front of
+// user column names, such as to_ssn and from_ssn in dolt_diff_people.
+var maskedColumnPrefixes = []string{"to_", "from_", "base_", "our_", "their_"}
+
+// ColumnMaskRule masks the columns matching Column, a case-insensitive glob
+// in the syntax of path.Match written as "column", "table.column" or
+// "database.table.column".
+type ColumnMaskRule struct {
+	Column string     `yaml:"column" json:"column"`
+	Action MaskAction `yaml:"action" json:"action"`
+}
+
+// ColumnMasking rewrites sensitive columns in every query result. A result
+// column matches a rule when its name matches the rule's column and a table
+// the query references matches the rule's table. When the referenced tables
+// cannot be determined, the column name alone decides, so that values are
+// masked rather than leaked. The first matching rule applies. Queries that
+// may return a matching column under another name fail with
+// ErrMaskedColumnRenamed instead of running, and so do statements that may
+// keep one where a later statement reads it unmasked, such as a user
+// variable or the rows of INSERT ... SELECT.
+type ColumnMasking struct {
+	Rules []ColumnMaskRule `yaml:"rules" json:"rules"`
+	// HashSalt keys the hash used by the hash action. Without it, hashes of
+	// values with few possibilities, like phone numbers, can be reversed by
+	// hashing every possibility.
+	HashSalt string `yaml:"hash_salt" json:"hash_salt"`
+}
+
+// Validate checks that every rule has a well formed pattern and a known
+// action.
+func (m *ColumnMasking) Validate() error {
+	for _, rule := range m.Rules {
+		if rule.Column == "" {
+			return fmt.Errorf("column masking rule has no column")
+		}
+		if strings.Count(rule.Column, ".") > 2 {
+			return fmt.Errorf("invalid column masking pattern %q: expected [database.][table.]column", rule.Column)
+		}
+		if _, err := path.Match(rule.Column, ""); err != nil {
+			return fmt.Errorf("invalid column masking pattern %q: %w", rule.Column, err)
+		}
+		switch rule.Action {
+		case MaskActionMask, MaskActionHash, MaskActionDrop:
+		default:
+			return fmt.Errorf("invalid column masking action %q for %s: expected %s, %s or %s", rule.Action, rule.Column, MaskActionMask, MaskActionHash, MaskActionDrop)
+		}
+	}
+	return nil
+}
+
+// maskTable is a table a query references, with Dolt system table prefixes
+// removed.
+type maskTable struct {
+	database string
+	name     string
+}
+
+func referencedTableNames(stmts ...policyStatement) ([]string, error) {
+	var names []string
+	for _, stmt := range stmts {
+		if len(stmt.tableFunctions) > 0 {
+			return nil, fmt.Errorf("%w: %s", ErrTableFunctionReference, stmt.tableFunctions[0].clause)
+		}
+		for _, table := range stmt.tables {
+			if table.database != "" {
+				names = append(names, table.database+"."+table.name)
+			} else {
+				names = append(names, table.name)
+			}
+		}
+	}
+	return names, nil
+}
+
+func derivedColumnNames(stmts ...policyStatement) []string {
+	var names []string
+	for _, stmt := range stmts {
+		for _, column := range stmt.derived {
+			names = append(names, column.name)
+		}
+	}
+	return names
+}
+
+// newMaskTables resolves the tables returned by Dialect.ReferencedTables
+// against the current database.
+func newMaskTables(names []string, database string) []maskTable {
+	tables := make([]maskTable, len(names))
+	for i, name := range names {
+		table := maskTable{database: database, name: name}
+		if i := strings.LastIndex(name, "."); i >= 0 {
+			table.database, table.name = name[:i], name[i+1:]
+		}
+		table.database = baseDatabaseName(table.database)
+		lower := strings.ToLower(table.name)
+		for _, prefix := range maskedDoltTablePrefixes {
+			if strings.HasPrefix(lower, prefix) {
+				table.name = table.name[len(prefix):]
+				break
+			}
+		}
+		tables[i] = table
+	}
+	return tables
+}
+
+// splitMaskPattern splits a rule's column pattern into its database, table
+// and column parts.
+func splitMaskPattern(pattern string) (database, table, column string) {
+	parts := strings.Split(pattern, ".")
+	column = parts[len(parts)-1]
+	if len(parts) > 1 {
+		table = parts[len(parts)-2]
+	}
+	if len(parts) > 2 {
+		database = parts[0]
+	}
+	return database, table, column
+}
+
+func matchesMaskColumn(pattern, column string) bool {
+	if matchPolicyPattern(pattern, column) {
+		return true
+	}
+	lower := strings.ToLower(column)
+	for _, prefix := range maskedColumnPrefixes {
+		if strings.HasPrefix(lower, prefix) && matchPolicyPattern(pattern, column[len(prefix):]) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesMaskTables reports whether the rule's database and table parts
+// match one of tables. An unknown database matches any database pattern.
+func matchesMaskTables(databasePattern, tablePattern string, tables []maskTable) bool {
+	if tablePattern == "" || len(tables) == 0 {
+		return true
+	}
+	for _, table := range tables {
+		if !matchPolicyPattern(tablePattern, table.name) {
+			continue
+		}
+		if databasePattern == "" || table.database == "" || matchPolicyPattern(databasePattern, table.database) {
+			return true
+		}
+	}
+	return false
+}
+
+// needsDatabase reports whether any rule names a database, in which case
+// the current database is needed to match unqualified tables.
+func (m *ColumnMasking) needsDatabase() bool {
+	for _, rule := range m.Rules {
+		if strings.Count(rule.Column, ".") == 2 {
+			return true
+		}
+	}
+	return false
+}
+
+// action returns the action for the result column, or "" when no rule
+// matches it.
+func (m *ColumnMasking) action(column string, tables []maskTable) MaskAction {
+	for _, rule := range m.Rules {
+		databasePattern, tablePattern, columnPattern := splitMaskPattern(rule.Column)
+		if matchesMaskColumn(columnPattern, column) && matchesMaskTables(databasePattern, tablePattern, tables) {
+			return rule.Action
+		}
+	}
+	return ""
+}
+
+// checkDerived returns ErrMaskedColumnRenamed when a column a query may
+// return under another name, as returned by Dialect.DerivedColumns, matches
+// a rule. The column's own table is not known, so any table the query
+// references decides, and "*" matches every column.
+func (m *ColumnMasking) checkDerived(derived []string, tables []maskTable) error {
+	for _, column := range derived {
+		for _, rule := range m.Rules {
+			databasePattern, tablePattern, columnPattern := splitMaskPattern(rule.Column)
+			if (column == "*" || matchesMaskColumn(columnPattern, column)) && matchesMaskTables(databasePattern, tablePattern, tables) {
+				return fmt.Errorf("%w: %s", ErrMaskedColumnRenamed, column)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *ColumnMasking) hash(value interface{}) string {
+	mac := hmac.New(sha256.New, []byte(m.HashSalt))
+	mac.Write([]byte(fmt.Sprintf("%v", value)))
+	return hex.EncodeToString(mac.Sum(nil))[:maskedValueHashLength]
+}
+
+// apply masks the rows of a result in place and returns the columns that
+// remain after dropped columns are removed. NULL values are left as they
+// are for the mask and hash actions.
+func (m *ColumnMasking) apply(rowMaps []RowMap, columns Columns, tables []maskTable) Columns {
+	remaining := make(Columns, 0, len(columns))
+	for _, column := range columns {
+		action := m.action(column, tables)
+		switch action {
+		case MaskActionDrop:
+			for _, rowMap := range rowMaps {
+				delete(rowMap, column)
+			}
+			continue
+		case MaskActionMask, MaskActionHash:
+			for _, rowMap := range rowMaps {
+				if rowMap[column] == nil {
+					continue
+				}
+				if action == MaskActionMask {
+					rowMap[column] = maskedValuePlaceholder
+				} else {
+					rowMap[column] = m.hash(rowMap[column])
+				}
+			}
+		}
+		remaining = append(remaining, column)
+	}
+	return remaining
+}
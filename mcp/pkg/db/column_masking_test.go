@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testColumnMasking = &ColumnMasking{
+	Rules: []ColumnMaskRule{
+		{Column: "people.ssn", Action: MaskActionMask},
+		{Column: "people.email", Action: MaskActionHash},
+		{Column: "mydb.users.password_hash", Action: MaskActionDrop},
+		{Column: "*_token", Action: MaskActionDrop},
+	},
+	HashSalt: "salt",
+}
+
+func testMaskRows() ([]RowMap, Columns) {
+	columns := Columns{"id", "ssn", "email", "password_hash", "api_token"}
+	rows := []RowMap{
+		{"id": 1, "ssn": "123-45-6789", "email": "a@example.com", "password_hash": "x", "api_token": "t"},
+		{"id": 2, "ssn": nil, "email": "a@example.com", "password_hash": "y", "api_token": "u"},
+	}
+	return rows, columns
+}
+
+func TestColumnMaskingApply(t *testing.T) {
+	rows, columns := testMaskRows()
+	columns = testColumnMasking.apply(rows, columns, newMaskTables([]string{"people"}, "mydb"))
+
+	require.Equal(t, Columns{"id", "ssn", "email", "password_hash"}, columns)
+	require.Equal(t, maskedValuePlaceholder, rows[0]["ssn"])
+	require.Nil(t, rows[1]["ssn"])
+	require.Len(t, rows[0]["email"], maskedValueHashLength)
+	require.NotEqual(t, "a@example.com", rows[0]["email"])
+	require.Equal(t, rows[0]["email"], rows[1]["email"])
+	require.Equal(t, "x", rows[0]["password_hash"])
+	require.NotContains(t, rows[0], "api_token")
+}
+
+func TestColumnMaskingMatchesTables(t *testing.T) {
+	tests := []struct {
+		description string
+		tables      []string
+		database    string
+		masked      bool
+	}{
+		{"unrelated table", []string{"orders"}, "mydb", false},
+		{"masked table", []string{"orders", "people"}, "mydb", true},
+		{"diff table", []string{"dolt_diff_people"}, "mydb", true},
+		{"history table", []string{"dolt_history_people"}, "mydb", true},
+		{"unknown tables", nil, "mydb", true},
+	}
+	for _, test := range tests {
+		rows := []RowMap{{"to_ssn": "1", "from_ssn": "2", "ssn": "3"}}
+		testColumnMasking.apply(rows, Columns{"to_ssn", "from_ssn", "ssn"}, newMaskTables(test.tables, test.database))
+		for _, column := range []string{"to_ssn", "from_ssn", "ssn"} {
+			if test.masked {
+				require.Equal(t, maskedValuePlaceholder, rows[0][column], test.description)
+			} else {
+				require.NotEqual(t, maskedValuePlaceholder, rows[0][column], test.description)
+			}
+		}
+	}
+}
+
+func TestColumnMaskingMatchesDatabase(t *testing.T) {
+	columns := Columns{"password_hash"}
+
+	rows := []RowMap{{"password_hash": "x"}}
+	require.Empty(t, testColumnMasking.apply(rows, columns, newMaskTables([]string{"users"}, "mydb/feature")))
+
+	rows = []RowMap{{"password_hash": "x"}}
+	require.Equal(t, columns, testColumnMasking.apply(rows, columns, newMaskTables([]string{"users"}, "otherdb")))
+
+	rows = []RowMap{{"password_hash": "x"}}
+	require.Empty(t, testColumnMasking.apply(rows, columns, newMaskTables([]string{"mydb.users"}, "otherdb")))
+
+	rows = []RowMap{{"password_hash": "x"}}
+	require.Empty(t, testColumnMasking.apply(rows, columns, newMaskTables([]string{"users"}, "")))
+}
+
+func TestReferencedTables(t *testing.T) {
+	for _, d := range []Dialect{NewMySQLDialect(), NewPostgresDialect(), NewDoltLiteDialect()} {
+		tables, err := d.ReferencedTables(d.ListTableDiffChangesQuery("people", "'a'", "'b'"))
+		if err != nil {
+			require.ErrorIs(t, err, ErrTableFunctionReference)
+		} else {
+			require.Equal(t, []string{"dolt_diff_people"}, tables)
+		}
+
+		tables, err = d.ReferencedTables("SELECT p.name FROM people p JOIN orders o ON o.person_id = p.id")
+		require.NoError(t, err)
+		require.Equal(t, []string{"people", "orders"}, tables)
+
+		_, err = d.ReferencedTables("SELECT * FROM orders JOIN dolt_diff('HEAD~', 'HEAD', 'people') d ON d.to_id = orders.id")
+		require.ErrorIs(t, err, ErrTableFunctionReference)
+	}
+}
+
+func TestColumnMaskingValidate(t *testing.T) {
+	require.NoError(t, testColumnMasking.Validate())
+	require.Error(t, (&ColumnMasking{Rules: []ColumnMaskRule{{Column: "ssn", Action: "redact"}}}).Validate())
+	require.Error(t, (&ColumnMasking{Rules: []ColumnMaskRule{{Column: "[unclosed", Action: MaskActionMask}}}).Validate())
+	require.Error(t, (&ColumnMasking{Rules: []ColumnMaskRule{{Column: "a.b.c.d", Action: MaskActionMask}}}).Validate())
+	require.Error(t, (&ColumnMasking{Rules: []ColumnMaskRule{{Action: MaskActionMask}}}).Validate())
+}
+
+func TestDerivedColumns(t *testing.T) {
+	renamed := []string{
+		"SELECT ssn AS x FROM people",
+		"SELECT UPPER(ssn) FROM people",
+		"SELECT CONCAT(ssn, '') AS ssn FROM people",
+		"SELECT * FROM (SELECT ssn AS s FROM people) t",
+		"SELECT s FROM (SELECT p.ssn AS s FROM people p) t",
+		"SELECT name FROM orders UNION SELECT ssn FROM people",
+		"SELECT (SELECT ssn FROM people LIMIT 1) AS x FROM orders",
+		"SELECT to_ssn AS x FROM dolt_diff_people",
+		"INSERT INTO people_copy SELECT ssn FROM people",
+		"CREATE TABLE people_copy AS SELECT ssn FROM people",
+		"UPDATE orders SET note = (SELECT ssn FROM people LIMIT 1)",
+	}
+	passthrough := []string{
+		"SELECT ssn FROM people",
+		"SELECT p.ssn, p.name AS full_name FROM people p",
+		"SELECT ssn AS ssn FROM people",
+		"SELECT * FROM people WHERE UPPER(ssn) = 'X'",
+		"SELECT * FROM (SELECT * FROM people) t",
+		"SELECT to_ssn, from_ssn FROM dolt_diff_people",
+		"INSERT INTO people (name, ssn) VALUES ('a', 'b')",
+		"UPDATE people SET ssn = 'x' WHERE ssn = 'y'",
+	}
+	for _, d := range []Dialect{NewMySQLDialect(), NewPostgresDialect(), NewDoltLiteDialect()} {
+		for _, query := range renamed {
+			derived, err := d.DerivedColumns(query)
+			require.NoError(t, err, query)
+			tables, err := d.ReferencedTables(query)
+			require.NoError(t, err, query)
+			require.ErrorIs(t, testColumnMasking.checkDerived(derived, newMaskTables(tables, "mydb")), ErrMaskedColumnRenamed, "%T: %s", d, query)
+		}
+		for _, query := range passthrough {
+			derived, err := d.DerivedColumns(query)
+			require.NoError(t, err, query)
+			tables, err := d.ReferencedTables(query)
+			require.NoError(t, err, query)
+			require.NoError(t, testColumnMasking.checkDerived(derived, newMaskTables(tables, "mydb")), "%T: %s", d, query)
+		}
+	}
+}
+
+func TestDerivedColumnsRenamedTables(t *testing.T) {
+	for query, d := range map[string]Dialect{
+		"WITH t (a, b) AS (SELECT id, ssn FROM people) SELECT b FROM t":      NewMySQLDialect(),
+		"SELECT * FROM (SELECT id, ssn FROM people) AS t (a, b)":             NewPostgresDialect(),
+		"SELECT b FROM people AS p (a, b)":                                   NewPostgresDialect(),
+		"SELECT p FROM people p":                                             NewPostgresDialect(),
+		"SELECT row_to_json(p) FROM people p":                                NewPostgresDialect(),
+		"UPDATE people SET name = 'a' RETURNING ssn AS x":                    NewPostgresDialect(),
+		"SELECT ssn INTO @x FROM people":                                     NewMySQLDialect(),
+		"SET @x = (SELECT ssn FROM people LIMIT 1)":                          NewMySQLDialect(),
+		"SELECT name FROM people WHERE id IN (SELECT id FROM people) OR ssn": NewDoltLiteDialect(),
+	} {
+		derived, err := d.DerivedColumns(query)
+		require.NoError(t, err, query)
+		tables, err := d.ReferencedTables(query)
+		require.NoError(t, err, query)
+		require.ErrorIs(t, testColumnMasking.checkDerived(derived, newMaskTables(tables, "mydb")), ErrMaskedColumnRenamed, query)
+	}
+}
+
+func TestDerivedColumnsUnparsedQuery(t *testing.T) {
+	// Queries the dialect cannot parse fall back to scanning their names.
+	query := "SELECT ssn AS x FROM people WITH SOMETHING UNPARSEABLE"
+	_, err := NewMySQLDialect().DerivedColumns(query)
+	require.Error(t, err)
+	require.ErrorIs(t, testColumnMasking.checkDerived(liteDerivedColumns(query), nil), ErrMaskedColumnRenamed)
+}
+
+type fakeSQLExecutor struct {
+	executed []string
+}
+
+func (e *fakeSQLExecutor) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	return nil, errors.New("unexpected query")
+}
+
+func (e *fakeSQLExecutor) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	e.executed = append(e.executed, query)
+	return driver.RowsAffected(1), nil
+}
+
+func TestColumnMaskingExec(t *testing.T) {
+	executor := &fakeSQLExecutor{}
+	tx := &databaseTransactionImpl{
+		executor: executor,
+		dialect:  NewMySQLDialect(),
+		masking:  &ColumnMasking{Rules: []ColumnMaskRule{{Column: "people.ssn", Action: MaskActionMask}}},
+	}
+	ctx := context.Background()
+
+	_, err := tx.ExecContextRowsAffected(ctx, "SET @x = (SELECT ssn FROM people LIMIT 1)")
+	require.ErrorIs(t, err, ErrMaskedColumnRenamed)
+	require.ErrorIs(t, tx.ExecContext(ctx, "INSERT INTO people_copy SELECT ssn FROM people"), ErrMaskedColumnRenamed)
+	require.Empty(t, executor.executed)
+
+	n, err := tx.ExecContextRowsAffected(ctx, "UPDATE people SET ssn = 'x' WHERE id = 1")
+	require.NoError(t, err)
+	require.Equal(t, int64(1), n)
+	require.Equal(t, []string{"UPDATE people SET ssn = 'x' WHERE id = 1"}, executor.executed)
+}
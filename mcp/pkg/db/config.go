@@ -9,11 +9,19 @@ var ErrNoHostDefined = errors.New("no host defined")
 var ErrNoUserDefined = errors.New("no user defined")
 var ErrNoDatabaseNameDefined = errors.New("no database name defined")
 var ErrNoPortDefined = errors.New("no port defined")
+var ErrSocketWithHost = errors.New("a socket cannot be used with a host")
 var ErrNoDatabaseFileDefined = errors.New("no database file defined")
 var ErrInvalidDoltLiteBusyTimeout = errors.New("DoltLite busy timeout must be between 0 and 2147483647 milliseconds")
 
 const DefaultDoltLiteBusyTimeout = 5 * time.Second
 
+// DefaultDoltLiteCommitName and DefaultDoltLiteCommitEmail author the DoltLite
+// commits of servers without --commit-name and --commit-email.
+const (
+	DefaultDoltLiteCommitName  = "doltlite"
+	DefaultDoltLiteCommitEmail = "doltlite@localhost"
+)
+
 const maxDoltLiteBusyTimeout = time.Duration(1<<31-1) * time.Millisecond
 
 type Config struct {
@@ -28,12 +36,28 @@ type Config struct {
 	TLS             string      `yaml:"tls" json:"tls"`
 	TLSCAFile       string      `yaml:"tls_ca_file" json:"tls_ca_file"`
 	DialectType     DialectType `yaml:"dialect_type" json:"dialect_type"`
+	// AllowCleartextPasswords lets MySQL connections send the password
+	// unhashed to users whose authentication plugin requires it.
+	AllowCleartextPasswords bool `yaml:"allow_cleartext_passwords" json:"allow_cleartext_passwords"`
+	// Socket is the path of a unix domain socket to connect to in place of
+	// Host and Port. For postgres it is the directory holding the socket,
+	// or the .s.PGSQL.<port> socket itself.
+	Socket string `yaml:"socket" json:"socket"`
+	// Hosts are the members of a Dolt cluster, each host or host:port, that
+	// connections fail over between. Members without a port use Port.
+	Hosts []string `yaml:"hosts" json:"hosts"`
+	// Primary, when set, picks the member of Hosts that connections are
+	// made to. Host and Port are used while it knows of none.
+	Primary EndpointResolver `yaml:"-" json:"-"`
 
 	Path        string        `yaml:"path" json:"path"`
 	CommitName  string        `yaml:"commit_name" json:"commit_name"`
 	CommitEmail string        `yaml:"commit_email" json:"commit_email"`
 	BusyTimeout time.Duration `yaml:"busy_timeout" json:"busy_timeout"`
 
+	// ColumnMasking, when set, masks sensitive columns in query results.
+	ColumnMasking *ColumnMasking `yaml:"column_masking" json:"column_masking"`
+
 	doltLiteDatabase *doltLiteDatabase
 }
 
@@ -50,14 +74,49 @@ func (c *Config) Validate() error {
 		}
 		return nil
 	}
-	if c.Host == "" {
+	if c.Socket != "" {
+		if c.Host != "" || len(c.Hosts) > 0 {
+			return ErrSocketWithHost
+		}
+	} else if c.Host == "" && len(c.Hosts) == 0 {
 		return ErrNoHostDefined
 	}
+	if _, err := c.Endpoints(); err != nil {
+		return err
+	}
 	if c.User == "" {
 		return ErrNoUserDefined
 	}
-	if c.Port == 0 {
+	if c.Port == 0 && c.Socket == "" {
 		return ErrNoPortDefined
 	}
 	return nil
 }
+
+// doltLiteCommitAuthor returns the author of the DoltLite commits made
+// without an author of their own.
+func (c *Config) doltLiteCommitAuthor() (string, string) {
+	name, email := c.CommitName, c.CommitEmail
+	if name == "" {
+		name = DefaultDoltLiteCommitName
+	}
+	if email == "" {
+		email = DefaultDoltLiteCommitEmail
+	}
+	return name, email
+}
+
+// RequiresTLS reports whether connections made with the config are refused
+// unless they are encrypted, rather than merely preferring TLS.
+func (c *Config) RequiresTLS() bool {
+	switch c.DialectType {
+	case DialectDoltLite:
+		return false
+	case DialectPostgres:
+		return c.TLS == "true" || c.TLS == "skip-verify"
+	default:
+		// ConfigureTLS requires TLS verified with the CA file when one is
+		// given, whatever the TLS mode.
+		return c.TLS == "true" || c.TLS == "skip-verify" || c.TLS == "custom" || c.TLSCAFile != ""
+	}
+}
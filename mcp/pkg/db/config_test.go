@@ -1,6 +1,7 @@
 package db
 
 import (
+	"context"
 	"errors"
 	"testing"
 	"time"
@@ -32,3 +33,134 @@ func TestDoltLiteConfigValidation(t *testing.T) {
 		t.Fatalf("expected ErrInvalidDoltLiteBusyTimeout, got %v", err)
 	}
 }
+
+func TestConfigWithCredentials(t *testing.T) {
+	config := Config{Host: "localhost", Port: 3306, User: "root", Password: "root-secret", DialectType: DialectMySQL}
+
+	unchanged, err := config.withCredentials(context.Background())
+	if err != nil || unchanged.User != "root" {
+		t.Fatalf("expected the configured user without credentials, got %+v err=%v", unchanged, err)
+	}
+
+	ctx := ContextWithCredentials(context.Background(), Credentials{User: "alice", Password: "token", Cleartext: true})
+	withCredentials, err := config.withCredentials(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if dsn := NewMySQLDialect().FormatDSN(withCredentials); dsn != "alice:token@tcp(localhost:3306)/?allowCleartextPasswords=true" {
+		t.Fatalf("unexpected DSN %q", dsn)
+	}
+
+	config.DSN = "root@tcp(localhost:3306)/"
+	if _, err := config.withCredentials(ctx); !errors.Is(err, ErrCredentialsWithDSN) {
+		t.Fatalf("expected ErrCredentialsWithDSN, got %v", err)
+	}
+}
+
+type fixedResolver struct {
+	endpoint Endpoint
+	ok       bool
+}
+
+func (r fixedResolver) Endpoint() (Endpoint, bool) {
+	return r.endpoint, r.ok
+}
+
+func TestConfigEndpoints(t *testing.T) {
+	config := Config{Host: "primary", Port: 3306, User: "root", Hosts: []string{"dolt-1", "dolt-2:3307", "[::1]:3308"}}
+	endpoints, err := config.Endpoints()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []Endpoint{{Host: "dolt-1", Port: 3306}, {Host: "dolt-2", Port: 3307}, {Host: "::1", Port: 3308}}
+	if len(endpoints) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, endpoints)
+	}
+	for i := range expected {
+		if endpoints[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, endpoints)
+		}
+	}
+
+	for _, hosts := range [][]string{{"dolt-1", ":3306"}, {"dolt-1", "dolt-2:port"}, {"dolt-1", "dolt-2:0"}} {
+		config.Hosts = hosts
+		if err := config.Validate(); err == nil {
+			t.Fatalf("expected an error for hosts %q", hosts)
+		}
+	}
+}
+
+func TestConfigWithPrimary(t *testing.T) {
+	config := Config{Host: "dolt-1", Port: 3306, Primary: fixedResolver{}}
+	if resolved := config.withPrimary(); resolved.Host != "dolt-1" || resolved.Port != 3306 {
+		t.Fatalf("expected the configured host without a primary, got %s:%d", resolved.Host, resolved.Port)
+	}
+
+	config.Primary = fixedResolver{endpoint: Endpoint{Host: "dolt-2", Port: 3307}, ok: true}
+	if resolved := config.withPrimary(); resolved.Host != "dolt-2" || resolved.Port != 3307 {
+		t.Fatalf("expected the primary, got %s:%d", resolved.Host, resolved.Port)
+	}
+
+	replica, err := config.WithEndpoint(Endpoint{Host: "replica", Port: 3306})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resolved := replica.withPrimary(); resolved.Host != "replica" {
+		t.Fatalf("expected an explicit endpoint to take precedence over the primary, got %s", resolved.Host)
+	}
+}
+
+func TestIsClusterRoleChange(t *testing.T) {
+	for _, message := range []string{
+		"Error 1105 (HY000): This server transitioned cluster roles. This connection can no longer be used. Please reconnect.",
+		"Error 1105 (HY000): Database mydb is read-only.",
+		"database server is set to read only mode",
+	} {
+		if !IsClusterRoleChange(message) {
+			t.Fatalf("expected %q to be a cluster role change", message)
+		}
+	}
+	if IsClusterRoleChange("Error 1146 (42S02): table not found: people") {
+		t.Fatal("expected an unrelated error not to be a cluster role change")
+	}
+}
+
+func TestSocketDSN(t *testing.T) {
+	config := Config{Socket: "/var/run/dolt/mysql.sock", User: "root", DatabaseName: "mydb", DialectType: DialectMySQL}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("expected a socket without a host or port to be valid, got %v", err)
+	}
+	if dsn := NewMySQLDialect().FormatDSN(config); dsn != "root:@unix(/var/run/dolt/mysql.sock)/mydb" {
+		t.Fatalf("unexpected MySQL DSN %q", dsn)
+	}
+
+	config = Config{Socket: "/tmp", Port: 5432, User: "postgres", DatabaseName: "mydb", DialectType: DialectPostgres}
+	if dsn := NewPostgresDialect().FormatDSN(config); dsn != "postgres://postgres:@/mydb?host=%2Ftmp&port=5432&sslmode=disable" {
+		t.Fatalf("unexpected Postgres DSN %q", dsn)
+	}
+	config.Socket = "/var/run/doltgres/.s.PGSQL.5433"
+	if dsn := NewPostgresDialect().FormatDSN(config); dsn != "postgres://postgres:@/mydb?host=%2Fvar%2Frun%2Fdoltgres&port=5433&sslmode=disable" {
+		t.Fatalf("unexpected Postgres DSN %q", dsn)
+	}
+
+	config.Host = "localhost"
+	if err := config.Validate(); !errors.Is(err, ErrSocketWithHost) {
+		t.Fatalf("expected ErrSocketWithHost, got %v", err)
+	}
+
+	replica, err := config.WithEndpoint(Endpoint{Host: "replica", Port: 5432})
+	if err != nil || replica.Socket != "" {
+		t.Fatalf("expected an endpoint to replace the socket, got %+v err=%v", replica, err)
+	}
+}
+
+func TestDoltLiteCommitAuthorDefaults(t *testing.T) {
+	config := Config{DialectType: DialectDoltLite}
+	if name, email := config.doltLiteCommitAuthor(); name != DefaultDoltLiteCommitName || email != DefaultDoltLiteCommitEmail {
+		t.Fatalf("expected the default author, got %q <%s>", name, email)
+	}
+	config.CommitName, config.CommitEmail = "Your Name", "you@example.com"
+	if name, email := config.doltLiteCommitAuthor(); name != "Your Name" || email != "you@example.com" {
+		t.Fatalf("expected the configured author, got %q <%s>", name, email)
+	}
+}
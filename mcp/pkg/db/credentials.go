@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"errors"
+)
+
+var ErrCredentialsWithDSN = errors.New("per-caller database credentials cannot be used with a DSN")
+
+// Credentials are the database user and password a transaction connects
+// with in place of the configured ones.
+type Credentials struct {
+	User     string
+	Password string
+	// Cleartext sends the password as is rather than hashed, as users
+	// identified by a token, such as Dolt's authentication_dolt_jwt users,
+	// require.
+	Cleartext bool
+}
+
+type credentialsKey struct{}
+
+// ContextWithCredentials returns a copy of ctx whose transactions connect
+// with credentials.
+func ContextWithCredentials(ctx context.Context, credentials Credentials) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, credentials)
+}
+
+// CredentialsFromContext returns the credentials set on ctx by
+// ContextWithCredentials.
+func CredentialsFromContext(ctx context.Context) (Credentials, bool) {
+	credentials, ok := ctx.Value(credentialsKey{}).(Credentials)
+	return credentials, ok
+}
+
+// withCredentials returns a copy of c that connects with the credentials on
+// ctx, if any.
+func (c Config) withCredentials(ctx context.Context) (Config, error) {
+	credentials, ok := CredentialsFromContext(ctx)
+	if !ok {
+		return c, nil
+	}
+	if c.DSN != "" {
+		return c, ErrCredentialsWithDSN
+	}
+	c.User = credentials.User
+	c.Password = credentials.Password
+	c.AllowCleartextPasswords = credentials.Cleartext
+	return c, nil
+}
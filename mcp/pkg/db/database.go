@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -27,6 +28,9 @@ type Columns []string
 type DatabaseTransaction interface {
 	QueryContext(ctx context.Context, query string, resultFormat ResultFormat) (string, error)
 	ExecContext(ctx context.Context, query string) error
+	// ExecContextRowsAffected runs query like ExecContext and reports the
+	// number of rows it affected.
+	ExecContextRowsAffected(ctx context.Context, query string) (int64, error)
 	Rollback(ctx context.Context) error
 	Commit(ctx context.Context) error
 }
@@ -42,6 +46,9 @@ type databaseTransactionImpl struct {
 	conn             *sql.Conn
 	doltLiteDatabase *doltLiteDatabase
 	closeDoltLiteDB  bool
+	dialect          Dialect
+	dialectType      DialectType
+	masking          *ColumnMasking
 }
 
 var _ DatabaseTransaction = &databaseTransactionImpl{}
@@ -51,6 +58,14 @@ func NewDatabaseTransaction(ctx context.Context, config Config) (DatabaseTransac
 		return newDoltLiteTransaction(ctx, config)
 	}
 
+	config, err := config.withCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+	config, err = config.withContextEndpoint(ctx)
+	if err != nil {
+		return nil, err
+	}
 	db, err := newDB(config)
 	if err != nil {
 		return nil, err
@@ -60,9 +75,13 @@ func NewDatabaseTransaction(ctx context.Context, config Config) (DatabaseTransac
 		db.Close()
 		return nil, err
 	}
+	openPools.Store(db, transactionPool)
 	return &databaseTransactionImpl{
-		executor: db,
-		db:       db,
+		executor:    db,
+		db:          db,
+		dialect:     NewDialect(config.DialectType),
+		dialectType: config.DialectType,
+		masking:     config.ColumnMasking,
 	}, nil
 }
 
@@ -79,11 +98,13 @@ func newDoltLiteTransaction(ctx context.Context, config Config) (DatabaseTransac
 			return nil, err
 		}
 		closeDatabase = true
+		openPools.Store(database.db, transactionPool)
 	}
 
 	conn, err := database.db.Conn(ctx)
 	if err != nil {
 		if closeDatabase {
+			openPools.Delete(database.db)
 			_ = database.db.Close()
 		}
 		return nil, err
@@ -94,20 +115,23 @@ func newDoltLiteTransaction(ctx context.Context, config Config) (DatabaseTransac
 		conn:             conn,
 		doltLiteDatabase: database,
 		closeDoltLiteDB:  closeDatabase,
+		dialect:          NewDialect(config.DialectType),
+		dialectType:      config.DialectType,
+		masking:          config.ColumnMasking,
 	}
 
 	if _, err = conn.ExecContext(ctx, fmt.Sprintf("PRAGMA busy_timeout = %d;", config.BusyTimeout.Milliseconds())); err != nil {
 		return nil, tx.finish(err)
 	}
-	if config.CommitName != "" {
-		if _, err = conn.ExecContext(ctx, "SELECT dolt_config('user.name', ?);", config.CommitName); err != nil {
-			return nil, tx.finish(fmt.Errorf("failed to configure DoltLite commit author name: %w", err))
-		}
+	// The author is set by every transaction, even without --commit-name and
+	// --commit-email, since the author of a commit made for a caller would
+	// otherwise stay configured for the next transaction of the connection.
+	name, email := config.doltLiteCommitAuthor()
+	if _, err = conn.ExecContext(ctx, "SELECT dolt_config('user.name', ?);", name); err != nil {
+		return nil, tx.finish(fmt.Errorf("failed to configure DoltLite commit author name: %w", err))
 	}
-	if config.CommitEmail != "" {
-		if _, err = conn.ExecContext(ctx, "SELECT dolt_config('user.email', ?);", config.CommitEmail); err != nil {
-			return nil, tx.finish(fmt.Errorf("failed to configure DoltLite commit author email: %w", err))
-		}
+	if _, err = conn.ExecContext(ctx, "SELECT dolt_config('user.email', ?);", email); err != nil {
+		return nil, tx.finish(fmt.Errorf("failed to configure DoltLite commit author email: %w", err))
 	}
 	_, err = conn.ExecContext(ctx, "BEGIN;")
 	if err != nil {
@@ -206,6 +230,33 @@ func (d *databaseTransactionImpl) doQueryContext(ctx context.Context, query stri
 		return nil, nil, ErrTransactionHasBeenCommittedOrRolledBack
 	}
 
+	masking := d.masking != nil && len(d.masking.Rules) > 0
+	var maskTables []maskTable
+	if masking {
+		var err error
+		maskTables, err = d.maskTables(ctx, query)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	start := time.Now()
+	spanCtx, span := d.startStatementSpan(ctx, query)
+	rowMaps, columns, err := d.scanQuery(spanCtx, query)
+	endStatementSpan(span, err)
+	statementRecorderFromContext(ctx).recordQuery(query, start, len(rowMaps), err)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if masking {
+		columns = d.masking.apply(rowMaps, columns, maskTables)
+	}
+
+	return rowMaps, columns, nil
+}
+
+func (d *databaseTransactionImpl) scanQuery(ctx context.Context, query string) ([]RowMap, Columns, error) {
 	rows, err := d.executor.QueryContext(ctx, query)
 	if err != nil {
 		return nil, nil, err
@@ -253,11 +304,86 @@ func (d *databaseTransactionImpl) doQueryContext(ctx context.Context, query stri
 	return rowMaps, columns, nil
 }
 
+// maskTables returns the tables the column masking rules are matched
+// against for query, or ErrMaskedColumnRenamed when the query may return a
+// masked column under another name. Queries the dialect cannot parse are
+// masked by column name alone, and their names are scanned for masked
+// columns outside a plain select list.
+func (d *databaseTransactionImpl) maskTables(ctx context.Context, query string) ([]maskTable, error) {
+	tables, err := d.dialect.ReferencedTables(query)
+	if err != nil {
+		tables = nil
+	}
+	derived, err := d.dialect.DerivedColumns(query)
+	if err != nil {
+		derived = liteDerivedColumns(query)
+	}
+
+	var database string
+	if d.masking.needsDatabase() {
+		database, err = d.currentDatabase(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve the current database for column masking: %w", err)
+		}
+	}
+
+	maskTables := newMaskTables(tables, database)
+	if err := d.masking.checkDerived(derived, maskTables); err != nil {
+		return nil, err
+	}
+	return maskTables, nil
+}
+
+// checkMaskedColumns returns ErrMaskedColumnRenamed when query, which runs
+// without a result to mask, may keep a masked column where later statements
+// read it unmasked, as in SET @x = (SELECT ssn FROM people) or INSERT ...
+// SELECT.
+func (d *databaseTransactionImpl) checkMaskedColumns(ctx context.Context, query string) error {
+	if d.masking == nil || len(d.masking.Rules) == 0 {
+		return nil
+	}
+	_, err := d.maskTables(ctx, query)
+	return err
+}
+
+func (d *databaseTransactionImpl) currentDatabase(ctx context.Context) (string, error) {
+	query := d.dialect.CurrentDatabaseQuery()
+	if query == "" {
+		return "", nil
+	}
+	rows, err := d.executor.QueryContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	var database sql.NullString
+	if rows.Next() {
+		if err := rows.Scan(&database); err != nil {
+			return "", err
+		}
+	}
+	return database.String, rows.Err()
+}
+
 func (d *databaseTransactionImpl) doExecContext(ctx context.Context, query string) error {
 	if d.executor == nil {
 		return ErrTransactionHasBeenCommittedOrRolledBack
 	}
-	_, err := d.executor.ExecContext(ctx, query)
+	if err := d.checkMaskedColumns(ctx, query); err != nil {
+		return err
+	}
+	spanCtx, span := d.startStatementSpan(ctx, query)
+	recorder := statementRecorderFromContext(ctx)
+	if recorder != nil && commitProcedurePattern.MatchString(query) {
+		err := d.execCommitProcedure(spanCtx, recorder, query)
+		endStatementSpan(span, err)
+		return err
+	}
+	start := time.Now()
+	res, err := d.executor.ExecContext(spanCtx, query)
+	endStatementSpan(span, err)
+	recorder.recordExec(query, start, res, err)
 	return err
 }
 
@@ -265,6 +391,24 @@ func (d *databaseTransactionImpl) ExecContext(ctx context.Context, query string)
 	return d.doExecContext(ctx, query)
 }
 
+func (d *databaseTransactionImpl) ExecContextRowsAffected(ctx context.Context, query string) (int64, error) {
+	if d.executor == nil {
+		return 0, ErrTransactionHasBeenCommittedOrRolledBack
+	}
+	if err := d.checkMaskedColumns(ctx, query); err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	spanCtx, span := d.startStatementSpan(ctx, query)
+	res, err := d.executor.ExecContext(spanCtx, query)
+	endStatementSpan(span, err)
+	statementRecorderFromContext(ctx).recordExec(query, start, res, err)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 func isNoActiveTransactionError(err error) bool {
 	return err != nil && strings.Contains(err.Error(), "no transaction is active")
 }
@@ -294,12 +438,14 @@ func (d *databaseTransactionImpl) finish(err error) error {
 		}
 	}
 	if d.db != nil {
+		openPools.Delete(d.db)
 		cerr := d.db.Close()
 		if err == nil {
 			err = cerr
 		}
 	}
 	if d.closeDoltLiteDB && d.doltLiteDatabase != nil {
+		openPools.Delete(d.doltLiteDatabase.db)
 		cerr := d.doltLiteDatabase.db.Close()
 		if err == nil {
 			err = cerr
@@ -358,6 +504,60 @@ func (d *databaseTransactionImpl) Commit(ctx context.Context) (err error) {
 	return
 }
 
+// openPools holds the connection pools in use, for ConnectionStats. Every
+// transaction to a server has a pool of its own, and DoltLite transactions
+// share the pool of the embedded database.
+var openPools sync.Map
+
+// poolOwner tells the pools of transactions apart from the long lived pools
+// of prepared databases and pingers, which are closed by their owners.
+type poolOwner int
+
+const (
+	sharedPool poolOwner = iota
+	transactionPool
+)
+
+// CloseOpenPools closes the pools of transactions that are still open, such
+// as the ones of tool calls interrupted by shutdown. Their transactions are
+// rolled back by the database. It returns the number of pools closed.
+func CloseOpenPools() (int, error) {
+	var closed int
+	var errs []error
+	openPools.Range(func(key, owner any) bool {
+		if owner != transactionPool {
+			return true
+		}
+		openPools.Delete(key)
+		closed++
+		if err := key.(*sql.DB).Close(); err != nil {
+			errs = append(errs, err)
+		}
+		return true
+	})
+	return closed, errors.Join(errs...)
+}
+
+// ConnectionStats counts the open database connections.
+type ConnectionStats struct {
+	Open  int
+	InUse int
+	Idle  int
+}
+
+// CurrentConnectionStats returns the connections open across every pool.
+func CurrentConnectionStats() ConnectionStats {
+	var stats ConnectionStats
+	openPools.Range(func(key, _ any) bool {
+		poolStats := key.(*sql.DB).Stats()
+		stats.Open += poolStats.OpenConnections
+		stats.InUse += poolStats.InUse
+		stats.Idle += poolStats.Idle
+		return true
+	})
+	return stats
+}
+
 type doltLiteDatabase struct {
 	db *sql.DB
 }
@@ -381,6 +581,7 @@ func PrepareDatabase(config *Config) error {
 		return err
 	}
 	config.doltLiteDatabase = database
+	openPools.Store(database.db, sharedPool)
 	return nil
 }
 
@@ -389,6 +590,7 @@ func CloseDatabase(config Config) error {
 	if config.DialectType != DialectDoltLite || config.doltLiteDatabase == nil {
 		return nil
 	}
+	openPools.Delete(config.doltLiteDatabase.db)
 	return config.doltLiteDatabase.db.Close()
 }
 
@@ -425,6 +627,7 @@ func openDoltLiteDatabase(config Config) (*doltLiteDatabase, error) {
 }
 
 func newDB(config Config) (*sql.DB, error) {
+	config = config.withPrimary()
 	dialect := NewDialect(config.DialectType)
 
 	if err := dialect.ConfigureTLS(&config); err != nil {
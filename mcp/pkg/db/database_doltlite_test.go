@@ -152,3 +152,29 @@ func TestDoltLiteCoordinatesConcurrentWriters(t *testing.T) {
 	require.Equal(t, "count\n2\n", result)
 	require.NoError(t, verify.Rollback(ctx))
 }
+
+func TestDoltLiteCommitAuthorDoesNotLeakToNextTransaction(t *testing.T) {
+	config := newPreparedDoltLiteTestConfig(t)
+	ctx := context.Background()
+	dialect := NewDialect(DialectDoltLite)
+
+	commit := func(table string, author bool) string {
+		tx, err := NewDatabaseTransaction(ctx, config)
+		require.NoError(t, err)
+		if author {
+			statements, _ := dialect.CommitAuthor("caller", "caller@example.com")
+			for _, statement := range statements {
+				require.NoError(t, tx.ExecContext(ctx, statement))
+			}
+		}
+		require.NoError(t, tx.ExecContext(ctx, "CREATE TABLE "+table+" (id INTEGER PRIMARY KEY);"))
+		require.NoError(t, tx.ExecContext(ctx, "SELECT dolt_commit('-A', '-m', 'create "+table+"');"))
+		result, err := tx.QueryContext(ctx, "SELECT committer, email FROM dolt_log ORDER BY date DESC LIMIT 1;", ResultFormatCSV)
+		require.NoError(t, err)
+		require.NoError(t, tx.Commit(ctx))
+		return result
+	}
+
+	require.Equal(t, "committer,email\ncaller,caller@example.com\n", commit("first_caller", true))
+	require.Equal(t, "committer,email\n"+DefaultDoltLiteCommitName+","+DefaultDoltLiteCommitEmail+"\n", commit("second_caller", false))
+}
@@ -17,6 +17,7 @@ var (
 	ErrInvalidSQLWriteQuery       = errors.New("invalid write query")
 	ErrInvalidCreateTableSQLQuery = errors.New("invalid create table statement")
 	ErrInvalidAlterTableSQLQuery  = errors.New("invalid alter table statement")
+	ErrMultipleSQLStatements      = errors.New("query must contain a single statement")
 )
 
 // DoltProcedure represents a Dolt stored procedure name shared across dialects.
@@ -34,6 +35,7 @@ const (
 	DoltFetch    DoltProcedure = "DOLT_FETCH"
 	DoltPush     DoltProcedure = "DOLT_PUSH"
 	DoltPull     DoltProcedure = "DOLT_PULL"
+	DoltConfig   DoltProcedure = "DOLT_CONFIG"
 )
 
 // Dialect encapsulates all SQL dialect differences between database engines.
@@ -49,9 +51,16 @@ type Dialect interface {
 	// SQL generation
 	QuoteIdentifier(name string) string
 	CallProcedure(proc DoltProcedure, args ...string) string
+	// CommitAuthor returns the statements to run in a transaction, and the
+	// arguments to pass to DOLT_COMMIT or DOLT_MERGE, so that the commit is
+	// authored by name and email instead of the connection's user.
+	CommitAuthor(name, email string) (statements []string, args []string)
 	// UseDatabase returns a statement selecting the given database, or ""
 	// when the dialect has no database selection (single-database engines).
 	UseDatabase(database string) string
+	// CurrentDatabaseQuery returns a query selecting the name of the current
+	// database, or "" when the dialect has no database selection.
+	CurrentDatabaseQuery() string
 
 	// Schema inspection statements, which have no common syntax across engines.
 	ShowTablesQuery() string
@@ -71,6 +80,26 @@ type Dialect interface {
 	ValidateWriteQuery(query string) error
 	ValidateCreateTableQuery(query string) error
 	ValidateAlterTableQuery(query string) error
+	// ValidateQueryPolicy checks the statements in query against policy,
+	// with database as the working database. A nil policy allows anything.
+	ValidateQueryPolicy(query, database string, policy *SQLPolicy) error
+	// ReferencedTables returns the tables the statements in query reference,
+	// as "database.table" when the reference names a database. It returns
+	// ErrTableFunctionReference when a statement reads from a table function.
+	ReferencedTables(query string) ([]string, error)
+	// DerivedColumns returns the names of the columns the statements in
+	// query may return under a name other than their own: those in aliased
+	// or computed select expressions, in set operations after the first, and
+	// in subqueries whose columns are renamed. "*" stands for every column of
+	// a table.
+	DerivedColumns(query string) ([]string, error)
+	// StatementTargets returns the databases, revisions and Dolt procedures
+	// the statements in query name.
+	StatementTargets(query string) (StatementTargets, error)
+	// StatementKinds returns the kind of each statement in query, such as
+	// "SELECT" or "CREATE TABLE", followed by "CALL DOLT_*" for each Dolt
+	// procedure it calls.
+	StatementKinds(query string) ([]string, error)
 }
 
 // NewDialect creates a Dialect for the given DialectType.
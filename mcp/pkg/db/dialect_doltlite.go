@@ -87,10 +87,24 @@ func (d *DoltLiteDialect) CallProcedure(proc DoltProcedure, args ...string) stri
 	return fmt.Sprintf("SELECT %s(%s);", fnName, strings.Join(quotedArgs, ", "))
 }
 
+func (d *DoltLiteDialect) CommitAuthor(name, email string) ([]string, []string) {
+	// The author is configured on the connection, as for --commit-name and
+	// --commit-email. Connections are pooled, and every new transaction sets
+	// the configured author again.
+	return []string{
+		d.CallProcedure(DoltConfig, "user.name", name),
+		d.CallProcedure(DoltConfig, "user.email", email),
+	}, nil
+}
+
 func (d *DoltLiteDialect) UseDatabase(_ string) string {
 	return ""
 }
 
+func (d *DoltLiteDialect) CurrentDatabaseQuery() string {
+	return ""
+}
+
 func (d *DoltLiteDialect) ShowTablesQuery() string {
 	return "SELECT name FROM sqlite_schema WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name;"
 }
@@ -118,37 +132,8 @@ func (d *DoltLiteDialect) ListTableDiffChangesQuery(table, fromExpr, toExpr stri
 	return fmt.Sprintf("SELECT * FROM %s(%s, %s);", diffTable, fromExpr, toExpr)
 }
 
-func liteLeadingKeyword(query string) string {
-	s := query
-	for {
-		s = strings.TrimLeft(s, " \t\r\n;")
-		switch {
-		case strings.HasPrefix(s, "--"):
-			idx := strings.Index(s, "\n")
-			if idx < 0 {
-				return ""
-			}
-			s = s[idx+1:]
-		case strings.HasPrefix(s, "/*"):
-			idx := strings.Index(s, "*/")
-			if idx < 0 {
-				return ""
-			}
-			s = s[idx+2:]
-		default:
-			end := strings.IndexFunc(s, func(r rune) bool {
-				return !((r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_')
-			})
-			if end < 0 {
-				end = len(s)
-			}
-			return strings.ToUpper(s[:end])
-		}
-	}
-}
-
 func liteSecondKeyword(query string) string {
-	first := liteLeadingKeyword(query)
+	first := leadingKeyword(query)
 	if first == "" {
 		return ""
 	}
@@ -157,7 +142,7 @@ func liteSecondKeyword(query string) string {
 	if idx < 0 {
 		return ""
 	}
-	return liteLeadingKeyword(s[idx+len(first):])
+	return leadingKeyword(s[idx+len(first):])
 }
 
 var liteReadOnlyKeywords = map[string]bool{
@@ -279,10 +264,10 @@ var liteMutatingFunctionPattern = regexp.MustCompile(
 )
 
 func (d *DoltLiteDialect) ValidateReadQuery(query string) error {
-	if !liteReadOnlyKeywords[liteLeadingKeyword(query)] {
-		return ErrInvalidSQLReadQuery
-	}
 	if liteContainsMultipleStatements(query) {
+		return ErrMultipleSQLStatements
+	}
+	if !liteReadOnlyKeywords[leadingKeyword(query)] {
 		return ErrInvalidSQLReadQuery
 	}
 	if liteMutatingFunctionPattern.MatchString(query) {
@@ -292,7 +277,10 @@ func (d *DoltLiteDialect) ValidateReadQuery(query string) error {
 }
 
 func (d *DoltLiteDialect) ValidateWriteQuery(query string) error {
-	keyword := liteLeadingKeyword(query)
+	if liteContainsMultipleStatements(query) {
+		return ErrMultipleSQLStatements
+	}
+	keyword := leadingKeyword(query)
 	if keyword == "" {
 		return ErrInvalidSQLWriteQuery
 	}
@@ -306,15 +294,511 @@ func (d *DoltLiteDialect) ValidateWriteQuery(query string) error {
 }
 
 func (d *DoltLiteDialect) ValidateCreateTableQuery(query string) error {
-	if liteLeadingKeyword(query) == "CREATE" && liteSecondKeyword(query) == "TABLE" {
+	if liteContainsMultipleStatements(query) {
+		return ErrMultipleSQLStatements
+	}
+	if leadingKeyword(query) == "CREATE" && liteSecondKeyword(query) == "TABLE" {
 		return nil
 	}
 	return ErrInvalidCreateTableSQLQuery
 }
 
 func (d *DoltLiteDialect) ValidateAlterTableQuery(query string) error {
-	if liteLeadingKeyword(query) == "ALTER" && liteSecondKeyword(query) == "TABLE" {
+	if liteContainsMultipleStatements(query) {
+		return ErrMultipleSQLStatements
+	}
+	if leadingKeyword(query) == "ALTER" && liteSecondKeyword(query) == "TABLE" {
 		return nil
 	}
 	return ErrInvalidAlterTableSQLQuery
 }
+
+// SQL policy checks by scanning tokens. DoltLite statements are not parsed
+// in this process, so table references are recognized by the keywords that
+// introduce them.
+
+func (d *DoltLiteDialect) ValidateQueryPolicy(query, database string, policy *SQLPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	return policy.checkAll(d.policyStatements(query), database)
+}
+
+// policyStatements returns what query touches. Statements are not split, so
+// a query is taken for one statement.
+func (d *DoltLiteDialect) policyStatements(query string) []policyStatement {
+	return []policyStatement{litePolicyStatement(query)}
+}
+
+func (d *DoltLiteDialect) ReferencedTables(query string) ([]string, error) {
+	return referencedTableNames(d.policyStatements(query)...)
+}
+
+func (d *DoltLiteDialect) StatementKinds(query string) ([]string, error) {
+	return statementKinds(d.policyStatements(query)...), nil
+}
+
+func (d *DoltLiteDialect) StatementTargets(query string) (StatementTargets, error) {
+	return newStatementTargets(d.policyStatements(query)...), nil
+}
+
+func (d *DoltLiteDialect) DerivedColumns(query string) ([]string, error) {
+	return liteDerivedColumns(query), nil
+}
+
+// liteDerivedColumns returns the names in query that may reach its result
+// under a name other than their own. The tokens are not parsed, so every
+// name counts in a query with more than one select or RETURNING list, and in
+// the list of a query with one, every name in an item that is not a column
+// selected under its own name. Statements that write rows keep what they
+// read out of reach of masking, so the names they write count too.
+func liteDerivedColumns(query string) []string {
+	tokens := liteTokens(query)
+	lists, start := 0, 0
+	for i, t := range tokens {
+		if kw := t.keyword(); kw == "SELECT" || kw == "RETURNING" {
+			lists++
+			start = i + 1
+		}
+	}
+	names := func(tokens []liteToken) []string {
+		var names []string
+		for _, t := range tokens {
+			if t.name() {
+				names = append(names, t.text)
+			}
+		}
+		return names
+	}
+	var derived []string
+	switch leadingKeyword(query) {
+	case "SELECT", "WITH", "VALUES":
+	default:
+		derived = liteWrittenNames(tokens)
+	}
+	switch {
+	case lists == 0:
+		return derived
+	case lists > 1:
+		return names(tokens)
+	}
+
+	var item []liteToken
+	flush := func() {
+		if !liteSelectsColumnAsItself(item) {
+			derived = append(derived, names(item)...)
+		}
+		item = nil
+	}
+	depth := 0
+	for _, t := range tokens[start:] {
+		switch t.text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+		if depth == 0 && (t.keyword() == "FROM" || t.text == ";") {
+			break
+		}
+		if depth == 0 && t.text == "," {
+			flush()
+			continue
+		}
+		if len(item) == 0 && (t.keyword() == "DISTINCT" || t.keyword() == "ALL") {
+			continue
+		}
+		item = append(item, t)
+	}
+	flush()
+	return derived
+}
+
+// liteWrittenNames returns the names whose values a statement writing rows
+// may write: every name after its first SELECT, as in INSERT ... SELECT, or
+// the names after the = of its SET assignments.
+func liteWrittenNames(tokens []liteToken) []string {
+	var names []string
+	for i, t := range tokens {
+		switch t.keyword() {
+		case "SELECT":
+			for _, t := range tokens[i+1:] {
+				if t.name() {
+					names = append(names, t.text)
+				}
+			}
+			return names
+		case "SET":
+			depth, value := 0, false
+			for _, t := range tokens[i+1:] {
+				switch {
+				case t.text == "(":
+					depth++
+				case t.text == ")":
+					depth--
+				case depth == 0 && t.text == ",":
+					value = false
+				case depth == 0 && t.text == "=":
+					value = true
+				case depth == 0 && (t.keyword() == "WHERE" || t.keyword() == "FROM" || t.keyword() == "RETURNING" || t.text == ";"):
+					return names
+				case value && t.name():
+					names = append(names, t.text)
+				}
+			}
+			return names
+		}
+	}
+	return nil
+}
+
+// liteSelectsColumnAsItself reports whether a select list item is *, a
+// possibly qualified column or table.*, optionally aliased to its own name.
+func liteSelectsColumnAsItself(item []liteToken) bool {
+	i := 0
+	for i+2 < len(item) && item[i].ident && item[i+1].text == "." {
+		i += 2
+	}
+	if i >= len(item) || !(item[i].name() || item[i].text == "*") {
+		return false
+	}
+	column := item[i]
+	rest := item[i+1:]
+	if len(rest) > 0 && rest[0].keyword() == "AS" {
+		rest = rest[1:]
+	}
+	switch len(rest) {
+	case 0:
+		return true
+	case 1:
+		return column.text != "*" && rest[0].name() && strings.EqualFold(rest[0].text, column.text)
+	}
+	return false
+}
+
+// liteArguments returns the arguments of the call whose tokens after the
+// opening parenthesis are tokens, with "" for an argument that is not a
+// string literal, and whether every argument is one.
+func liteArguments(tokens []liteToken) ([]string, bool) {
+	var arguments []string
+	literals := true
+	var argument []liteToken
+	depth := 0
+	for _, t := range tokens {
+		switch {
+		case t.text == "(":
+			depth++
+		case t.text == ")" && depth > 0:
+			depth--
+		case (t.text == ")" || t.text == ",") && depth == 0:
+			if len(argument) == 1 && argument[0].text == "'" && !argument[0].ident {
+				arguments = append(arguments, argument[0].value)
+			} else if len(argument) > 0 || t.text == "," {
+				arguments = append(arguments, "")
+				literals = false
+			}
+			if t.text == ")" {
+				return arguments, literals
+			}
+			argument = nil
+			continue
+		}
+		argument = append(argument, t)
+	}
+	return arguments, false
+}
+
+type liteToken struct {
+	text   string
+	ident  bool
+	quoted bool
+	// value is the value of a string literal, whose text is "'".
+	value string
+}
+
+func (t liteToken) keyword() string {
+	if !t.ident || t.quoted {
+		return ""
+	}
+	upper := strings.ToUpper(t.text)
+	if liteKeywords[upper] {
+		return upper
+	}
+	return ""
+}
+
+func (t liteToken) name() bool {
+	return t.ident && t.keyword() == ""
+}
+
+var liteKeywords = func() map[string]bool {
+	keywords := map[string]bool{}
+	for _, k := range strings.Fields(`ABORT ACTION ADD AFTER ALL ALTER ALWAYS ANALYZE AND AS ASC ATTACH
+		AUTOINCREMENT BEFORE BEGIN BETWEEN BY CASCADE CASE CAST CHECK COLLATE COLUMN COMMIT CONFLICT
+		CONSTRAINT CREATE CROSS CURRENT CURRENT_DATE CURRENT_TIME CURRENT_TIMESTAMP DATABASE DEFAULT
+		DEFERRABLE DEFERRED DELETE DESC DETACH DISTINCT DO DROP EACH ELSE END ESCAPE EXCEPT EXCLUDE
+		EXCLUSIVE EXISTS EXPLAIN FAIL FILTER FIRST FOLLOWING FOR FOREIGN FROM FULL GENERATED GLOB GROUP
+		GROUPS HAVING IF IGNORE IMMEDIATE IN INDEX INDEXED INITIALLY INNER INSERT INSTEAD INTERSECT INTO
+		IS ISNULL JOIN KEY LAST LEFT LIKE LIMIT MATCH MATERIALIZED NATURAL NO NOT NOTHING NOTNULL NULL
+		NULLS OF OFFSET ON OR ORDER OTHERS OUTER OVER PARTITION PLAN PRAGMA PRECEDING PRIMARY QUERY RAISE
+		RANGE RECURSIVE REFERENCES REGEXP REINDEX RELEASE RENAME REPLACE RESTRICT RETURNING RIGHT
+		ROLLBACK ROW ROWS SAVEPOINT SELECT SET TABLE TEMP TEMPORARY THEN TIES TO TRANSACTION TRIGGER
+		UNBOUNDED UNION UNIQUE UPDATE USING VACUUM VALUES VIEW VIRTUAL WHEN WHERE WINDOW WITH WITHOUT`) {
+		keywords[k] = true
+	}
+	return keywords
+}()
+
+// liteTokens splits query into identifiers and punctuation, dropping
+// comments and replacing string and numeric literals with placeholders.
+func liteTokens(query string) []liteToken {
+	var tokens []liteToken
+	isWord := func(c byte) bool {
+		return c == '_' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9') || c >= 0x80
+	}
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			i++
+		case strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexAny(query[i:], "\r\n")
+			if end < 0 {
+				return tokens
+			}
+			i += end
+		case strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				return tokens
+			}
+			i += end + 4
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			var b strings.Builder
+			j := i + 1
+			for j < len(query) {
+				if query[j] == closing {
+					if closing != ']' && j+1 < len(query) && query[j+1] == closing {
+						b.WriteByte(closing)
+						j += 2
+						continue
+					}
+					break
+				}
+				b.WriteByte(query[j])
+				j++
+			}
+			if c == '\'' {
+				tokens = append(tokens, liteToken{text: "'", value: b.String()})
+			} else {
+				tokens = append(tokens, liteToken{text: b.String(), ident: true, quoted: true})
+			}
+			i = j + 1
+		case isWord(c):
+			j := i
+			for j < len(query) && isWord(query[j]) {
+				j++
+			}
+			word := query[i:j]
+			tokens = append(tokens, liteToken{text: word, ident: c < '0' || c > '9'})
+			i = j
+		default:
+			tokens = append(tokens, liteToken{text: string(c)})
+			i++
+		}
+	}
+	return tokens
+}
+
+// liteStatementKind returns the statement kind named by the leading
+// keywords, such as "INSERT" or "DROP TABLE".
+func liteStatementKind(tokens []liteToken) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+	first := tokens[0].keyword()
+	switch first {
+	case "CREATE", "DROP", "ALTER":
+		for _, t := range tokens[1:] {
+			switch kw := t.keyword(); kw {
+			case "TEMP", "TEMPORARY", "UNIQUE", "VIRTUAL":
+			case "":
+				return first
+			default:
+				return first + " " + kw
+			}
+		}
+	case "ATTACH", "DETACH":
+		return first + " DATABASE"
+	case "WITH":
+		depth := 0
+		for _, t := range tokens[1:] {
+			switch {
+			case t.text == "(":
+				depth++
+			case t.text == ")":
+				depth--
+			case depth == 0 && (t.keyword() == "INSERT" || t.keyword() == "REPLACE" || t.keyword() == "UPDATE" || t.keyword() == "DELETE"):
+				return t.keyword()
+			}
+		}
+		return "SELECT"
+	}
+	if first == "" {
+		return strings.ToUpper(tokens[0].text)
+	}
+	return first
+}
+
+// liteDatabaseName maps a schema qualifier to the database it names, or ""
+// for the schemas of the open database itself.
+func liteDatabaseName(schema string) string {
+	if strings.EqualFold(schema, "main") || strings.EqualFold(schema, "temp") {
+		return ""
+	}
+	return schema
+}
+
+func litePolicyStatement(query string) policyStatement {
+	var s policyStatement
+	tokens := liteTokens(query)
+	kind := liteStatementKind(tokens)
+	s.addKind(kind, strings.TrimSpace(query))
+
+	if kind == "ATTACH DATABASE" || kind == "DETACH DATABASE" {
+		for i := len(tokens) - 1; i >= 0; i-- {
+			if tokens[i].name() {
+				s.addDatabase(tokens[i].text, strings.TrimSpace(query))
+				break
+			}
+		}
+		return s
+	}
+
+	// qualifiedName reads a dotted name starting at i and returns its parts
+	// and the index of the token following it.
+	qualifiedName := func(i int) ([]string, int) {
+		parts := []string{tokens[i].text}
+		for i+2 < len(tokens) && tokens[i+1].text == "." && tokens[i+2].ident {
+			parts = append(parts, tokens[i+2].text)
+			i += 2
+		}
+		return parts, i + 1
+	}
+
+	aliases := map[string]string{}
+	tableIntroducers := map[string]bool{"FROM": true, "JOIN": true, "INTO": true, "UPDATE": true, "TABLE": true}
+	if kind == "CREATE INDEX" || kind == "CREATE TRIGGER" {
+		tableIntroducers["ON"] = true
+	}
+
+	type liteColumn struct {
+		qualifier []string
+		name      string
+		star      bool
+	}
+	var columns []liteColumn
+	expectTable, inFromList := false, false
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		if kw := t.keyword(); kw != "" {
+			switch {
+			case tableIntroducers[kw]:
+				expectTable = true
+				inFromList = kw == "FROM" || kw == "JOIN"
+			case kw == "IF" || kw == "NOT" || kw == "EXISTS":
+			default:
+				expectTable, inFromList = false, false
+			}
+			if kw == "SELECT" || kw == "DISTINCT" || kw == "ALL" {
+				if i+1 < len(tokens) && tokens[i+1].text == "*" {
+					columns = append(columns, liteColumn{star: true})
+					i++
+				}
+			}
+			continue
+		}
+
+		if !t.ident {
+			switch t.text {
+			case ",":
+				expectTable = inFromList
+				if !inFromList && i+1 < len(tokens) && tokens[i+1].text == "*" {
+					columns = append(columns, liteColumn{star: true})
+					i++
+				}
+			default:
+				expectTable = false
+			}
+			continue
+		}
+
+		// A name followed by ( is a call, except for the table of INSERT
+		// INTO t (...), CREATE TABLE t (...) and the like.
+		parts, next := qualifiedName(i)
+		if next < len(tokens) && tokens[next].text == "(" && (!expectTable || inFromList) {
+			arguments, literals := liteArguments(tokens[next+1:])
+			if procedure, ok := doltProcedureName(parts[len(parts)-1]); ok {
+				s.addKind("CALL "+procedure, strings.Join(parts, ".")+"(...)")
+				s.addProcedure(procedure, arguments, literals, strings.Join(parts, ".")+"(...)")
+			}
+			if expectTable {
+				s.addTableFunction(parts[len(parts)-1], arguments, literals, strings.Join(parts, ".")+"(...)")
+			}
+			expectTable = false
+			i = next - 1
+			continue
+		}
+
+		if expectTable {
+			table := parts[len(parts)-1]
+			schema := ""
+			if len(parts) > 1 {
+				schema = liteDatabaseName(parts[len(parts)-2])
+			}
+			s.addTable(schema, table, strings.Join(parts, "."))
+			if next < len(tokens) && tokens[next].keyword() == "AS" {
+				next++
+			}
+			if next < len(tokens) && tokens[next].name() {
+				aliases[strings.ToLower(tokens[next].text)] = table
+				next++
+			}
+			expectTable = false
+			i = next - 1
+			continue
+		}
+
+		if next+1 < len(tokens) && tokens[next].text == "." && tokens[next+1].text == "*" {
+			columns = append(columns, liteColumn{qualifier: parts, star: true})
+			i = next + 1
+			continue
+		}
+		columns = append(columns, liteColumn{qualifier: parts[:len(parts)-1], name: parts[len(parts)-1]})
+		i = next - 1
+	}
+
+	for _, column := range columns {
+		table := ""
+		if len(column.qualifier) > 0 {
+			table = column.qualifier[len(column.qualifier)-1]
+			if resolved, ok := aliases[strings.ToLower(table)]; ok {
+				table = resolved
+			}
+		}
+		if len(column.qualifier) > 1 {
+			s.addDatabase(liteDatabaseName(column.qualifier[len(column.qualifier)-2]), strings.Join(column.qualifier, "."))
+		}
+		clause := strings.Join(append(append([]string{}, column.qualifier...), column.name), ".")
+		if column.star {
+			s.addStar(table, strings.Join(append(append([]string{}, column.qualifier...), "*"), "."))
+			continue
+		}
+		s.addColumn(table, column.name, clause)
+	}
+	return s
+}
@@ -34,6 +34,19 @@ func TestDoltLiteDialectCallProcedure(t *testing.T) {
 		d.CallProcedure(DoltPush, "--force", "origin", "main"))
 }
 
+func TestDialectCommitAuthor(t *testing.T) {
+	statements, args := NewDoltLiteDialect().CommitAuthor("Alice O'Neil", "alice@example.com")
+	require.Equal(t, []string{
+		"SELECT dolt_config('user.name', 'Alice O''Neil');",
+		"SELECT dolt_config('user.email', 'alice@example.com');",
+	}, statements)
+	require.Empty(t, args)
+
+	statements, args = NewMySQLDialect().CommitAuthor("Alice", "alice@example.com")
+	require.Empty(t, statements)
+	require.Equal(t, []string{"--author", "Alice <alice@example.com>"}, args)
+}
+
 func TestDoltLiteDialectSQLGeneration(t *testing.T) {
 	d := NewDoltLiteDialect()
 
@@ -187,3 +200,13 @@ func TestDoltLiteDialectValidateDDLQueries(t *testing.T) {
 	require.Error(t, d.ValidateAlterTableQuery("CREATE TABLE t (id INTEGER);"))
 	require.Error(t, d.ValidateAlterTableQuery("SELECT 1;"))
 }
+
+func TestDoltLiteDialectRejectsMultipleStatements(t *testing.T) {
+	d := NewDoltLiteDialect()
+
+	require.ErrorIs(t, d.ValidateReadQuery("SELECT 1; DELETE FROM people;"), ErrMultipleSQLStatements)
+	require.ErrorIs(t, d.ValidateWriteQuery("INSERT INTO people VALUES (1); DROP TABLE people;"), ErrMultipleSQLStatements)
+	require.ErrorIs(t, d.ValidateCreateTableQuery("CREATE TABLE t (id INTEGER); DROP TABLE people;"), ErrMultipleSQLStatements)
+	require.ErrorIs(t, d.ValidateAlterTableQuery("ALTER TABLE t ADD COLUMN c TEXT; DROP TABLE people;"), ErrMultipleSQLStatements)
+	require.NoError(t, d.ValidateWriteQuery("INSERT INTO people VALUES (1); -- done"))
+}
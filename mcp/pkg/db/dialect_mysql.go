@@ -3,6 +3,7 @@ package db
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
@@ -41,6 +42,9 @@ func (d *MySQLDialect) FormatDSN(c Config) string {
 	}
 
 	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/", c.User, c.Password, c.Host, c.Port)
+	if c.Socket != "" {
+		dsn = fmt.Sprintf("%s:%s@unix(%s)/", c.User, c.Password, c.Socket)
+	}
 	if c.DatabaseName != "" {
 		dsn += c.DatabaseName
 	}
@@ -55,6 +59,9 @@ func (d *MySQLDialect) FormatDSN(c Config) string {
 	if c.TLS != "" {
 		options = append(options, fmt.Sprintf("tls=%s", c.TLS))
 	}
+	if c.AllowCleartextPasswords {
+		options = append(options, "allowCleartextPasswords=true")
+	}
 	if len(options) > 0 {
 		dsn += "?" + strings.Join(options, "&")
 	}
@@ -96,10 +103,18 @@ func (d *MySQLDialect) CallProcedure(proc DoltProcedure, args ...string) string
 	return fmt.Sprintf("CALL %s(%s);", string(proc), strings.Join(quotedArgs, ", "))
 }
 
+func (d *MySQLDialect) CommitAuthor(name, email string) ([]string, []string) {
+	return nil, []string{"--author", fmt.Sprintf("%s <%s>", name, email)}
+}
+
 func (d *MySQLDialect) UseDatabase(database string) string {
 	return fmt.Sprintf("USE %s;", d.QuoteIdentifier(database))
 }
 
+func (d *MySQLDialect) CurrentDatabaseQuery() string {
+	return "SELECT DATABASE();"
+}
+
 func (d *MySQLDialect) ShowTablesQuery() string {
 	return "SHOW TABLES;"
 }
@@ -123,10 +138,45 @@ func (d *MySQLDialect) ListTableDiffChangesQuery(table, fromExpr, toExpr string)
 
 // SQL validation using the Vitess MySQL parser.
 
-func (d *MySQLDialect) parseSQLQuery(query string) (sqlparser.Statement, error) {
+// parseSQLStatements parses every statement in query, and returns the text
+// of each statement too. The parser stops after the first statement it reads,
+// so the rest of the query is parsed in turn.
+func (d *MySQLDialect) parseSQLStatements(query string) ([]sqlparser.Statement, []string, error) {
 	sqlCtx := gosql.NewEmptyContext()
 	sqlMode := gosql.LoadSqlMode(sqlCtx)
-	return sqlparser.ParseWithOptions(sqlCtx, query, sqlMode.ParserOptions())
+
+	var stmts []sqlparser.Statement
+	var texts []string
+	remainder := query
+	for {
+		stmt, next, err := sqlparser.ParseOneWithOptions(sqlCtx, remainder, sqlMode.ParserOptions())
+		if errors.Is(err, sqlparser.ErrEmpty) && len(stmts) > 0 {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		stmts = append(stmts, stmt)
+		if next <= 0 || next >= len(remainder) {
+			texts = append(texts, remainder)
+			break
+		}
+		texts = append(texts, remainder[:next])
+		remainder = remainder[next:]
+	}
+	return stmts, texts, nil
+}
+
+// parseSQLQuery parses query, which must hold exactly one statement.
+func (d *MySQLDialect) parseSQLQuery(query string) (sqlparser.Statement, error) {
+	stmts, _, err := d.parseSQLStatements(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(stmts) > 1 {
+		return nil, ErrMultipleSQLStatements
+	}
+	return stmts[0], nil
 }
 
 func (d *MySQLDialect) isReadOnlyStatement(stmt sqlparser.Statement) bool {
@@ -187,3 +237,392 @@ func (d *MySQLDialect) ValidateAlterTableQuery(query string) error {
 	}
 	return ErrInvalidAlterTableSQLQuery
 }
+
+// SQL policy checks using the Vitess parse tree.
+
+func (d *MySQLDialect) ValidateQueryPolicy(query, database string, policy *SQLPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	stmts, err := d.policyStatements(query)
+	if err != nil {
+		return err
+	}
+	return policy.checkAll(stmts, database)
+}
+
+// policyStatements returns what each statement of query touches.
+func (d *MySQLDialect) policyStatements(query string) ([]policyStatement, error) {
+	stmts, texts, err := d.parseSQLStatements(query)
+	if err != nil {
+		return nil, err
+	}
+	policyStmts := make([]policyStatement, len(stmts))
+	for i, stmt := range stmts {
+		policyStmts[i] = mysqlPolicyStatement(texts[i], stmt)
+	}
+	return policyStmts, nil
+}
+
+// mysqlPolicyStatement extracts what stmt touches. text is the statement's
+// own text, whose leading keyword is its kind when the parse tree has none.
+func mysqlPolicyStatement(text string, stmt sqlparser.Statement) policyStatement {
+	var s policyStatement
+	kind := mysqlStatementKind(stmt)
+	if kind == "" {
+		kind = leadingKeyword(text)
+	}
+	s.addKind(kind, sqlparser.String(stmt))
+
+	// Collect table aliases and common table expression names first, so
+	// that qualified columns resolve to their tables and references to
+	// aliases or CTEs are not taken for tables.
+	aliases := map[string]string{}
+	ctes := map[string]bool{}
+	walkMySQLStatement(func(node sqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case *sqlparser.CommonTableExpr:
+			if n != nil && n.AliasedTableExpr != nil {
+				ctes[strings.ToLower(n.As.String())] = true
+			}
+		case *sqlparser.AliasedTableExpr:
+			if n == nil || n.As.IsEmpty() {
+				break
+			}
+			if t, ok := n.Expr.(sqlparser.TableName); ok {
+				aliases[strings.ToLower(n.As.String())] = t.Name.String()
+			}
+		}
+		return true, nil
+	}, stmt)
+	resolve := func(t sqlparser.TableName) string {
+		if table, ok := aliases[strings.ToLower(t.Name.String())]; ok && t.DbQualifier.IsEmpty() {
+			return table
+		}
+		return t.Name.String()
+	}
+
+	var visit sqlparser.Visit
+	visit = func(node sqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case sqlparser.TableName:
+			name := strings.ToLower(n.Name.String())
+			if n.DbQualifier.IsEmpty() && (ctes[name] || (aliases[name] != "" && !strings.EqualFold(aliases[name], name))) {
+				return false, nil
+			}
+			s.addTable(n.DbQualifier.String(), n.Name.String(), sqlparser.String(n))
+			return false, nil
+		case *sqlparser.ColName:
+			if n != nil {
+				s.addDatabase(n.Qualifier.DbQualifier.String(), sqlparser.String(n))
+				s.addColumn(resolve(n.Qualifier), n.Name.String(), sqlparser.String(n))
+			}
+			return false, nil
+		case *sqlparser.StarExpr:
+			if n != nil {
+				s.addDatabase(n.TableName.DbQualifier.String(), sqlparser.String(n))
+				s.addStar(resolve(n.TableName), sqlparser.String(n))
+			}
+			return false, nil
+		case *sqlparser.FuncExpr:
+			if n == nil {
+				return false, nil
+			}
+			if procedure, ok := doltProcedureName(n.Name.String()); ok {
+				s.addKind("CALL "+procedure, sqlparser.String(n))
+				arguments, literals := mysqlSelectArguments(n.Exprs)
+				s.addProcedure(procedure, arguments, literals, sqlparser.String(n))
+			}
+			// COUNT(*) and similar do not read every column.
+			for _, expr := range n.Exprs {
+				if _, ok := expr.(*sqlparser.StarExpr); !ok {
+					_ = sqlparser.Walk(visit, expr)
+				}
+			}
+			_ = sqlparser.Walk(visit, n.Over)
+			return false, nil
+		case sqlparser.TableFuncExpr:
+			mysqlTableFunction(&s, &n)
+		case *sqlparser.TableFuncExpr:
+			if n != nil {
+				mysqlTableFunction(&s, n)
+			}
+		case *sqlparser.Insert:
+			for _, column := range n.Columns {
+				s.addColumn(n.Table.Name.String(), column.String(), sqlparser.String(column))
+			}
+		case *sqlparser.Load:
+			for _, column := range n.Columns {
+				s.addColumn(n.Table.Name.String(), column.String(), sqlparser.String(column))
+			}
+		case *sqlparser.Call:
+			s.addDatabase(n.ProcName.Qualifier.String(), sqlparser.String(n))
+			mysqlRevision(&s, n.AsOf, sqlparser.String(n))
+			if procedure, ok := doltProcedureName(n.ProcName.Name.String()); ok {
+				arguments, literals := mysqlArguments(n.Params)
+				s.addProcedure(procedure, arguments, literals, sqlparser.String(n))
+			}
+		case *sqlparser.AsOf:
+			if n != nil {
+				mysqlRevision(&s, n.Time, sqlparser.String(n))
+				mysqlRevision(&s, n.Start, sqlparser.String(n))
+				mysqlRevision(&s, n.End, sqlparser.String(n))
+				if n.All {
+					s.addRevision("", sqlparser.String(n))
+				}
+			}
+		case *sqlparser.Use:
+			s.addDatabase(n.DBName.String(), sqlparser.String(n))
+		case *sqlparser.DBDDL:
+			s.addDatabase(n.DBName, sqlparser.String(n))
+		case *sqlparser.Show:
+			s.addDatabase(n.Database, sqlparser.String(n))
+			if n.ShowTablesOpt != nil {
+				s.addDatabase(n.ShowTablesOpt.DbName, sqlparser.String(n))
+				mysqlRevision(&s, n.ShowTablesOpt.AsOf, sqlparser.String(n))
+			}
+		}
+		return true, nil
+	}
+	walkMySQLStatement(visit, stmt)
+
+	// Result columns are masked by name, so record every column that may
+	// reach a result under another name.
+	derive := func(node sqlparser.SQLNode) {
+		_ = sqlparser.Walk(func(node sqlparser.SQLNode) (bool, error) {
+			switch n := node.(type) {
+			case *sqlparser.ColName:
+				if n != nil {
+					s.addDerived(resolve(n.Qualifier), n.Name.String(), sqlparser.String(n))
+				}
+			case *sqlparser.StarExpr:
+				if n != nil {
+					s.addDerived(resolve(n.TableName), "*", sqlparser.String(n))
+				}
+			}
+			return true, nil
+		}, node)
+	}
+	walkMySQLStatement(func(node sqlparser.SQLNode) (bool, error) {
+		switch n := node.(type) {
+		case *sqlparser.DDL:
+			// CREATE TABLE ... AS SELECT keeps values for later statements,
+			// out of reach of masking.
+			if n != nil && n.OptSelect != nil {
+				derive(n.OptSelect.Select)
+			}
+		case *sqlparser.Set:
+			// So do user variables, as in SET @x = (SELECT ...), and the
+			// rows a statement writes.
+			if n != nil {
+				for _, expr := range n.Exprs {
+					derive(expr.Expr)
+				}
+			}
+		case *sqlparser.Insert:
+			if n != nil {
+				derive(n.Rows)
+				for _, expr := range n.OnDup {
+					derive(expr.Expr)
+				}
+			}
+		case *sqlparser.Update:
+			if n != nil {
+				for _, expr := range n.Exprs {
+					derive(expr.Expr)
+				}
+			}
+		case *sqlparser.Select:
+			if n == nil {
+				break
+			}
+			for _, expr := range n.SelectExprs {
+				aliased, ok := expr.(*sqlparser.AliasedExpr)
+				if !ok {
+					// SELECT ... INTO keeps values for later statements,
+					// out of reach of masking.
+					if n.Into != nil {
+						derive(expr)
+					}
+					continue
+				}
+				column, ok := aliased.Expr.(*sqlparser.ColName)
+				if n.Into != nil || !ok || !(aliased.As.IsEmpty() || aliased.As.Equal(column.Name)) {
+					derive(aliased.Expr)
+				}
+			}
+		case *sqlparser.SetOp:
+			// The columns of a union are named after its first select.
+			if n != nil {
+				derive(n.Right)
+			}
+		case *sqlparser.Subquery:
+			if n != nil && len(n.Columns) > 0 {
+				derive(n.Select)
+			}
+		case *sqlparser.CommonTableExpr:
+			if n != nil && len(n.Columns) > 0 && n.AliasedTableExpr != nil {
+				derive(n.AliasedTableExpr.Expr)
+			}
+		}
+		return true, nil
+	}, stmt)
+	return s
+}
+
+// walkMySQLStatement walks stmt like sqlparser.Walk, and also the parts of
+// DDL statements sqlparser.Walk leaves out: the table spec, the select of
+// CREATE TABLE ... AS SELECT, and the tables of CREATE TABLE ... LIKE.
+func walkMySQLStatement(visit sqlparser.Visit, stmt sqlparser.SQLNode) {
+	var walk sqlparser.Visit
+	walk = func(node sqlparser.SQLNode) (bool, error) {
+		kontinue, err := visit(node)
+		if ddl, ok := node.(*sqlparser.DDL); ok && ddl != nil && kontinue && err == nil {
+			if ddl.TableSpec != nil {
+				_ = sqlparser.Walk(walk, ddl.TableSpec)
+			}
+			if ddl.OptSelect != nil {
+				_ = sqlparser.Walk(walk, ddl.OptSelect.Select)
+			}
+			if ddl.OptLike != nil {
+				for _, table := range ddl.OptLike.LikeTables {
+					_ = sqlparser.Walk(walk, table)
+				}
+			}
+		}
+		return kontinue, err
+	}
+	_ = sqlparser.Walk(walk, stmt)
+}
+
+// mysqlRevision records the revision an AS OF clause reads, which is only
+// known when it is a string literal.
+func mysqlRevision(s *policyStatement, expr sqlparser.Expr, clause string) {
+	if expr == nil {
+		return
+	}
+	if v, ok := expr.(*sqlparser.SQLVal); ok && v.Type == sqlparser.StrVal {
+		s.addRevision(string(v.Val), clause)
+		return
+	}
+	s.addRevision("", clause)
+}
+
+func mysqlStatementKind(stmt sqlparser.Statement) string {
+	switch n := stmt.(type) {
+	case sqlparser.SelectStatement:
+		return "SELECT"
+	case *sqlparser.Insert:
+		return n.Action
+	case *sqlparser.Update:
+		return "UPDATE"
+	case *sqlparser.Delete:
+		return "DELETE"
+	case *sqlparser.DDL:
+		return n.Action + " " + mysqlDDLObject(n)
+	case *sqlparser.AlterTable:
+		return "ALTER TABLE"
+	case *sqlparser.DBDDL:
+		return n.Action + " DATABASE"
+	case *sqlparser.Load:
+		return "LOAD DATA"
+	case *sqlparser.Call:
+		return "CALL " + n.ProcName.Name.String()
+	case *sqlparser.GrantPrivilege, *sqlparser.GrantRole, *sqlparser.GrantProxy:
+		return "GRANT"
+	case *sqlparser.RevokePrivilege, *sqlparser.RevokeRole, *sqlparser.RevokeProxy:
+		return "REVOKE"
+	case *sqlparser.CreateUser:
+		return "CREATE USER"
+	case *sqlparser.RenameUser:
+		return "RENAME USER"
+	case *sqlparser.DropUser:
+		return "DROP USER"
+	case *sqlparser.CreateRole:
+		return "CREATE ROLE"
+	case *sqlparser.DropRole:
+		return "DROP ROLE"
+	case *sqlparser.LockTables:
+		return "LOCK TABLES"
+	}
+	return ""
+}
+
+func mysqlDDLObject(ddl *sqlparser.DDL) string {
+	switch {
+	case ddl.ViewSpec != nil || len(ddl.FromViews) > 0:
+		return "VIEW"
+	case ddl.TriggerSpec != nil:
+		return "TRIGGER"
+	case ddl.ProcedureSpec != nil:
+		return "PROCEDURE"
+	case ddl.EventSpec != nil:
+		return "EVENT"
+	}
+	return "TABLE"
+}
+
+func (d *MySQLDialect) ReferencedTables(query string) ([]string, error) {
+	stmts, err := d.policyStatements(query)
+	if err != nil {
+		return nil, err
+	}
+	return referencedTableNames(stmts...)
+}
+
+func (d *MySQLDialect) DerivedColumns(query string) ([]string, error) {
+	stmts, err := d.policyStatements(query)
+	if err != nil {
+		return nil, err
+	}
+	return derivedColumnNames(stmts...), nil
+}
+
+func (d *MySQLDialect) StatementTargets(query string) (StatementTargets, error) {
+	stmts, err := d.policyStatements(query)
+	if err != nil {
+		return StatementTargets{}, err
+	}
+	return newStatementTargets(stmts...), nil
+}
+
+func (d *MySQLDialect) StatementKinds(query string) ([]string, error) {
+	stmts, err := d.policyStatements(query)
+	if err != nil {
+		return nil, err
+	}
+	return statementKinds(stmts...), nil
+}
+
+// mysqlTableFunction records the table function n with the string literals
+// among its arguments.
+func mysqlTableFunction(s *policyStatement, n *sqlparser.TableFuncExpr) {
+	arguments, literals := mysqlSelectArguments(n.Exprs)
+	s.addTableFunction(n.Name, arguments, literals, sqlparser.String(n))
+}
+
+// mysqlArguments returns the arguments of a call, with "" for an argument
+// that is not a string literal, and whether every argument is one.
+func mysqlArguments(exprs []sqlparser.Expr) ([]string, bool) {
+	arguments := make([]string, len(exprs))
+	literals := true
+	for i, expr := range exprs {
+		if v, ok := expr.(*sqlparser.SQLVal); ok && v.Type == sqlparser.StrVal {
+			arguments[i] = string(v.Val)
+			continue
+		}
+		literals = false
+	}
+	return arguments, literals
+}
+
+// mysqlSelectArguments is mysqlArguments for the arguments of a function.
+func mysqlSelectArguments(exprs sqlparser.SelectExprs) ([]string, bool) {
+	args := make([]sqlparser.Expr, len(exprs))
+	for i, expr := range exprs {
+		if aliased, ok := expr.(*sqlparser.AliasedExpr); ok {
+			args[i] = aliased.Expr
+		}
+	}
+	return mysqlArguments(args)
+}
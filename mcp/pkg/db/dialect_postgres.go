@@ -2,11 +2,16 @@ package db
 
 import (
 	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 	pganalyze "github.com/pganalyze/pg_query_go/v6"
 	pg_query "github.com/wasilibs/go-pgquery"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
 )
 
 // PostgresDialect implements Dialect for PostgreSQL-compatible DoltgreSQL servers.
@@ -42,11 +47,23 @@ func (d *PostgresDialect) FormatDSN(c Config) string {
 	}
 
 	dsn := fmt.Sprintf("postgres://%s:%s@%s:%d", c.User, c.Password, c.Host, c.Port)
+	if c.Socket != "" {
+		dsn = fmt.Sprintf("postgres://%s:%s@", c.User, c.Password)
+	}
 	if c.DatabaseName != "" {
 		dsn += "/" + c.DatabaseName
 	}
 
 	options := []string{}
+	if c.Socket != "" {
+		// libpq connects to a unix socket when the host is a directory, in
+		// which the socket is named after the port.
+		dir, port := postgresSocket(c.Socket, c.Port)
+		options = append(options, "host="+url.QueryEscape(dir))
+		if port != 0 {
+			options = append(options, fmt.Sprintf("port=%d", port))
+		}
+	}
 	sslMode := d.mapTLSToSSLMode(c.TLS, c.TLSCAFile)
 	if sslMode != "" {
 		options = append(options, fmt.Sprintf("sslmode=%s", sslMode))
@@ -67,6 +84,18 @@ func (d *PostgresDialect) FormatDSN(c Config) string {
 	return dsn
 }
 
+// postgresSocket returns the directory and port of the unix socket at
+// socket, which is either the directory or the .s.PGSQL.<port> socket in it.
+func postgresSocket(socket string, port int) (string, int) {
+	name := filepath.Base(socket)
+	if suffix, ok := strings.CutPrefix(name, ".s.PGSQL."); ok {
+		if socketPort, err := strconv.Atoi(suffix); err == nil {
+			return filepath.Dir(socket), socketPort
+		}
+	}
+	return socket, port
+}
+
 func (d *PostgresDialect) mapTLSToSSLMode(tls, tlsCAFile string) string {
 	switch tls {
 	case "true":
@@ -103,10 +132,18 @@ func (d *PostgresDialect) CallProcedure(proc DoltProcedure, args ...string) stri
 	return fmt.Sprintf("SELECT %s(%s);", pgName, strings.Join(quotedArgs, ", "))
 }
 
+func (d *PostgresDialect) CommitAuthor(name, email string) ([]string, []string) {
+	return nil, []string{"--author", fmt.Sprintf("%s <%s>", name, email)}
+}
+
 func (d *PostgresDialect) UseDatabase(database string) string {
 	return fmt.Sprintf("USE %s;", d.QuoteIdentifier(database))
 }
 
+func (d *PostgresDialect) CurrentDatabaseQuery() string {
+	return "SELECT current_database();"
+}
+
 func (d *PostgresDialect) ShowTablesQuery() string {
 	return "SHOW TABLES;"
 }
@@ -134,56 +171,511 @@ func (d *PostgresDialect) parseSQLQuery(query string) (*pganalyze.ParseResult, e
 	return pg_query.Parse(query)
 }
 
-func (d *PostgresDialect) isReadOnlyStatement(result *pganalyze.ParseResult) bool {
+// parseSingleStatement parses query and returns its statement, or nil when
+// the query is empty. Queries with more than one statement are rejected.
+func (d *PostgresDialect) parseSingleStatement(query string) (*pganalyze.Node, error) {
+	result, err := d.parseSQLQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Stmts) > 1 {
+		return nil, ErrMultipleSQLStatements
+	}
 	if len(result.Stmts) == 0 {
+		return nil, nil
+	}
+	return result.Stmts[0].Stmt, nil
+}
+
+func (d *PostgresDialect) isReadOnlyStatement(node *pganalyze.Node) bool {
+	if node == nil {
 		return false
 	}
-	node := result.Stmts[0].Stmt
 	return node.GetSelectStmt() != nil ||
 		node.GetVariableShowStmt() != nil ||
 		node.GetExplainStmt() != nil
 }
 
 func (d *PostgresDialect) ValidateReadQuery(query string) error {
-	result, err := d.parseSQLQuery(query)
+	node, err := d.parseSingleStatement(query)
 	if err != nil {
 		return err
 	}
-	if d.isReadOnlyStatement(result) {
+	if d.isReadOnlyStatement(node) {
 		return nil
 	}
 	return ErrInvalidSQLReadQuery
 }
 
 func (d *PostgresDialect) ValidateWriteQuery(query string) error {
-	result, err := d.parseSQLQuery(query)
+	node, err := d.parseSingleStatement(query)
 	if err != nil {
 		return err
 	}
-	if d.isReadOnlyStatement(result) {
+	if d.isReadOnlyStatement(node) {
 		return ErrInvalidSQLWriteQuery
 	}
 	return nil
 }
 
 func (d *PostgresDialect) ValidateCreateTableQuery(query string) error {
-	result, err := d.parseSQLQuery(query)
+	node, err := d.parseSingleStatement(query)
 	if err != nil {
 		return err
 	}
-	if len(result.Stmts) > 0 && result.Stmts[0].Stmt.GetCreateStmt() != nil {
+	if node.GetCreateStmt() != nil {
 		return nil
 	}
 	return ErrInvalidCreateTableSQLQuery
 }
 
 func (d *PostgresDialect) ValidateAlterTableQuery(query string) error {
-	result, err := d.parseSQLQuery(query)
+	node, err := d.parseSingleStatement(query)
 	if err != nil {
 		return err
 	}
-	if len(result.Stmts) > 0 && result.Stmts[0].Stmt.GetAlterTableStmt() != nil {
+	if node.GetAlterTableStmt() != nil {
 		return nil
 	}
 	return ErrInvalidAlterTableSQLQuery
 }
+
+// SQL policy checks using the PostgreSQL parse tree.
+
+func (d *PostgresDialect) ValidateQueryPolicy(query, database string, policy *SQLPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	stmts, err := d.policyStatements(query)
+	if err != nil {
+		return err
+	}
+	return policy.checkAll(stmts, database)
+}
+
+// policyStatements returns what each statement of query touches.
+func (d *PostgresDialect) policyStatements(query string) ([]policyStatement, error) {
+	result, err := d.parseSQLQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	policyStmts := make([]policyStatement, len(result.Stmts))
+	for i, raw := range result.Stmts {
+		policyStmts[i] = postgresPolicyStatement(query, raw)
+	}
+	return policyStmts, nil
+}
+
+func postgresPolicyStatement(query string, raw *pganalyze.RawStmt) policyStatement {
+	var s policyStatement
+	text := postgresStatementText(query, raw)
+	kind := postgresStatementKind(raw.Stmt)
+	if kind == "" {
+		kind = leadingKeyword(text)
+	}
+	s.addKind(kind, text)
+	if copyStmt := raw.Stmt.GetCopyStmt(); copyStmt != nil && copyStmt.IsFrom {
+		s.addKind("LOAD DATA", text)
+	}
+
+	// Collect table aliases and common table expression names first, so
+	// that qualified columns resolve to their tables and references to
+	// CTEs are not taken for tables.
+	aliases := map[string]string{}
+	ctes := map[string]bool{}
+	walkPostgresTree(raw.ProtoReflect(), func(m proto.Message) bool {
+		switch n := m.(type) {
+		case *pganalyze.CommonTableExpr:
+			ctes[strings.ToLower(n.Ctename)] = true
+		case *pganalyze.RangeVar:
+			if n.Alias != nil && n.Alias.Aliasname != "" {
+				aliases[strings.ToLower(n.Alias.Aliasname)] = n.Relname
+			}
+		}
+		return true
+	})
+	resolve := func(table string) string {
+		if resolved, ok := aliases[strings.ToLower(table)]; ok {
+			return resolved
+		}
+		return table
+	}
+
+	walkPostgresTree(raw.ProtoReflect(), func(m proto.Message) bool {
+		switch n := m.(type) {
+		case *pganalyze.RangeVar:
+			if n.Catalogname == "" && n.Schemaname == "" && ctes[strings.ToLower(n.Relname)] {
+				return false
+			}
+			s.addTable(n.Catalogname, n.Relname, joinPostgresName(n.Catalogname, n.Schemaname, n.Relname))
+			return false
+		case *pganalyze.RangeFunction:
+			postgresTableFunctions(&s, n, text)
+		case *pganalyze.ColumnRef:
+			postgresColumnRef(&s, n, resolve)
+			return false
+		case *pganalyze.FuncCall:
+			if procedure, ok := doltProcedureName(postgresFuncName(n)); ok {
+				s.addKind("CALL "+procedure, strings.ToLower(procedure)+"(...)")
+				arguments, literals := postgresArguments(n)
+				s.addProcedure(procedure, arguments, literals, strings.ToLower(procedure)+"(...)")
+			}
+		case *pganalyze.InsertStmt:
+			for _, col := range n.Cols {
+				if target := col.GetResTarget(); target != nil && n.Relation != nil {
+					s.addColumn(n.Relation.Relname, target.Name, target.Name)
+				}
+			}
+		case *pganalyze.UpdateStmt:
+			for _, col := range n.TargetList {
+				if target := col.GetResTarget(); target != nil && n.Relation != nil {
+					s.addColumn(n.Relation.Relname, target.Name, target.Name)
+				}
+			}
+		case *pganalyze.CopyStmt:
+			for _, col := range n.Attlist {
+				if name := col.GetString_(); name != nil && n.Relation != nil {
+					s.addColumn(n.Relation.Relname, name.Sval, name.Sval)
+				}
+			}
+		case *pganalyze.CreatedbStmt:
+			s.addDatabase(n.Dbname, text)
+		case *pganalyze.DropdbStmt:
+			s.addDatabase(n.Dbname, text)
+		}
+		return true
+	})
+
+	postgresDerivedColumns(&s, raw, aliases, resolve)
+	return s
+}
+
+// walkPostgresTree calls visit for m and every message below it, skipping
+// the children of messages for which visit returns false.
+func walkPostgresTree(m protoreflect.Message, visit func(proto.Message) bool) {
+	if !m.IsValid() || !visit(m.Interface()) {
+		return
+	}
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case fd.Message() == nil || fd.IsMap():
+		case fd.IsList():
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				walkPostgresTree(list.Get(i).Message(), visit)
+			}
+		default:
+			walkPostgresTree(v.Message(), visit)
+		}
+		return true
+	})
+}
+
+// postgresDerivedColumns records every column of raw that may reach a result
+// under another name, since result columns are masked by name. A reference
+// to a whole row, as in SELECT p FROM people p, stands for all of its
+// columns.
+func postgresDerivedColumns(s *policyStatement, raw *pganalyze.RawStmt, aliases map[string]string, resolve func(string) string) {
+	relations := map[string]bool{}
+	for alias, table := range aliases {
+		relations[alias] = true
+		relations[strings.ToLower(table)] = true
+	}
+	walkPostgresTree(raw.ProtoReflect(), func(m proto.Message) bool {
+		if n, ok := m.(*pganalyze.RangeVar); ok {
+			relations[strings.ToLower(n.Relname)] = true
+		}
+		return true
+	})
+	derive := func(m proto.Message) {
+		if m == nil {
+			return
+		}
+		walkPostgresTree(m.ProtoReflect(), func(m proto.Message) bool {
+			switch n := m.(type) {
+			case *pganalyze.ColumnRef:
+				var names []string
+				for _, field := range n.Fields {
+					if field.GetString_() != nil {
+						names = append(names, field.GetString_().Sval)
+					} else if field.GetAStar() != nil {
+						names = append(names, "*")
+					}
+				}
+				if len(names) == 0 {
+					break
+				}
+				column := names[len(names)-1]
+				table := ""
+				if len(names) > 1 {
+					table = resolve(names[len(names)-2])
+				} else if relations[strings.ToLower(column)] {
+					table, column = resolve(column), "*"
+				}
+				s.addDerived(table, column, joinPostgresName(names...))
+			}
+			return true
+		})
+	}
+	// targets records the columns of a select or RETURNING list that are not
+	// selected on their own under their own names.
+	targets := func(list []*pganalyze.Node, into bool) {
+		for _, node := range list {
+			target := node.GetResTarget()
+			if target == nil {
+				continue
+			}
+			if ref := target.Val.GetColumnRef(); ref != nil && len(ref.Fields) > 0 && !into {
+				last := ref.Fields[len(ref.Fields)-1]
+				if last.GetAStar() != nil {
+					continue
+				}
+				name := last.GetString_().GetSval()
+				if (len(ref.Fields) > 1 || !relations[strings.ToLower(name)]) && (target.Name == "" || strings.EqualFold(target.Name, name)) {
+					continue
+				}
+			}
+			derive(target.Val)
+		}
+	}
+	walkPostgresTree(raw.ProtoReflect(), func(m proto.Message) bool {
+		switch n := m.(type) {
+		case *pganalyze.SelectStmt:
+			targets(n.TargetList, n.IntoClause != nil)
+			// The columns of a set operation are named after its first
+			// select.
+			if n.Rarg != nil {
+				derive(n.Rarg)
+			}
+		case *pganalyze.InsertStmt:
+			// The rows a statement writes keep values for later statements,
+			// out of reach of masking.
+			if n.SelectStmt != nil {
+				derive(n.SelectStmt)
+			}
+			targets(n.ReturningList, false)
+		case *pganalyze.UpdateStmt:
+			for _, node := range n.TargetList {
+				if target := node.GetResTarget(); target != nil {
+					derive(target.Val)
+				}
+			}
+			targets(n.ReturningList, false)
+		case *pganalyze.CreateTableAsStmt:
+			if n.Query != nil {
+				derive(n.Query)
+			}
+		case *pganalyze.DeleteStmt:
+			targets(n.ReturningList, false)
+		case *pganalyze.RangeSubselect:
+			if n.Alias != nil && len(n.Alias.Colnames) > 0 {
+				derive(n.Subquery)
+			}
+		case *pganalyze.RangeVar:
+			if n.Alias != nil && len(n.Alias.Colnames) > 0 {
+				s.addDerived(n.Relname, "*", n.Relname)
+			}
+		case *pganalyze.CommonTableExpr:
+			if len(n.Aliascolnames) > 0 {
+				derive(n.Ctequery)
+			}
+		}
+		return true
+	})
+}
+
+func postgresColumnRef(s *policyStatement, ref *pganalyze.ColumnRef, resolve func(string) string) {
+	var names []string
+	star := false
+	for _, field := range ref.Fields {
+		switch {
+		case field.GetString_() != nil:
+			names = append(names, field.GetString_().Sval)
+		case field.GetAStar() != nil:
+			star = true
+		}
+	}
+
+	clause := joinPostgresName(names...)
+	if star {
+		clause = joinPostgresName(append(names, "*")...)
+	}
+	// database.schema.table.column is the only form naming a database.
+	if len(names) == 4 || (star && len(names) == 3) {
+		s.addDatabase(names[0], clause)
+	}
+
+	table := ""
+	if star {
+		if len(names) > 0 {
+			table = resolve(names[len(names)-1])
+		}
+		s.addStar(table, clause)
+		return
+	}
+	if len(names) == 0 {
+		return
+	}
+	if len(names) > 1 {
+		table = resolve(names[len(names)-2])
+	}
+	s.addColumn(table, names[len(names)-1], clause)
+}
+
+func postgresStatementText(query string, raw *pganalyze.RawStmt) string {
+	start := int(raw.StmtLocation)
+	end := len(query)
+	if raw.StmtLen > 0 && start+int(raw.StmtLen) <= len(query) {
+		end = start + int(raw.StmtLen)
+	}
+	if start < 0 || start > end {
+		return query
+	}
+	return strings.TrimSpace(query[start:end])
+}
+
+func postgresFuncName(call *pganalyze.FuncCall) string {
+	if call == nil || len(call.Funcname) == 0 {
+		return ""
+	}
+	if name := call.Funcname[len(call.Funcname)-1].GetString_(); name != nil {
+		return name.Sval
+	}
+	return ""
+}
+
+// postgresTableFunctions records the functions of n with the string literals
+// among their arguments. A function that is not a plain call, such as
+// ROWS FROM of a cast, is recorded with no name.
+func postgresTableFunctions(s *policyStatement, n *pganalyze.RangeFunction, clause string) {
+	for _, function := range n.Functions {
+		var call *pganalyze.FuncCall
+		if items := function.GetList().GetItems(); len(items) > 0 {
+			call = items[0].GetFuncCall()
+		}
+		if call == nil {
+			s.addTableFunction("", nil, false, clause)
+			continue
+		}
+		arguments, literals := postgresArguments(call)
+		s.addTableFunction(postgresFuncName(call), arguments, literals, clause)
+	}
+}
+
+// postgresArguments returns the arguments of call, with "" for an argument
+// that is not a string literal, and whether every argument is one.
+func postgresArguments(call *pganalyze.FuncCall) ([]string, bool) {
+	arguments := make([]string, len(call.Args))
+	literals := true
+	for i, arg := range call.Args {
+		if value := arg.GetAConst().GetSval(); value != nil {
+			arguments[i] = value.Sval
+			continue
+		}
+		literals = false
+	}
+	return arguments, literals
+}
+
+func joinPostgresName(parts ...string) string {
+	var nonEmpty []string
+	for _, part := range parts {
+		if part != "" {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	return strings.Join(nonEmpty, ".")
+}
+
+func postgresObjectName(objectType pganalyze.ObjectType) string {
+	return strings.ReplaceAll(strings.TrimPrefix(objectType.String(), "OBJECT_"), "_", " ")
+}
+
+func postgresStatementKind(node *pganalyze.Node) string {
+	switch n := node.GetNode().(type) {
+	case *pganalyze.Node_SelectStmt:
+		return "SELECT"
+	case *pganalyze.Node_InsertStmt:
+		return "INSERT"
+	case *pganalyze.Node_UpdateStmt:
+		return "UPDATE"
+	case *pganalyze.Node_DeleteStmt:
+		return "DELETE"
+	case *pganalyze.Node_MergeStmt:
+		return "MERGE"
+	case *pganalyze.Node_CreateStmt, *pganalyze.Node_CreateTableAsStmt:
+		return "CREATE TABLE"
+	case *pganalyze.Node_ViewStmt:
+		return "CREATE VIEW"
+	case *pganalyze.Node_IndexStmt:
+		return "CREATE INDEX"
+	case *pganalyze.Node_AlterTableStmt:
+		return "ALTER TABLE"
+	case *pganalyze.Node_RenameStmt:
+		return "ALTER " + postgresObjectName(n.RenameStmt.RenameType)
+	case *pganalyze.Node_DropStmt:
+		return "DROP " + postgresObjectName(n.DropStmt.RemoveType)
+	case *pganalyze.Node_TruncateStmt:
+		return "TRUNCATE TABLE"
+	case *pganalyze.Node_CreatedbStmt:
+		return "CREATE DATABASE"
+	case *pganalyze.Node_DropdbStmt:
+		return "DROP DATABASE"
+	case *pganalyze.Node_AlterDatabaseStmt:
+		return "ALTER DATABASE"
+	case *pganalyze.Node_GrantStmt:
+		if n.GrantStmt.IsGrant {
+			return "GRANT"
+		}
+		return "REVOKE"
+	case *pganalyze.Node_GrantRoleStmt:
+		if n.GrantRoleStmt.IsGrant {
+			return "GRANT"
+		}
+		return "REVOKE"
+	case *pganalyze.Node_CreateRoleStmt:
+		if n.CreateRoleStmt.StmtType == pganalyze.RoleStmtType_ROLESTMT_USER {
+			return "CREATE USER"
+		}
+		return "CREATE ROLE"
+	case *pganalyze.Node_DropRoleStmt:
+		return "DROP ROLE"
+	case *pganalyze.Node_CopyStmt:
+		return "COPY"
+	case *pganalyze.Node_CallStmt:
+		return "CALL " + postgresFuncName(n.CallStmt.Funccall)
+	}
+	return ""
+}
+
+func (d *PostgresDialect) ReferencedTables(query string) ([]string, error) {
+	stmts, err := d.policyStatements(query)
+	if err != nil {
+		return nil, err
+	}
+	return referencedTableNames(stmts...)
+}
+
+func (d *PostgresDialect) DerivedColumns(query string) ([]string, error) {
+	stmts, err := d.policyStatements(query)
+	if err != nil {
+		return nil, err
+	}
+	return derivedColumnNames(stmts...), nil
+}
+
+func (d *PostgresDialect) StatementTargets(query string) (StatementTargets, error) {
+	stmts, err := d.policyStatements(query)
+	if err != nil {
+		return StatementTargets{}, err
+	}
+	return newStatementTargets(stmts...), nil
+}
+
+func (d *PostgresDialect) StatementKinds(query string) ([]string, error) {
+	stmts, err := d.policyStatements(query)
+	if err != nil {
+		return nil, err
+	}
+	return statementKinds(stmts...), nil
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrEndpointWithDSN = errors.New("read replicas cannot be used with a DSN")
+
+// Endpoint is a server a transaction connects to in place of the configured
+// one, such as a read replica. It is reached with the same user and TLS
+// settings.
+type Endpoint struct {
+	Host string
+	Port int
+}
+
+func (e Endpoint) String() string {
+	return fmt.Sprintf("%s:%d", e.Host, e.Port)
+}
+
+type endpointKey struct{}
+
+// ContextWithEndpoint returns a copy of ctx whose transactions connect to
+// endpoint.
+func ContextWithEndpoint(ctx context.Context, endpoint Endpoint) context.Context {
+	return context.WithValue(ctx, endpointKey{}, endpoint)
+}
+
+// EndpointFromContext returns the endpoint set on ctx by
+// ContextWithEndpoint.
+func EndpointFromContext(ctx context.Context) (Endpoint, bool) {
+	endpoint, ok := ctx.Value(endpointKey{}).(Endpoint)
+	return endpoint, ok
+}
+
+// WithEndpoint returns a copy of c that connects to endpoint, in place of
+// the one its Primary resolver picks.
+func (c Config) WithEndpoint(endpoint Endpoint) (Config, error) {
+	if c.DSN != "" {
+		return c, ErrEndpointWithDSN
+	}
+	c.Host = endpoint.Host
+	c.Port = endpoint.Port
+	c.Socket = ""
+	c.Primary = nil
+	return c, nil
+}
+
+// withContextEndpoint returns a copy of c that connects to the endpoint on
+// ctx, if any.
+func (c Config) withContextEndpoint(ctx context.Context) (Config, error) {
+	endpoint, ok := EndpointFromContext(ctx)
+	if !ok {
+		return c, nil
+	}
+	return c.WithEndpoint(endpoint)
+}
+
+// ReplicationLag returns how far the standby named standbyRemote is behind
+// the Dolt cluster primary config connects to, across every replicated
+// database. It reads the dolt_cluster_status table, which reports no lag
+// for a standby that has not caught up yet, so that is an error too.
+func ReplicationLag(ctx context.Context, config Config, standbyRemote string) (time.Duration, error) {
+	db, err := newDB(config)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, "SELECT `database`, replication_lag_millis FROM dolt_cluster_status WHERE standby_remote = ?", standbyRemote)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read dolt_cluster_status: %w", err)
+	}
+	defer rows.Close()
+
+	var lag time.Duration
+	found := false
+	for rows.Next() {
+		var database string
+		var millis sql.NullInt64
+		if err := rows.Scan(&database, &millis); err != nil {
+			return 0, err
+		}
+		if !millis.Valid {
+			return 0, fmt.Errorf("standby %s has no replication lag for database %s", standbyRemote, database)
+		}
+		found = true
+		lag = max(lag, time.Duration(millis.Int64)*time.Millisecond)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	if !found {
+		return 0, fmt.Errorf("standby %s is not in dolt_cluster_status", standbyRemote)
+	}
+	return lag, nil
+}
@@ -0,0 +1,42 @@
+package db
+
+import "strings"
+
+// implicitCommitKinds are the statement kinds that end the transaction they
+// run in, or that change refs outside of it, so that a rollback does not
+// undo them. A kind also covers the kinds it is a word prefix of.
+var implicitCommitKinds = []string{
+	"CREATE",
+	"ALTER",
+	"DROP",
+	"RENAME",
+	"TRUNCATE",
+	"GRANT",
+	"REVOKE",
+	"LOCK TABLES",
+	"CALL DOLT_",
+}
+
+// CommitsImplicitly reports whether a statement of kind, as returned by
+// Dialect.StatementKinds, takes effect even when its transaction is rolled
+// back. MySQL and Dolt commit schema and account changes implicitly, and
+// Dolt procedures such as DOLT_COMMIT and DOLT_RESET move branch heads.
+func CommitsImplicitly(kind string) bool {
+	kind = normalizeStatementKind(kind)
+	for _, k := range implicitCommitKinds {
+		if kind == k || strings.HasPrefix(kind, k+" ") || (strings.HasSuffix(k, "_") && strings.HasPrefix(kind, k)) {
+			return true
+		}
+	}
+	return false
+}
+
+func statementKinds(stmts ...policyStatement) []string {
+	var kinds []string
+	for _, stmt := range stmts {
+		for _, kind := range stmt.kinds {
+			kinds = append(kinds, kind.name)
+		}
+	}
+	return kinds
+}
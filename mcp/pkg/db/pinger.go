@@ -0,0 +1,75 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+)
+
+var ErrDatabaseNotPrepared = errors.New("database has not been prepared")
+
+// Pinger checks that the database can be reached. It keeps a connection
+// open between pings, so that frequent checks, such as readiness probes, do
+// not connect every time.
+type Pinger struct {
+	config Config
+
+	mu sync.Mutex
+	db *sql.DB
+	// endpoint is the server db connects to, which changes when the
+	// config's Primary resolver picks another one.
+	endpoint Endpoint
+}
+
+// NewPinger returns a pinger connecting with config, which must have been
+// passed to PrepareDatabase.
+func NewPinger(config Config) *Pinger {
+	return &Pinger{config: config}
+}
+
+// Ping returns an error when the database cannot be reached.
+func (p *Pinger) Ping(ctx context.Context) error {
+	if p.config.DialectType == DialectDoltLite {
+		if p.config.doltLiteDatabase == nil {
+			return ErrDatabaseNotPrepared
+		}
+		return p.config.doltLiteDatabase.db.PingContext(ctx)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	config := p.config.withPrimary()
+	endpoint := Endpoint{Host: config.Host, Port: config.Port}
+	if p.db != nil && p.endpoint != endpoint {
+		openPools.Delete(p.db)
+		p.db.Close()
+		p.db = nil
+	}
+	if p.db == nil {
+		db, err := newDB(config)
+		if err != nil {
+			return err
+		}
+		db.SetMaxOpenConns(1)
+		db.SetMaxIdleConns(1)
+		p.db = db
+		p.endpoint = endpoint
+		openPools.Store(db, sharedPool)
+		return nil
+	}
+	return p.db.PingContext(ctx)
+}
+
+// Close closes the pinger's connection.
+func (p *Pinger) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.db == nil {
+		return nil
+	}
+	openPools.Delete(p.db)
+	err := p.db.Close()
+	p.db = nil
+	return err
+}
@@ -0,0 +1,552 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+var ErrSQLPolicyViolation = errors.New("sql policy violation")
+
+// SQLPolicyViolationError reports the rule a statement broke and the clause
+// of the statement that broke it.
+type SQLPolicyViolationError struct {
+	Rule   string
+	Clause string
+}
+
+func (e *SQLPolicyViolationError) Error() string {
+	return fmt.Sprintf("%s: %s: %s", ErrSQLPolicyViolation, e.Rule, e.Clause)
+}
+
+func (e *SQLPolicyViolationError) Unwrap() error {
+	return ErrSQLPolicyViolation
+}
+
+// SQLPolicy restricts the statements clients may run. Table and column
+// patterns are case-insensitive globs in the syntax of path.Match. Dolt
+// system tables carrying the rows of a table, such as dolt_diff_people, are
+// subject to the rules of that table, and so are Dolt table functions, such
+// as dolt_diff(), reading the table their arguments name. A table function
+// whose arguments do not name its table breaks any table or column rule.
+// Tools that take a table argument, such
+// as describe_table, check it with CheckTable. Tools listing table names
+// only, such as show_tables and the dolt_diff summaries, are not restricted.
+type SQLPolicy struct {
+	// DeniedStatements lists statement kinds that may not run, such as
+	// "DROP DATABASE", "GRANT", "LOAD DATA" or "CALL DOLT_PUSH". A kind also
+	// denies the kinds it is a word prefix of, so "DROP" denies "DROP TABLE".
+	// Dolt procedures are denied the same way whether they are invoked with
+	// CALL or as functions. PREPARE, EXECUTE, and DEALLOCATE are denied
+	// whenever the policy has any rule.
+	DeniedStatements []string `yaml:"denied_statements" json:"denied_statements"`
+	// AllowedTables, when set, lists the only tables statements may
+	// reference. A pattern containing a dot is matched against
+	// "database.table".
+	AllowedTables []string `yaml:"allowed_tables" json:"allowed_tables"`
+	// DeniedTables lists tables statements may not reference.
+	DeniedTables []string `yaml:"denied_tables" json:"denied_tables"`
+	// DeniedColumns lists columns statements may not reference, as
+	// "table.column" or "column". Selecting * from a table that may contain
+	// a denied column is also a violation.
+	DeniedColumns []string `yaml:"denied_columns" json:"denied_columns"`
+	// BlockCrossDatabase rejects statements that reference a database other
+	// than the working database. Revisions of the working database, such as
+	// "mydb/feature", are not another database.
+	BlockCrossDatabase bool `yaml:"block_cross_database" json:"block_cross_database"`
+
+	// deniedBranches are branches, in any database, that client SQL may not
+	// name in Dolt procedures, Dolt table functions, or revision databases,
+	// and that tools may not change, as checked with CheckBranch.
+	deniedBranches []string
+}
+
+// preparedStatementKinds are the statements that prepare, run, or release a
+// statement the policy cannot check, as in PREPARE s FROM @query. A policy
+// with any rule denies them.
+var preparedStatementKinds = map[string]bool{
+	"PREPARE":    true,
+	"EXECUTE":    true,
+	"DEALLOCATE": true,
+}
+
+// restricts reports whether the policy has any rule.
+func (p *SQLPolicy) restricts() bool {
+	return len(p.DeniedStatements) > 0 || len(p.AllowedTables) > 0 || len(p.DeniedTables) > 0 ||
+		len(p.DeniedColumns) > 0 || p.BlockCrossDatabase || len(p.deniedBranches) > 0
+}
+
+// Validate checks that every pattern in the policy is well formed.
+func (p *SQLPolicy) Validate() error {
+	for _, patterns := range [][]string{p.AllowedTables, p.DeniedTables, p.DeniedColumns} {
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid sql policy pattern %q: %w", pattern, err)
+			}
+		}
+	}
+	return nil
+}
+
+// WithDeniedTables returns a copy of the policy that also denies the tables
+// matching patterns. A nil policy denies nothing else.
+func (p *SQLPolicy) WithDeniedTables(patterns ...string) *SQLPolicy {
+	denied := &SQLPolicy{}
+	if p != nil {
+		*denied = *p
+	}
+	denied.DeniedTables = append(append([]string(nil), denied.DeniedTables...), patterns...)
+	return denied
+}
+
+// WithDeniedBranches returns a copy of the policy that also denies branches.
+// A nil policy denies nothing else.
+func (p *SQLPolicy) WithDeniedBranches(branches ...string) *SQLPolicy {
+	denied := &SQLPolicy{}
+	if p != nil {
+		*denied = *p
+	}
+	denied.deniedBranches = append(append([]string(nil), denied.deniedBranches...), branches...)
+	return denied
+}
+
+// policyStatement is what a single statement touches, extracted from a
+// dialect's parse tree so that the policy can be checked the same way for
+// every dialect.
+type policyStatement struct {
+	kinds          []policyReference
+	databases      []policyReference
+	tables         []policyTable
+	columns        []policyColumn
+	stars          []policyColumn
+	tableFunctions []policyCall
+	// procedures are the Dolt procedures the statement calls, with CALL or
+	// as functions.
+	procedures []policyCall
+	// derived are the columns the statement may return under a name other
+	// than their own, with "*" standing for every column of a table.
+	derived []policyColumn
+	// revisions are the revisions read with AS OF, "" for one that is not a
+	// string literal.
+	revisions []policyReference
+}
+
+// policyReference is a named element of a statement and the clause it
+// appeared in.
+type policyReference struct {
+	name   string
+	clause string
+}
+
+// policyCall is a call of a table function or Dolt procedure. literals is
+// false when an argument is not a string literal, and arguments then holds
+// "" in its place.
+type policyCall struct {
+	name      string
+	arguments []string
+	literals  bool
+	clause    string
+}
+
+type policyTable struct {
+	database string
+	name     string
+	clause   string
+}
+
+// policyColumn is a column or * reference. table is the referenced table
+// with aliases resolved, or "" when the reference is unqualified.
+type policyColumn struct {
+	table  string
+	name   string
+	clause string
+}
+
+func (s *policyStatement) addKind(kind, clause string) {
+	s.kinds = append(s.kinds, policyReference{name: normalizeStatementKind(kind), clause: clause})
+}
+
+func (s *policyStatement) addDatabase(database, clause string) {
+	if database != "" {
+		s.databases = append(s.databases, policyReference{name: database, clause: clause})
+	}
+}
+
+func (s *policyStatement) addTable(database, table, clause string) {
+	if table == "" {
+		return
+	}
+	s.addDatabase(database, clause)
+	s.tables = append(s.tables, policyTable{database: database, name: table, clause: clause})
+}
+
+// addTableFunction records a table function the statement reads from, such
+// as dolt_diff(), whose tables are named by its arguments.
+func (s *policyStatement) addTableFunction(function string, arguments []string, literals bool, clause string) {
+	s.tableFunctions = append(s.tableFunctions, policyCall{name: function, arguments: arguments, literals: literals, clause: clause})
+}
+
+// addProcedure records a call of a Dolt procedure, whose branches are named
+// by its arguments. Its kind is recorded apart.
+func (s *policyStatement) addProcedure(procedure string, arguments []string, literals bool, clause string) {
+	s.procedures = append(s.procedures, policyCall{name: procedure, arguments: arguments, literals: literals, clause: clause})
+}
+
+func (s *policyStatement) addColumn(table, column, clause string) {
+	s.columns = append(s.columns, policyColumn{table: table, name: column, clause: clause})
+}
+
+func (s *policyStatement) addStar(table, clause string) {
+	s.stars = append(s.stars, policyColumn{table: table, name: "*", clause: clause})
+}
+
+// addDerived records a column read into a result column that is not named
+// after it, such as through an alias, an expression or a subquery.
+func (s *policyStatement) addDerived(table, column, clause string) {
+	s.derived = append(s.derived, policyColumn{table: table, name: column, clause: clause})
+}
+
+func (s *policyStatement) addRevision(revision, clause string) {
+	s.revisions = append(s.revisions, policyReference{name: revision, clause: clause})
+}
+
+// doltProcedureName returns the name of the Dolt procedure a function call
+// invokes, for dialects and queries that call procedures as functions.
+func doltProcedureName(function string) (string, bool) {
+	name := strings.ToUpper(function)
+	return name, strings.HasPrefix(name, "DOLT_")
+}
+
+func normalizeStatementKind(kind string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(kind), " "))
+}
+
+// leadingKeyword returns the first word of query in upper case, skipping
+// comments, for statements whose kind a parser does not tell.
+func leadingKeyword(query string) string {
+	s := query
+	for {
+		s = strings.TrimLeft(s, " \t\r\n;")
+		switch {
+		case strings.HasPrefix(s, "--"):
+			idx := strings.Index(s, "\n")
+			if idx < 0 {
+				return ""
+			}
+			s = s[idx+1:]
+		case strings.HasPrefix(s, "/*"):
+			idx := strings.Index(s, "*/")
+			if idx < 0 {
+				return ""
+			}
+			s = s[idx+2:]
+		default:
+			end := strings.IndexFunc(s, func(r rune) bool {
+				return !((r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || r == '_')
+			})
+			if end < 0 {
+				end = len(s)
+			}
+			return strings.ToUpper(s[:end])
+		}
+	}
+}
+
+// tables returns the tables whose rows or schema the table function reads,
+// and false when its arguments do not tell them, such as for dolt_patch()
+// of every table or a table name that is not a string literal. Named
+// functions other than Dolt's read no table.
+func (f policyCall) tables() ([]string, bool) {
+	name := strings.ToLower(f.name)
+	switch name {
+	case "dolt_log", "dolt_reflog":
+		return nil, true
+	case "dolt_diff", "dolt_preview_merge_conflicts":
+		// dolt_diff('from', 'to', 'table') or dolt_diff('from..to', 'table').
+		if f.literals && len(f.arguments) >= 2 {
+			return policyTableFunctionTable(f.arguments[len(f.arguments)-1])
+		}
+		return nil, false
+	case "dolt_patch", "dolt_diff_stat", "dolt_diff_summary", "dolt_schema_diff":
+		// The table is optional, and every table is read without it.
+		if f.literals && (len(f.arguments) == 3 || (len(f.arguments) == 2 && strings.Contains(f.arguments[0], ".."))) {
+			return policyTableFunctionTable(f.arguments[len(f.arguments)-1])
+		}
+		return nil, false
+	}
+	if len(policyTableNames(f.name)) > 1 {
+		// DoltLite names the table in the function, as in dolt_diff_people().
+		return []string{f.name}, true
+	}
+	return nil, name != "" && !strings.HasPrefix(name, "dolt_")
+}
+
+// policyTableFunctionTable returns the table a table function argument
+// names. A qualified name is not resolved, so that it cannot name a table of
+// another database the policy is not checked against.
+func policyTableFunctionTable(argument string) ([]string, bool) {
+	if argument == "" || strings.Contains(argument, ".") {
+		return nil, false
+	}
+	return []string{argument}, true
+}
+
+// baseDatabaseName strips a revision from a database name, so that
+// "mydb/feature" and "mydb@hash" refer to "mydb".
+func baseDatabaseName(database string) string {
+	if i := strings.IndexAny(database, "/@"); i >= 0 {
+		return database[:i]
+	}
+	return database
+}
+
+func matchPolicyPattern(pattern, name string) bool {
+	ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(name))
+	return ok
+}
+
+func splitColumnPattern(pattern string) (table, column string) {
+	if i := strings.LastIndex(pattern, "."); i >= 0 {
+		return pattern[:i], pattern[i+1:]
+	}
+	return "", pattern
+}
+
+func (p *SQLPolicy) deniesStatement(kind string) bool {
+	for _, denied := range p.DeniedStatements {
+		denied = normalizeStatementKind(denied)
+		if kind == denied || strings.HasPrefix(kind, denied+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *SQLPolicy) matchesTable(patterns []string, database string, table policyTable) bool {
+	if table.database != "" {
+		database = baseDatabaseName(table.database)
+	}
+	for _, pattern := range patterns {
+		for _, name := range policyTableNames(table.name) {
+			if strings.Contains(pattern, ".") {
+				name = database + "." + name
+			}
+			if matchPolicyPattern(pattern, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// policyTableNames returns table, and for a Dolt system table carrying the
+// rows of a user table, the user table too.
+func policyTableNames(table string) []string {
+	lower := strings.ToLower(table)
+	for _, prefix := range maskedDoltTablePrefixes {
+		if strings.HasPrefix(lower, prefix) && len(table) > len(prefix) {
+			return []string{table, table[len(prefix):]}
+		}
+	}
+	return []string{table}
+}
+
+// matchesTableName reports whether pattern matches table, or the user table
+// of a Dolt system table.
+func matchesTableName(pattern, table string) bool {
+	for _, name := range policyTableNames(table) {
+		if matchPolicyPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// deniedColumnTable reports whether the denied column pattern's table part
+// matches table, or, for an unqualified reference, any table referenced by
+// the statement.
+func deniedColumnTable(tablePattern, table string, stmt policyStatement) bool {
+	if tablePattern == "" {
+		return true
+	}
+	if table != "" {
+		return matchesTableName(tablePattern, table)
+	}
+	for _, t := range stmt.tables {
+		if matchesTableName(tablePattern, t.name) {
+			return true
+		}
+	}
+	return false
+}
+
+// deniedColumnName reports whether the denied column pattern matches column.
+// Dolt system tables prefix the columns of the user table, as in to_ssn and
+// from_ssn, so the prefixes are ignored when the column may belong to one.
+func deniedColumnName(columnPattern string, column policyColumn, stmt policyStatement) bool {
+	if matchPolicyPattern(columnPattern, column.name) {
+		return true
+	}
+	systemTable := len(policyTableNames(column.table)) > 1
+	if column.table == "" {
+		for _, t := range stmt.tables {
+			systemTable = systemTable || len(policyTableNames(t.name)) > 1
+		}
+	}
+	return systemTable && matchesMaskColumn(columnPattern, column.name)
+}
+
+// deniesBranch reports whether revision, such as a branch, a ref like
+// refs/heads/feature or origin/feature, a refspec like main:feature, or a
+// range like main..feature, names a denied branch.
+func (p *SQLPolicy) deniesBranch(revision string) bool {
+	for _, ref := range strings.Split(revision, ":") {
+		for _, part := range strings.Split(ref, "..") {
+			part = strings.Trim(part, ".+")
+			if i := strings.IndexAny(part, "~^"); i >= 0 {
+				part = part[:i]
+			}
+			for _, denied := range p.deniedBranches {
+				if strings.EqualFold(part, denied) || strings.HasSuffix(strings.ToLower(part), "/"+strings.ToLower(denied)) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// CheckBranch returns the violation of the policy, if any, in a tool
+// running on or changing branch without running a statement of the
+// client's.
+func (p *SQLPolicy) CheckBranch(branch string) error {
+	if p == nil || !p.deniesBranch(branch) {
+		return nil
+	}
+	return &SQLPolicyViolationError{Rule: fmt.Sprintf("branch %s is denied", branch), Clause: branch}
+}
+
+// CheckTable returns the violation of the policy, if any, in a tool reading
+// or changing table in the working database without running a statement of
+// the client's. When columns is true, the tool shows the table's columns or
+// rows, which is a violation when one of them may be denied.
+func (p *SQLPolicy) CheckTable(database, table string, columns bool) error {
+	if p == nil {
+		return nil
+	}
+	var stmt policyStatement
+	stmt.addTable("", table, table)
+	if columns {
+		stmt.addStar(table, table)
+	}
+	return p.check(stmt, database)
+}
+
+// checkAll returns the first violation of the policy in stmts, run against
+// the working database.
+func (p *SQLPolicy) checkAll(stmts []policyStatement, database string) error {
+	for _, stmt := range stmts {
+		if err := p.check(stmt, database); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withTableFunctionTables returns stmt with the tables its table functions
+// read added as tables read with *, since the functions return every column
+// of them. When a table function's tables are unknown, it is a violation of
+// any table or column rule.
+func (p *SQLPolicy) withTableFunctionTables(stmt policyStatement) (policyStatement, error) {
+	if len(stmt.tableFunctions) == 0 || (len(p.AllowedTables) == 0 && len(p.DeniedTables) == 0 && len(p.DeniedColumns) == 0) {
+		return stmt, nil
+	}
+	stmt.tables = append([]policyTable(nil), stmt.tables...)
+	stmt.stars = append([]policyColumn(nil), stmt.stars...)
+	for _, function := range stmt.tableFunctions {
+		tables, ok := function.tables()
+		if !ok {
+			return stmt, &SQLPolicyViolationError{Rule: fmt.Sprintf("table function %s may read tables the policy restricts", function.name), Clause: function.clause}
+		}
+		for _, table := range tables {
+			stmt.addTable("", table, function.clause)
+			stmt.addStar(table, function.clause)
+		}
+	}
+	return stmt, nil
+}
+
+// check returns the first violation of the policy in stmt, run against the
+// working database.
+func (p *SQLPolicy) check(stmt policyStatement, database string) error {
+	stmt, err := p.withTableFunctionTables(stmt)
+	if err != nil {
+		return err
+	}
+
+	for _, kind := range stmt.kinds {
+		if p.deniesStatement(kind.name) {
+			return &SQLPolicyViolationError{Rule: fmt.Sprintf("statement %s is denied", kind.name), Clause: kind.clause}
+		}
+		if preparedStatementKinds[kind.name] && p.restricts() {
+			return &SQLPolicyViolationError{Rule: fmt.Sprintf("statement %s is denied, since the statement it prepares is not checked", kind.name), Clause: kind.clause}
+		}
+	}
+
+	if len(p.deniedBranches) > 0 {
+		for _, ref := range stmt.databases {
+			if i := strings.IndexAny(ref.name, "/@"); i >= 0 && p.deniesBranch(ref.name[i+1:]) {
+				return &SQLPolicyViolationError{Rule: fmt.Sprintf("branch %s is denied", ref.name[i+1:]), Clause: ref.clause}
+			}
+		}
+		calls := stmt.procedures
+		for _, function := range stmt.tableFunctions {
+			if strings.HasPrefix(strings.ToLower(function.name), "dolt_") {
+				calls = append(calls[:len(calls):len(calls)], function)
+			}
+		}
+		for _, procedure := range calls {
+			if !procedure.literals {
+				return &SQLPolicyViolationError{Rule: fmt.Sprintf("procedure %s may name a denied branch", procedure.name), Clause: procedure.clause}
+			}
+			for _, argument := range procedure.arguments {
+				if p.deniesBranch(argument) {
+					return &SQLPolicyViolationError{Rule: fmt.Sprintf("branch %s is denied", argument), Clause: procedure.clause}
+				}
+			}
+		}
+	}
+
+	if p.BlockCrossDatabase {
+		for _, ref := range stmt.databases {
+			if !strings.EqualFold(baseDatabaseName(ref.name), baseDatabaseName(database)) {
+				return &SQLPolicyViolationError{Rule: fmt.Sprintf("database %s is not the working database", ref.name), Clause: ref.clause}
+			}
+		}
+	}
+
+	for _, table := range stmt.tables {
+		if p.matchesTable(p.DeniedTables, database, table) {
+			return &SQLPolicyViolationError{Rule: fmt.Sprintf("table %s is denied", table.name), Clause: table.clause}
+		}
+		if len(p.AllowedTables) > 0 && !p.matchesTable(p.AllowedTables, database, table) {
+			return &SQLPolicyViolationError{Rule: fmt.Sprintf("table %s is not allowed", table.name), Clause: table.clause}
+		}
+	}
+
+	for _, pattern := range p.DeniedColumns {
+		tablePattern, columnPattern := splitColumnPattern(pattern)
+		for _, column := range stmt.columns {
+			if deniedColumnName(columnPattern, column, stmt) && deniedColumnTable(tablePattern, column.table, stmt) {
+				return &SQLPolicyViolationError{Rule: fmt.Sprintf("column %s is denied", pattern), Clause: column.clause}
+			}
+		}
+		for _, star := range stmt.stars {
+			if len(stmt.tables) > 0 && deniedColumnTable(tablePattern, star.table, stmt) {
+				return &SQLPolicyViolationError{Rule: fmt.Sprintf("column %s is denied and * may include it", pattern), Clause: star.clause}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package db
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testSQLPolicy = &SQLPolicy{
+	DeniedStatements:   []string{"DROP DATABASE", "GRANT", "LOAD DATA", "CALL DOLT_PUSH"},
+	DeniedTables:       []string{"secrets", "audit_*"},
+	DeniedColumns:      []string{"people.ssn", "users.password_hash"},
+	BlockCrossDatabase: true,
+}
+
+type sqlPolicyTest struct {
+	query  string
+	clause string
+}
+
+func runSQLPolicyTests(t *testing.T, d Dialect, allowed []string, denied []sqlPolicyTest) {
+	t.Helper()
+	for _, query := range allowed {
+		require.NoError(t, d.ValidateQueryPolicy(query, "mydb", testSQLPolicy), query)
+	}
+	for _, test := range denied {
+		err := d.ValidateQueryPolicy(test.query, "mydb", testSQLPolicy)
+		require.Error(t, err, test.query)
+		require.True(t, errors.Is(err, ErrSQLPolicyViolation), "%s: %v", test.query, err)
+		var violation *SQLPolicyViolationError
+		require.True(t, errors.As(err, &violation))
+		require.Contains(t, strings.ToLower(violation.Clause), strings.ToLower(test.clause), test.query)
+	}
+}
+
+func TestMySQLDialectQueryPolicy(t *testing.T) {
+	runSQLPolicyTests(t, NewMySQLDialect(),
+		[]string{
+			"SELECT name, email FROM people",
+			"SELECT p.name FROM people AS p JOIN orders o ON o.person_id = p.id",
+			"SELECT COUNT(*) FROM people",
+			"SELECT * FROM orders",
+			"SELECT * FROM `mydb/feature`.orders",
+			"WITH recent AS (SELECT id FROM orders) SELECT id FROM recent",
+			"INSERT INTO people (name, email) VALUES ('a', 'b')",
+			"CALL DOLT_COMMIT('-m', 'message')",
+			"DROP TABLE orders",
+			"CREATE TABLE t AS SELECT name FROM people",
+			"CREATE TABLE t (id INT PRIMARY KEY, ssn VARCHAR(11))",
+		},
+		[]sqlPolicyTest{
+			{"DROP DATABASE mydb", "drop database"},
+			{"GRANT SELECT ON mydb.* TO 'u'@'%'", "grant"},
+			{"LOAD DATA INFILE '/tmp/people.csv' INTO TABLE people", "load data"},
+			{"CALL DOLT_PUSH('origin', 'main')", "dolt_push"},
+			{"SELECT DOLT_PUSH('origin', 'main')", "dolt_push"},
+			{"SELECT * FROM secrets", "secrets"},
+			{"SELECT id FROM audit_log", "audit_log"},
+			{"SELECT p.ssn FROM people p", "p.ssn"},
+			{"SELECT ssn FROM people", "ssn"},
+			{"SELECT * FROM people", "*"},
+			{"SELECT o.* FROM orders o JOIN people p ON p.id = o.person_id WHERE p.ssn = '1'", "p.ssn"},
+			{"UPDATE users SET password_hash = '' WHERE id = 1", "password_hash"},
+			{"INSERT INTO people (name, ssn) VALUES ('a', 'b')", "ssn"},
+			{"SELECT id FROM otherdb.orders", "otherdb.orders"},
+			{"USE otherdb", "use otherdb"},
+			{"SELECT id FROM orders WHERE id IN (SELECT order_id FROM secrets)", "secrets"},
+			{"CREATE TABLE t AS SELECT * FROM secrets", "secrets"},
+			{"CREATE TABLE t AS SELECT ssn FROM people", "ssn"},
+			{"CREATE TABLE t AS SELECT p.ssn FROM people p", "p.ssn"},
+			{"CREATE TABLE t AS SELECT * FROM people", "*"},
+			{"CREATE TABLE t LIKE secrets", "secrets"},
+			{"CREATE TABLE t AS SELECT id FROM otherdb.orders", "otherdb.orders"},
+			{"PREPARE s FROM 'DROP DATABASE mydb'", "prepare"},
+			{"EXECUTE s", "execute"},
+			{"DEALLOCATE PREPARE s", "deallocate"},
+		},
+	)
+}
+
+func TestPostgresDialectQueryPolicy(t *testing.T) {
+	runSQLPolicyTests(t, NewPostgresDialect(),
+		[]string{
+			"SELECT name, email FROM people",
+			"SELECT p.name FROM people AS p JOIN orders o ON o.person_id = p.id",
+			"SELECT COUNT(*) FROM people",
+			"SELECT * FROM public.orders",
+			"WITH recent AS (SELECT id FROM orders) SELECT id FROM recent",
+			"SELECT dolt_commit('-m', 'message')",
+			"DROP TABLE orders",
+		},
+		[]sqlPolicyTest{
+			{"DROP DATABASE mydb", "DROP DATABASE mydb"},
+			{"GRANT SELECT ON people TO someone", "GRANT"},
+			{"COPY people FROM '/tmp/people.csv'", "COPY"},
+			{"SELECT dolt_push('origin', 'main')", "dolt_push"},
+			{"SELECT * FROM secrets", "secrets"},
+			{"SELECT p.ssn FROM people p", "p.ssn"},
+			{"SELECT * FROM people", "*"},
+			{"UPDATE users SET password_hash = '' WHERE id = 1", "password_hash"},
+			{"SELECT id FROM otherdb.public.orders", "otherdb.public.orders"},
+			{"SELECT 1; SELECT * FROM secrets", "secrets"},
+			{"CREATE TABLE t AS SELECT * FROM secrets", "secrets"},
+			{"CREATE TABLE t AS SELECT ssn FROM people", "ssn"},
+			{"PREPARE s AS DELETE FROM orders", "PREPARE"},
+			{"EXECUTE s", "EXECUTE"},
+			{"DEALLOCATE s", "DEALLOCATE"},
+		},
+	)
+}
+
+func TestDoltLiteDialectQueryPolicy(t *testing.T) {
+	runSQLPolicyTests(t, NewDoltLiteDialect(),
+		[]string{
+			"SELECT name, email FROM people",
+			"SELECT p.name FROM people AS p JOIN orders o ON o.person_id = p.id",
+			"SELECT COUNT(*) FROM people",
+			"SELECT * FROM main.orders",
+			"SELECT 'secrets' AS label FROM orders",
+			"SELECT dolt_commit('-m', 'message')",
+			"DROP TABLE orders",
+		},
+		[]sqlPolicyTest{
+			{"SELECT dolt_push('origin', 'main')", "dolt_push"},
+			{"SELECT * FROM secrets", "secrets"},
+			{"SELECT id FROM orders, audit_log", "audit_log"},
+			{"SELECT p.ssn FROM people p", "p.ssn"},
+			{"SELECT * FROM people", "*"},
+			{"UPDATE users SET password_hash = '' WHERE id = 1", "password_hash"},
+			{"SELECT id FROM other.orders", "other.orders"},
+			{"ATTACH DATABASE 'other.db' AS other", "ATTACH"},
+			{"INSERT INTO secrets (id) VALUES (1)", "secrets"},
+			{"CREATE TABLE t AS SELECT * FROM secrets", "secrets"},
+			{"CREATE TABLE t AS SELECT ssn FROM people", "ssn"},
+		},
+	)
+}
+
+func TestSQLPolicyAllowedTables(t *testing.T) {
+	policy := &SQLPolicy{AllowedTables: []string{"orders", "mydb.people"}}
+	d := NewMySQLDialect()
+	require.NoError(t, d.ValidateQueryPolicy("SELECT * FROM orders JOIN people ON people.id = orders.person_id", "mydb", policy))
+	require.ErrorIs(t, d.ValidateQueryPolicy("SELECT * FROM other.people", "mydb", policy), ErrSQLPolicyViolation)
+	require.ErrorIs(t, d.ValidateQueryPolicy("SELECT * FROM products", "mydb", policy), ErrSQLPolicyViolation)
+	require.NoError(t, d.ValidateQueryPolicy("DROP DATABASE mydb", "mydb", nil))
+	require.NoError(t, d.ValidateQueryPolicy("PREPARE s FROM 'SELECT 1'", "mydb", &SQLPolicy{}))
+}
+
+func TestSQLPolicyValidate(t *testing.T) {
+	require.NoError(t, testSQLPolicy.Validate())
+	require.Error(t, (&SQLPolicy{DeniedTables: []string{"[unclosed"}}).Validate())
+}
+
+func TestMySQLDialectStatementKindsPerStatement(t *testing.T) {
+	kinds, err := NewMySQLDialect().StatementKinds("SELECT 1; SET autocommit = 0")
+	require.NoError(t, err)
+	require.Equal(t, []string{"SELECT", "SET"}, kinds)
+
+	policy := &SQLPolicy{DeniedStatements: []string{"SET"}}
+	err = NewMySQLDialect().ValidateQueryPolicy("SELECT 1; SET autocommit = 0", "mydb", policy)
+	require.ErrorIs(t, err, ErrSQLPolicyViolation)
+}
+
+func TestSQLPolicyDoltSystemTables(t *testing.T) {
+	d := NewMySQLDialect()
+	for _, query := range []string{
+		"SELECT * FROM dolt_diff_secrets",
+		"SELECT to_ssn FROM dolt_diff_people",
+		"SELECT from_ssn FROM dolt_history_people",
+		"SELECT * FROM dolt_commit_diff_people",
+	} {
+		require.ErrorIs(t, d.ValidateQueryPolicy(query, "mydb", testSQLPolicy), ErrSQLPolicyViolation, query)
+	}
+	require.NoError(t, d.ValidateQueryPolicy("SELECT to_name FROM dolt_diff_people", "mydb", testSQLPolicy))
+	require.NoError(t, d.ValidateQueryPolicy("SELECT to_ssn FROM orders", "mydb", testSQLPolicy))
+}
+
+func TestSQLPolicyCheckTable(t *testing.T) {
+	require.NoError(t, testSQLPolicy.CheckTable("mydb", "orders", true))
+	require.NoError(t, testSQLPolicy.CheckTable("mydb", "people", false))
+	require.ErrorIs(t, testSQLPolicy.CheckTable("mydb", "people", true), ErrSQLPolicyViolation)
+	require.ErrorIs(t, testSQLPolicy.CheckTable("mydb", "secrets", false), ErrSQLPolicyViolation)
+	require.ErrorIs(t, (&SQLPolicy{AllowedTables: []string{"orders"}}).CheckTable("mydb", "people", false), ErrSQLPolicyViolation)
+	var policy *SQLPolicy
+	require.NoError(t, policy.CheckTable("mydb", "secrets", true))
+}
+
+func TestSQLPolicyTableFunctions(t *testing.T) {
+	tests := []struct {
+		d       Dialect
+		allowed []string
+		denied  []sqlPolicyTest
+	}{
+		{
+			d: NewMySQLDialect(),
+			allowed: []string{
+				"SELECT * FROM dolt_diff('main', 'HEAD', 'orders')",
+				"SELECT * FROM dolt_patch('main..HEAD', 'orders')",
+				"SELECT * FROM dolt_log('main')",
+			},
+			denied: []sqlPolicyTest{
+				{"SELECT * FROM dolt_diff('main', 'HEAD', 'secrets')", "dolt_diff"},
+				{"SELECT * FROM dolt_diff('main..HEAD', 'secrets')", "dolt_diff"},
+				{"SELECT to_id FROM dolt_diff('main', 'HEAD', 'people')", "dolt_diff"},
+				{"SELECT * FROM dolt_patch('main', 'HEAD', 'secrets')", "dolt_patch"},
+				{"SELECT * FROM dolt_patch('main', 'HEAD')", "dolt_patch"},
+				{"SELECT * FROM dolt_diff('main', 'HEAD', @t)", "dolt_diff"},
+				{"SELECT * FROM dolt_diff('main', 'HEAD', 'otherdb.orders')", "dolt_diff"},
+			},
+		},
+		{
+			d: NewPostgresDialect(),
+			allowed: []string{
+				"SELECT * FROM dolt_diff('main', 'HEAD', 'orders')",
+				"SELECT * FROM generate_series(1, 3)",
+			},
+			denied: []sqlPolicyTest{
+				{"SELECT * FROM dolt_diff('main', 'HEAD', 'secrets')", "dolt_diff"},
+				{"SELECT * FROM dolt_patch('main', 'HEAD')", "dolt_patch"},
+				{"SELECT * FROM dolt_diff('main', 'HEAD', $1)", "dolt_diff"},
+			},
+		},
+		{
+			d: NewDoltLiteDialect(),
+			allowed: []string{
+				"SELECT * FROM dolt_diff('main', 'HEAD', 'orders')",
+				`SELECT * FROM "dolt_diff_orders"('main', 'HEAD')`,
+			},
+			denied: []sqlPolicyTest{
+				{"SELECT * FROM dolt_diff('main', 'HEAD', 'secrets')", "dolt_diff"},
+				{`SELECT to_id FROM "dolt_diff_people"('main', 'HEAD')`, "dolt_diff_people"},
+				{"SELECT * FROM dolt_patch('main', 'HEAD')", "dolt_patch"},
+				{"SELECT * FROM dolt_diff('main', 'HEAD', lower('SECRETS'))", "dolt_diff"},
+			},
+		},
+	}
+	for _, test := range tests {
+		runSQLPolicyTests(t, test.d, test.allowed, test.denied)
+	}
+	require.NoError(t, NewMySQLDialect().ValidateQueryPolicy("SELECT * FROM dolt_patch('main', 'HEAD')", "mydb", &SQLPolicy{DeniedStatements: []string{"GRANT"}}))
+}
+
+func TestSQLPolicyDeniedBranches(t *testing.T) {
+	policy := testSQLPolicy.WithDeniedBranches("mcp_audit")
+	require.Empty(t, testSQLPolicy.deniedBranches)
+
+	tests := []struct {
+		d       Dialect
+		allowed []string
+		denied  []string
+	}{
+		{
+			d: NewMySQLDialect(),
+			allowed: []string{
+				"CALL DOLT_BRANCH('-D', 'feature')",
+				"CALL DOLT_CHECKOUT('main')",
+				"SELECT * FROM dolt_log('main')",
+				"SELECT * FROM `mydb/main`.orders",
+			},
+			denied: []string{
+				"CALL DOLT_BRANCH('-D', 'mcp_audit')",
+				"CALL dolt_branch('-m', 'mcp_audit', 'other')",
+				"SELECT dolt_reset('--hard', 'mcp_audit')",
+				"CALL DOLT_CHECKOUT('MCP_AUDIT')",
+				"CALL DOLT_BRANCH('-D', @branch)",
+				"CALL DOLT_MERGE('refs/heads/mcp_audit')",
+				"SELECT * FROM dolt_log('mcp_audit')",
+				"USE `mydb/mcp_audit`",
+				"INSERT INTO `mydb/mcp_audit`.orders VALUES (1)",
+			},
+		},
+		{
+			d:       NewPostgresDialect(),
+			allowed: []string{"SELECT dolt_branch('-D', 'feature')"},
+			denied: []string{
+				"SELECT dolt_branch('-D', 'mcp_audit')",
+				"SELECT dolt_reset('--hard', $1)",
+			},
+		},
+		{
+			d:       NewDoltLiteDialect(),
+			allowed: []string{"SELECT dolt_branch('-D', 'feature')"},
+			denied: []string{
+				"SELECT dolt_branch('-D', 'mcp_audit')",
+				"SELECT dolt_checkout(lower('MCP_AUDIT'))",
+			},
+		},
+	}
+	for _, test := range tests {
+		for _, query := range test.allowed {
+			require.NoError(t, test.d.ValidateQueryPolicy(query, "mydb", policy), query)
+		}
+		for _, query := range test.denied {
+			require.ErrorIs(t, test.d.ValidateQueryPolicy(query, "mydb", policy), ErrSQLPolicyViolation, query)
+		}
+	}
+}
+
+func TestSQLPolicyCheckBranch(t *testing.T) {
+	policy := (&SQLPolicy{}).WithDeniedBranches("mcp_audit")
+	require.NoError(t, policy.CheckBranch("main"))
+	require.NoError(t, policy.CheckBranch("mcp_audit_old"))
+	require.ErrorIs(t, policy.CheckBranch("mcp_audit"), ErrSQLPolicyViolation)
+	require.ErrorIs(t, policy.CheckBranch("origin/mcp_audit"), ErrSQLPolicyViolation)
+	require.ErrorIs(t, policy.CheckBranch("main:mcp_audit"), ErrSQLPolicyViolation)
+	require.NoError(t, testSQLPolicy.CheckBranch("mcp_audit"))
+
+	var nilPolicy *SQLPolicy
+	require.NoError(t, nilPolicy.CheckBranch("mcp_audit"))
+}
@@ -0,0 +1,156 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// commitProcedurePattern matches the Dolt procedures that create commits,
+// whose result holds the hash of the new commit.
+var commitProcedurePattern = regexp.MustCompile(`(?i)\bdolt_(commit|merge|cherry_pick|revert|pull)\s*\(`)
+
+// StatementRecord is a statement run by a transaction.
+type StatementRecord struct {
+	SQL string `json:"sql"`
+	// RowsAffected is set for statements that change rows.
+	RowsAffected *int64 `json:"rows_affected,omitempty"`
+	// RowsReturned is set for queries.
+	RowsReturned *int `json:"rows_returned,omitempty"`
+	// CommitHash is the commit created by a Dolt procedure such as
+	// DOLT_COMMIT or DOLT_MERGE.
+	CommitHash string `json:"commit_hash,omitempty"`
+	// Duration is how long the statement took to run.
+	Duration time.Duration `json:"-"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// StatementRecorder collects the statements run by the transactions of a
+// context, such as the transactions of one tool call.
+type StatementRecorder struct {
+	mu         sync.Mutex
+	statements []StatementRecord
+	// parent is the recorder of the enclosing context, which records the
+	// same statements.
+	parent *StatementRecorder
+}
+
+type statementRecorderKey struct{}
+
+// ContextWithStatementRecorder returns a copy of ctx whose transactions
+// record the statements they run in recorder, as well as in any recorder
+// already on ctx.
+func ContextWithStatementRecorder(ctx context.Context, recorder *StatementRecorder) context.Context {
+	recorder.parent = statementRecorderFromContext(ctx)
+	return context.WithValue(ctx, statementRecorderKey{}, recorder)
+}
+
+func statementRecorderFromContext(ctx context.Context) *StatementRecorder {
+	recorder, _ := ctx.Value(statementRecorderKey{}).(*StatementRecorder)
+	return recorder
+}
+
+// Statements returns the recorded statements in the order they ran.
+func (r *StatementRecorder) Statements() []StatementRecord {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]StatementRecord(nil), r.statements...)
+}
+
+func (r *StatementRecorder) record(statement StatementRecord, start time.Time, err error) {
+	if r == nil {
+		return
+	}
+	statement.Duration = time.Since(start)
+	if err != nil {
+		statement.Error = err.Error()
+	}
+	for ; r != nil; r = r.parent {
+		r.mu.Lock()
+		r.statements = append(r.statements, statement)
+		r.mu.Unlock()
+	}
+}
+
+func (r *StatementRecorder) recordExec(query string, start time.Time, res sql.Result, err error) {
+	if r == nil {
+		return
+	}
+	statement := StatementRecord{SQL: query}
+	if err == nil {
+		if rowsAffected, rerr := res.RowsAffected(); rerr == nil {
+			statement.RowsAffected = &rowsAffected
+		}
+	}
+	r.record(statement, start, err)
+}
+
+func (r *StatementRecorder) recordQuery(query string, start time.Time, rowsReturned int, err error) {
+	if r == nil {
+		return
+	}
+	statement := StatementRecord{SQL: query}
+	if err == nil {
+		statement.RowsReturned = &rowsReturned
+	}
+	r.record(statement, start, err)
+}
+
+// execCommitProcedure runs a Dolt procedure that creates a commit as a
+// query, so that the hash of the commit can be recorded.
+func (d *databaseTransactionImpl) execCommitProcedure(ctx context.Context, recorder *StatementRecorder, query string) error {
+	start := time.Now()
+	hash, err := d.queryCommitHash(ctx, query)
+	recorder.record(StatementRecord{SQL: query, CommitHash: hash}, start, err)
+	return err
+}
+
+func (d *databaseTransactionImpl) queryCommitHash(ctx context.Context, query string) (string, error) {
+	rows, err := d.executor.QueryContext(ctx, query)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return "", err
+	}
+	var hash string
+	if rows.Next() && len(columns) > 0 {
+		values := make([]any, len(columns))
+		valuePtrs := make([]any, len(columns))
+		for i := range values {
+			valuePtrs[i] = &values[i]
+		}
+		if err := rows.Scan(valuePtrs...); err != nil {
+			return "", err
+		}
+		hash = commitHashFromValue(values[0])
+	}
+	return hash, rows.Err()
+}
+
+// commitHashFromValue reads the commit hash from the first column of a
+// Dolt procedure result. DoltgreSQL returns the result as a text array such
+// as {hash,0,0}.
+func commitHashFromValue(value any) string {
+	var text string
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		text = fmt.Sprint(v)
+	}
+	text = strings.TrimSuffix(strings.TrimPrefix(text, "{"), "}")
+	hash, _, _ := strings.Cut(text, ",")
+	return strings.Trim(hash, `"`)
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type fakeResult int64
+
+func (r fakeResult) LastInsertId() (int64, error) { return 0, nil }
+func (r fakeResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+func TestCommitHashFromValue(t *testing.T) {
+	for _, tc := range []struct {
+		value any
+		want  string
+	}{
+		{value: nil, want: ""},
+		{value: "abc123", want: "abc123"},
+		{value: []byte("abc123"), want: "abc123"},
+		{value: "{abc123,0,0}", want: "abc123"},
+		{value: `{"abc123",0,0}`, want: "abc123"},
+	} {
+		if got := commitHashFromValue(tc.value); got != tc.want {
+			t.Errorf("commitHashFromValue(%v) = %q, want %q", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestStatementRecorder(t *testing.T) {
+	var nilRecorder *StatementRecorder
+	nilRecorder.recordExec("DELETE FROM t", time.Now(), fakeResult(1), nil)
+
+	recorder := &StatementRecorder{}
+	recorder.recordExec("DELETE FROM t", time.Now(), fakeResult(3), nil)
+	recorder.recordQuery("SELECT * FROM t", time.Now(), 2, nil)
+	recorder.recordExec("DROP TABLE missing", time.Now(), nil, errors.New("table not found"))
+
+	statements := recorder.Statements()
+	if len(statements) != 3 {
+		t.Fatalf("expected 3 statements, got %d", len(statements))
+	}
+	if statements[0].RowsAffected == nil || *statements[0].RowsAffected != 3 {
+		t.Fatalf("unexpected rows affected: %+v", statements[0])
+	}
+	if statements[1].RowsReturned == nil || *statements[1].RowsReturned != 2 {
+		t.Fatalf("unexpected rows returned: %+v", statements[1])
+	}
+	if statements[2].Error != "table not found" || statements[2].RowsAffected != nil {
+		t.Fatalf("unexpected failed statement: %+v", statements[2])
+	}
+}
+
+func TestStatementRecorderRecordsInEnclosingRecorders(t *testing.T) {
+	outer := &StatementRecorder{}
+	inner := &StatementRecorder{}
+	ctx := ContextWithStatementRecorder(ContextWithStatementRecorder(context.Background(), outer), inner)
+
+	statementRecorderFromContext(ctx).recordQuery("SELECT 1", time.Now(), 1, nil)
+	if len(inner.Statements()) != 1 || len(outer.Statements()) != 1 {
+		t.Fatalf("expected both recorders to record the statement, got %d and %d", len(inner.Statements()), len(outer.Statements()))
+	}
+}
@@ -0,0 +1,48 @@
+package db
+
+import "strings"
+
+// StatementTargets are what the statements of a query name besides the
+// database and branch they run on.
+type StatementTargets struct {
+	// Databases are the databases the statements reference, such as
+	// "otherdb" or the revision database "mydb/feature".
+	Databases []string
+	// Revisions are the branches, tags or commits the statements read with
+	// AS OF.
+	Revisions []string
+	// Procedures are the Dolt procedures the statements call, such as
+	// "DOLT_CHECKOUT", whether with CALL or as functions.
+	Procedures []string
+	// UnnamedRevisions is true when a statement reads revisions that are not
+	// named by a literal, such as through a table function like dolt_diff()
+	// or AS OF an expression.
+	UnnamedRevisions bool
+}
+
+func newStatementTargets(stmts ...policyStatement) StatementTargets {
+	var targets StatementTargets
+	for _, stmt := range stmts {
+		for _, database := range stmt.databases {
+			targets.Databases = append(targets.Databases, database.name)
+		}
+		for _, revision := range stmt.revisions {
+			if revision.name == "" {
+				targets.UnnamedRevisions = true
+				continue
+			}
+			targets.Revisions = append(targets.Revisions, revision.name)
+		}
+		for _, kind := range stmt.kinds {
+			if procedure, ok := strings.CutPrefix(kind.name, "CALL "); ok {
+				if procedure, ok := doltProcedureName(procedure); ok {
+					targets.Procedures = append(targets.Procedures, procedure)
+				}
+			}
+		}
+		if len(stmt.tableFunctions) > 0 {
+			targets.UnnamedRevisions = true
+		}
+	}
+	return targets
+}
@@ -0,0 +1,101 @@
+package db
+
+import (
+	"context"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "github.com/dolthub/dolt-mcp/mcp/pkg/db"
+
+// urlCredentialsPattern matches the user information of URLs, such as the
+// credentials of a remote URL passed to DOLT_REMOTE or DOLT_CLONE.
+var urlCredentialsPattern = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/@\s'"]+@`)
+
+// RedactURLCredentials replaces the credentials of URLs in s.
+func RedactURLCredentials(s string) string {
+	if !strings.Contains(s, "://") {
+		return s
+	}
+	return urlCredentialsPattern.ReplaceAllString(s, "${1}[REDACTED]@")
+}
+
+// sqlLiteral matches a quoted string literal, or a Postgres dollar-quoted
+// string with an empty tag.
+const sqlLiteral = `(?:'(?:[^'\\]|\\.|'')*'|"(?:[^"\\]|\\.|"")*"|\$\$.*?\$\$)`
+
+// passwordLiteralPatterns match the password literals of statements that
+// set a password: IDENTIFIED BY and AS of CREATE USER and ALTER USER, the
+// current password of their REPLACE clause, SET PASSWORD, the PASSWORD
+// function, and the PASSWORD of Postgres roles. Each has the text before the
+// literal as its first group.
+var passwordLiteralPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?is)(\bIDENTIFIED\s+(?:WITH\s+\S+\s+)?(?:BY|AS)\s+(?:PASSWORD\s+)?)` + sqlLiteral),
+	regexp.MustCompile(`(?is)(\bIDENTIFIED\b[^;]*?\bREPLACE\s+)` + sqlLiteral),
+	regexp.MustCompile(`(?is)(\bSET\s+PASSWORD\b[^=;]*=\s*(?:PASSWORD\s*\(\s*)?)` + sqlLiteral),
+	regexp.MustCompile(`(?is)(\bPASSWORD\s*(?:\(\s*)?)` + sqlLiteral),
+}
+
+// RedactPasswordLiterals replaces the passwords set by statements in s,
+// such as the password of CREATE USER ... IDENTIFIED BY '...'.
+func RedactPasswordLiterals(s string) string {
+	upper := strings.ToUpper(s)
+	if !strings.Contains(upper, "PASSWORD") && !strings.Contains(upper, "IDENTIFIED") {
+		return s
+	}
+	for _, pattern := range passwordLiteralPatterns {
+		s = pattern.ReplaceAllString(s, "${1}'[REDACTED]'")
+	}
+	return s
+}
+
+// RedactCredentials removes the credentials of URLs and the passwords set by
+// statements from s.
+func RedactCredentials(s string) string {
+	return RedactPasswordLiterals(RedactURLCredentials(s))
+}
+
+// dbSystemName returns the db.system.name span attribute of a dialect.
+func dbSystemName(dt DialectType) attribute.KeyValue {
+	switch dt {
+	case DialectPostgres:
+		return semconv.DBSystemNameKey.String("doltgres")
+	case DialectDoltLite:
+		return semconv.DBSystemNameKey.String("doltlite")
+	default:
+		return semconv.DBSystemNameKey.String("dolt")
+	}
+}
+
+// startStatementSpan starts the span of a statement run by the transaction.
+// Credentials in URLs and passwords are removed from the statement text.
+func (d *databaseTransactionImpl) startStatementSpan(ctx context.Context, query string) (context.Context, trace.Span) {
+	operation, _, _ := strings.Cut(strings.TrimSpace(query), " ")
+	operation = strings.ToUpper(strings.TrimSuffix(operation, ";"))
+	name := operation
+	if name == "" {
+		name = "SQL"
+	}
+	return otel.Tracer(tracerName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			dbSystemName(d.dialectType),
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(RedactCredentials(query)),
+		),
+	)
+}
+
+func endStatementSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
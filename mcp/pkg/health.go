@@ -0,0 +1,161 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+)
+
+const (
+	HealthzPath = "/healthz"
+	ReadyzPath  = "/readyz"
+	InfoPath    = "/info"
+
+	readinessCheckTimeout = 5 * time.Second
+
+	readinessOK     = "ok"
+	readinessFailed = "failed"
+)
+
+// readinessChecks reports whether the server can serve tool calls.
+type readinessChecks struct {
+	logger *zap.Logger
+	pinger *db.Pinger
+	// backendPingers check the databases of the backends, by name.
+	backendPingers map[string]*db.Pinger
+	// failover, when the database is a Dolt cluster, checks that one of its
+	// members is the primary.
+	failover      *ClusterFailover
+	authenticator *jwtAuthenticator
+	// calls makes the server unready once it starts shutting down, so that
+	// load balancers stop sending it new sessions.
+	calls *InFlightCalls
+}
+
+type readinessResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks"`
+}
+
+type infoResponse struct {
+	Name     string        `json:"name"`
+	Version  string        `json:"version"`
+	Dialect  string        `json:"dialect"`
+	Tools    []string      `json:"tools"`
+	Backends []backendInfo `json:"backends,omitempty"`
+}
+
+type backendInfo struct {
+	Name    string   `json:"name"`
+	Path    string   `json:"path"`
+	Dialect string   `json:"dialect"`
+	Tools   []string `json:"tools"`
+}
+
+// check runs every check. Failures are logged rather than returned, since
+// the endpoint is not authenticated.
+func (c *readinessChecks) check(ctx context.Context) (bool, map[string]string) {
+	ctx, cancel := context.WithTimeout(ctx, readinessCheckTimeout)
+	defer cancel()
+
+	ready := true
+	checks := map[string]string{}
+	run := func(name string, check func() error) {
+		if err := check(); err != nil {
+			c.logger.Warn("readiness check failed", zap.String("check", name), zap.Error(err))
+			checks[name] = readinessFailed
+			ready = false
+			return
+		}
+		checks[name] = readinessOK
+	}
+
+	if c.calls != nil {
+		run("shutdown", func() error {
+			if c.calls.Draining() {
+				return ErrServerDraining
+			}
+			return nil
+		})
+	}
+	run("database", func() error { return c.pinger.Ping(ctx) })
+	if c.failover != nil {
+		run("cluster_primary", func() error {
+			if _, ok := c.failover.Endpoint(); !ok {
+				return ErrNoClusterPrimary
+			}
+			return nil
+		})
+	}
+	for name, pinger := range c.backendPingers {
+		run("database:"+name, func() error { return pinger.Ping(ctx) })
+	}
+	if c.authenticator != nil {
+		run("jwks", c.authenticator.ready)
+	}
+	return ready, checks
+}
+
+// withHealthEndpoints serves HealthzPath, ReadyzPath and InfoPath ahead of
+// authentication, so that probes and load balancers can reach them without
+// credentials.
+func withHealthEndpoints(next http.Handler, checks *readinessChecks, mcp *server.MCPServer, dialect db.DialectType, backends []*backendServer) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(HealthzPath, func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": readinessOK})
+	})
+	mux.HandleFunc(ReadyzPath, func(w http.ResponseWriter, r *http.Request) {
+		ready, results := checks.check(r.Context())
+		response := readinessResponse{Status: "ready", Checks: results}
+		status := http.StatusOK
+		if !ready {
+			response.Status = "unavailable"
+			status = http.StatusServiceUnavailable
+		}
+		writeJSON(w, status, response)
+	})
+	if dialect == "" {
+		dialect = db.DialectMySQL
+	}
+	mux.HandleFunc(InfoPath, func(w http.ResponseWriter, r *http.Request) {
+		response := infoResponse{
+			Name:    DoltMCPServerName,
+			Version: DoltMCPServerVersion,
+			Dialect: string(dialect),
+			Tools:   toolNames(mcp),
+		}
+		for _, b := range backends {
+			response.Backends = append(response.Backends, backendInfo{
+				Name:    b.name,
+				Path:    BackendPath(b.name),
+				Dialect: string(b.dbConfig.DialectType),
+				Tools:   toolNames(b.mcp),
+			})
+		}
+		writeJSON(w, http.StatusOK, response)
+	})
+	mux.Handle("/", next)
+	return mux
+}
+
+func toolNames(mcp *server.MCPServer) []string {
+	names := []string{}
+	for name := range mcp.ListTools() {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,96 @@
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+func TestHealthEndpointsBypassAuthentication(t *testing.T) {
+	_, jwks := newTestJWKS(t)
+	// The database is unreachable, so the server is alive but not ready.
+	config := db.Config{Host: "127.0.0.1", Port: 1, User: "root", DatabaseName: "mydb", DialectType: db.DialectMySQL}
+	srv, err := NewMCPHTTPServer(zap.NewNop(), config, 0, map[string]string{"iss": "test-issuer"}, jwks.URL, nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+	handler := srv.(*httpServerImpl).handler
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected the MCP endpoint to require a token, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, HealthzPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected %s to return %d, got %d", HealthzPath, http.StatusOK, rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ReadyzPath, nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected %s to return %d, got %d", ReadyzPath, http.StatusServiceUnavailable, rec.Code)
+	}
+	var readiness readinessResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &readiness); err != nil {
+		t.Fatalf("failed to decode readiness: %v", err)
+	}
+	if readiness.Checks["database"] != readinessFailed || readiness.Checks["jwks"] != readinessOK {
+		t.Fatalf("unexpected readiness checks %v", readiness.Checks)
+	}
+
+	srv.MCP().AddTool(mcp.NewTool("query"), nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, InfoPath, nil))
+	var info infoResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("failed to decode info: %v", err)
+	}
+	if info.Version != DoltMCPServerVersion || info.Dialect != string(db.DialectMySQL) || len(info.Tools) != 1 || info.Tools[0] != "query" {
+		t.Fatalf("unexpected info %+v", info)
+	}
+}
+
+func TestJWKSCacheReady(t *testing.T) {
+	_, jwks := newTestJWKS(t)
+	if err := newJWKSCache(jwks.URL, 0).ready(); err != nil {
+		t.Fatalf("expected the keys to be fetched, got %v", err)
+	}
+	if err := newJWKSCache("http://127.0.0.1:1/jwks", 0).ready(); err == nil {
+		t.Fatal("expected unreachable keys to fail the check")
+	}
+}
+
+func TestDrainingServerRefusesNewSessions(t *testing.T) {
+	config := db.Config{Host: "127.0.0.1", Port: 1, User: "root", DatabaseName: "mydb", DialectType: db.DialectMySQL}
+	srv, err := NewMCPHTTPServer(zap.NewNop(), config, 0, nil, "", nil)
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+	handler := srv.(*httpServerImpl).handler
+	srv.ToolSettings().InFlightCalls.Drain(context.Background())
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/mcp", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected a new session to be refused, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, ReadyzPath, nil))
+	var readiness readinessResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &readiness); err != nil {
+		t.Fatalf("failed to decode readiness: %v", err)
+	}
+	if rec.Code != http.StatusServiceUnavailable || readiness.Checks["shutdown"] != readinessFailed {
+		t.Fatalf("expected a draining server to be unready, got %d %v", rec.Code, readiness.Checks)
+	}
+}
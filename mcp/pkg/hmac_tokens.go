@@ -0,0 +1,175 @@
+package pkg
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	// HMACTokenIssuer is the issuer of the identities of HMAC token callers.
+	HMACTokenIssuer = "hmac-token"
+
+	hmacTokenPrefix = "hmac."
+	// hmacKeyMinLength is the shortest secret accepted, the size of a
+	// SHA-256 digest.
+	hmacKeyMinLength = sha256.Size
+)
+
+// HMACKey is a secret that signs HMAC tokens. Tokens name the key that
+// signed them by ID, so that a key can be rotated by adding a new key,
+// signing new tokens with it, and removing the old key once its tokens
+// expire.
+type HMACKey struct {
+	ID     string `yaml:"id" json:"id"`
+	Secret string `yaml:"secret" json:"secret"`
+	// SecretEnv names an environment variable holding the secret, to keep
+	// it out of the config file.
+	SecretEnv string `yaml:"secret_env" json:"secret_env"`
+}
+
+// hmacTokenClaims is the payload of an HMAC token.
+type hmacTokenClaims struct {
+	Subject string `json:"sub"`
+	// Expiry is the time the token expires, in seconds since the epoch.
+	Expiry int64 `json:"exp"`
+	// Scopes limits the token to the tools matching one of these globs. An
+	// empty list allows every tool.
+	Scopes []string `json:"scopes,omitempty"`
+}
+
+func (k *HMACKey) secret() []byte {
+	if k.SecretEnv != "" {
+		return []byte(os.Getenv(k.SecretEnv))
+	}
+	return []byte(k.Secret)
+}
+
+func (k *HMACKey) Validate() error {
+	if k.ID == "" {
+		return errors.New("hmac key has no id")
+	}
+	if strings.Contains(k.ID, ".") {
+		return fmt.Errorf("hmac key id %s contains a dot", k.ID)
+	}
+	if k.Secret != "" && k.SecretEnv != "" {
+		return fmt.Errorf("hmac key %s sets both secret and secret_env", k.ID)
+	}
+	if _, ok := os.LookupEnv(k.SecretEnv); k.SecretEnv != "" && !ok {
+		return fmt.Errorf("secret_env %s of hmac key %s is not set", k.SecretEnv, k.ID)
+	}
+	if len(k.secret()) < hmacKeyMinLength {
+		return fmt.Errorf("secret of hmac key %s is shorter than %d bytes", k.ID, hmacKeyMinLength)
+	}
+	return nil
+}
+
+func validateHMACKeys(keys []HMACKey) error {
+	ids := map[string]bool{}
+	for _, key := range keys {
+		if err := key.Validate(); err != nil {
+			return err
+		}
+		if ids[key.ID] {
+			return fmt.Errorf("duplicate hmac key id %s", key.ID)
+		}
+		ids[key.ID] = true
+	}
+	return nil
+}
+
+// SignHMACToken returns a token for subject, limited to scopes when there
+// are any, that expires at expiry. The token is signed by key.
+func SignHMACToken(key HMACKey, subject string, scopes []string, expiry time.Time) (string, error) {
+	if err := key.Validate(); err != nil {
+		return "", err
+	}
+	if subject == "" {
+		return "", errors.New("hmac token has no subject")
+	}
+	payload, err := json.Marshal(hmacTokenClaims{Subject: subject, Expiry: expiry.Unix(), Scopes: scopes})
+	if err != nil {
+		return "", err
+	}
+	signed := hmacTokenPrefix + key.ID + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signed + "." + base64.RawURLEncoding.EncodeToString(hmacTokenSignature(key, signed)), nil
+}
+
+func hmacTokenSignature(key HMACKey, signed string) []byte {
+	mac := hmac.New(sha256.New, key.secret())
+	mac.Write([]byte(signed))
+	return mac.Sum(nil)
+}
+
+// isHMACToken reports whether token has the form of an HMAC token, rather
+// than a static API key.
+func isHMACToken(token string) bool {
+	return strings.HasPrefix(token, hmacTokenPrefix)
+}
+
+// authenticateHMACToken returns the identity of the caller presenting token,
+// or nil when token is not signed by one of keys, has expired, or is
+// malformed.
+func authenticateHMACToken(keys []HMACKey, token string, now time.Time) *Identity {
+	parts := strings.Split(strings.TrimPrefix(token, hmacTokenPrefix), ".")
+	if len(parts) != 3 {
+		return nil
+	}
+	id, payload, signature := parts[0], parts[1], parts[2]
+
+	var key *HMACKey
+	for i := range keys {
+		if keys[i].ID == id {
+			key = &keys[i]
+			break
+		}
+	}
+	if key == nil {
+		return nil
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(decoded, hmacTokenSignature(*key, hmacTokenPrefix+id+"."+payload)) {
+		return nil
+	}
+
+	// The payload is only parsed once its signature is verified.
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil
+	}
+	var claims hmacTokenClaims
+	if err := json.Unmarshal(data, &claims); err != nil || claims.Subject == "" {
+		return nil
+	}
+	if claims.Expiry == 0 || !now.Before(time.Unix(claims.Expiry, 0)) {
+		return nil
+	}
+	for _, scope := range claims.Scopes {
+		if _, err := path.Match(scope, ""); err != nil {
+			return nil
+		}
+	}
+
+	var scopes []string
+	if len(claims.Scopes) > 0 {
+		scopes = claims.Scopes
+	}
+	return &Identity{
+		Subject: claims.Subject,
+		Issuer:  HMACTokenIssuer,
+		Scopes:  scopes,
+		Claims: map[string]any{
+			"sub": claims.Subject,
+			"iss": HMACTokenIssuer,
+			"exp": claims.Expiry,
+			"kid": id,
+		},
+	}
+}
@@ -0,0 +1,94 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testHMACKey = HMACKey{ID: "2026-10", Secret: strings.Repeat("s", hmacKeyMinLength)}
+
+func TestHMACTokensAuthenticate(t *testing.T) {
+	keys := &APIKeys{HMACKeys: []HMACKey{testHMACKey}}
+	if err := keys.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+
+	token, err := SignHMACToken(testHMACKey, "ci", []string{"query", "list_*"}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	identity := keys.Authenticate(token)
+	if identity == nil || identity.Subject != "ci" || identity.Issuer != HMACTokenIssuer || identity.Claims["kid"] != testHMACKey.ID {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+	if !identity.AllowsTool("list_databases") || identity.AllowsTool("exec") {
+		t.Fatalf("expected the token to be limited to its scopes, got %v", identity.Scopes)
+	}
+
+	expired, err := SignHMACToken(testHMACKey, "ci", nil, time.Now().Add(-time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	other := HMACKey{ID: testHMACKey.ID, Secret: strings.Repeat("o", hmacKeyMinLength)}
+	forged, err := SignHMACToken(other, "admin", nil, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	unknown, err := SignHMACToken(HMACKey{ID: "old", Secret: testHMACKey.Secret}, "ci", nil, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	parts := strings.Split(token, ".")
+	tampered := strings.Join([]string{parts[0], parts[1], parts[2] + "x", parts[3]}, ".")
+	for name, token := range map[string]string{
+		"expired":   expired,
+		"forged":    forged,
+		"unknown":   unknown,
+		"tampered":  tampered,
+		"truncated": strings.Join(parts[:3], "."),
+	} {
+		if identity := keys.Authenticate(token); identity != nil {
+			t.Fatalf("expected no identity for the %s token, got %+v", name, identity)
+		}
+	}
+}
+
+func TestHMACKeysValidate(t *testing.T) {
+	t.Setenv("TEST_HMAC_SECRET", testHMACKey.Secret)
+	if err := (&APIKeys{HMACKeys: []HMACKey{{ID: "env", SecretEnv: "TEST_HMAC_SECRET"}}}).Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	for _, keys := range [][]HMACKey{
+		{{Secret: testHMACKey.Secret}},
+		{{ID: "a.b", Secret: testHMACKey.Secret}},
+		{{ID: "short", Secret: "secret"}},
+		{{ID: "both", Secret: testHMACKey.Secret, SecretEnv: "TEST_HMAC_SECRET"}},
+		{{ID: "unset", SecretEnv: "TEST_HMAC_SECRET_UNSET"}},
+		{testHMACKey, testHMACKey},
+	} {
+		if err := (&APIKeys{HMACKeys: keys}).Validate(); err == nil {
+			t.Fatalf("expected an error validating %+v", keys)
+		}
+	}
+}
+
+func TestHMACKeysReloadFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "keys.yaml")
+	if err := os.WriteFile(file, []byte("hmac_keys:\n  - id: "+testHMACKey.ID+"\n    secret: "+testHMACKey.Secret+"\n"), 0o600); err != nil {
+		t.Fatalf("failed to write keys file: %v", err)
+	}
+	keys := &APIKeys{File: file}
+	if err := keys.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	token, err := SignHMACToken(testHMACKey, "ci", nil, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if keys.Authenticate(token) == nil {
+		t.Fatalf("expected a token signed by a key from the file to authenticate")
+	}
+}
@@ -3,11 +3,14 @@ package pkg
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -16,14 +19,28 @@ import (
 	"go.uber.org/zap"
 )
 
+// connectionCloseTimeout is how long connections are given to close once
+// the tool calls have drained.
+const connectionCloseTimeout = 5 * time.Second
+
 type httpServerImpl struct {
-	mcp       *server.MCPServer
-	handler   http.Handler
-	port      int
-	dbConfig  db.Config
-	dialect   db.Dialect
-	logger    *zap.Logger
-	tlsConfig *tls.Config
+	mcp *server.MCPServer
+	// mcpHandler serves the MCP sessions, and handler wraps it in the
+	// authentication and endpoints the settings configure.
+	mcpHandler http.Handler
+	handler    *reloadableHandler
+	port       int
+	dbConfig   db.Config
+	dialect    db.Dialect
+	logger     *zap.Logger
+	tlsConfig  *tls.Config
+	pinger     *db.Pinger
+	// backendPingers check the databases of the backends, by name.
+	backendPingers map[string]*db.Pinger
+	jwkClaimsMap   map[string]string
+	jwkURL         string
+
+	serverSettings
 }
 
 type HTTPServer interface {
@@ -41,54 +58,143 @@ func NewMCPHTTPServer(logger *zap.Logger, config db.Config, port int, jwkClaimsM
 		server.WithLogging(),
 	)
 
+	inFlightCalls := NewInFlightCalls(logger)
 	baseHandler := server.NewStreamableHTTPServer(mcp, server.WithLogger(NewZapUtilLogger(logger)))
-	// If debug logging is enabled, wrap with access log middleware
-	var handler http.Handler = baseHandler
-	if logger.Core().Enabled(zap.DebugLevel) {
-		handler = withAccessLogging(baseHandler, logger)
-	}
 
-	bearerTokenAuth := false
-	if jwkClaimsMap != nil && jwkUrl != "" {
-		bearerTokenAuth = true
-	} else if jwkClaimsMap != nil || jwkUrl != "" {
+	if (jwkClaimsMap != nil) != (jwkUrl != "") {
 		return nil, fmt.Errorf("if a JWK URL or claims are provided, both must be provided for bearer token authentication")
 	}
 
-	if bearerTokenAuth {
-		var err error
-		handler, err = withBearerAuth(logger, handler, jwkClaimsMap, jwkUrl)
-		if err != nil {
-			return nil, fmt.Errorf("failed to set up bearer token authentication: %w", err)
-		}
-	}
-
 	if err := db.PrepareDatabase(&config); err != nil {
 		return nil, fmt.Errorf("failed to prepare database: %w", err)
 	}
 
 	srv := &httpServerImpl{
-		logger:    logger,
-		mcp:       mcp,
-		dbConfig:  config,
-		dialect:   db.NewDialect(config.DialectType),
-		port:      port,
-		handler:   handler,
-		tlsConfig: tlsConfig,
+		logger:       logger,
+		mcp:          mcp,
+		mcpHandler:   withNewSessionsRefusedWhileDraining(baseHandler, inFlightCalls),
+		dbConfig:     config,
+		dialect:      db.NewDialect(config.DialectType),
+		port:         port,
+		tlsConfig:    tlsConfig,
+		jwkClaimsMap: jwkClaimsMap,
+		jwkURL:       jwkUrl,
 	}
+	srv.inFlightCalls = inFlightCalls
 
 	for _, opt := range opts {
 		opt(srv)
 	}
+	if srv.optionErr != nil {
+		return nil, srv.optionErr
+	}
+
+	var sseServers []*server.SSEServer
+	if srv.sse {
+		sseServers = append(sseServers, newSSEServer(mcp, ""))
+	}
+	if len(srv.backends) > 0 {
+		backendHandlers := map[string]http.Handler{}
+		srv.backendPingers = map[string]*db.Pinger{}
+		for _, b := range srv.backends {
+			handler := server.NewStreamableHTTPServer(b.mcp, server.WithLogger(NewZapUtilLogger(logger)))
+			backendHandlers[b.name] = withNewSessionsRefusedWhileDraining(handler, inFlightCalls)
+			srv.backendPingers[b.name] = db.NewPinger(b.dbConfig)
+			if srv.sse {
+				sseServers = append(sseServers, newSSEServer(b.mcp, BackendPath(b.name)))
+			}
+		}
+		srv.mcpHandler = withBackendRoutes(srv.mcpHandler, backendHandlers)
+	}
+	if len(sseServers) > 0 {
+		srv.mcpHandler = withSSERoutes(srv.mcpHandler, inFlightCalls, sseServers...)
+	}
+	srv.pinger = db.NewPinger(srv.dbConfig)
+	handler, err := srv.newHandler(srv.jwtConfig, srv.apiKeys)
+	if err != nil {
+		return nil, err
+	}
+	srv.handler = &reloadableHandler{}
+	srv.handler.set(handler)
 
 	return srv, nil
 }
 
+// newHandler wraps the MCP handler in the authentication the settings
+// configure, with the issuers of jwtConfig and the keys of apiKeys.
+// Authentication is set up once the options have been applied, since
+// issuers and keys can come from the config file, and again when the config
+// file is reloaded.
+func (s *httpServerImpl) newHandler(jwtConfig *JWTConfig, apiKeys *APIKeys) (http.Handler, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	handler := s.mcpHandler
+	// Metrics are served behind the same authentication as MCP.
+	if s.metricsEndpoint {
+		handler = withMetricsEndpoint(handler)
+	}
+	// If debug logging is enabled, wrap with access log middleware
+	if s.logger.Core().Enabled(zap.DebugLevel) {
+		handler = withAccessLogging(handler, s.logger)
+	}
+	unauthenticatedHandler := handler
+
+	authenticator, err := newJWTAuthenticator(s.jwkClaimsMap, s.jwkURL, jwtConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set up bearer token authentication: %w", err)
+	}
+	var bearerAuthHandler http.Handler
+	if authenticator != nil {
+		bearerAuthHandler = withJWTAuth(s.logger, handler, authenticator)
+		handler = bearerAuthHandler
+	}
+	if apiKeys != nil {
+		handler = withAPIKeyAuth(s.logger, unauthenticatedHandler, apiKeys, bearerAuthHandler)
+	}
+	if s.clientCertificateIdentity {
+		handler = withClientCertificateIdentity(s.logger, handler)
+	}
+	if authenticator != nil {
+		handler = withProtectedResourceMetadata(handler, authenticator)
+	}
+	handler = withRemoteAddress(handler)
+	handler = withTracing(handler)
+	readiness := &readinessChecks{logger: s.logger, pinger: s.pinger, backendPingers: s.backendPingers, authenticator: authenticator, calls: s.inFlightCalls}
+	if failover, ok := s.dbConfig.Primary.(*ClusterFailover); ok {
+		readiness.failover = failover
+	}
+	return withHealthEndpoints(handler, readiness, s.mcp, s.dbConfig.DialectType, s.backends), nil
+}
+
+// reload reads the config file again and rebuilds the authentication it
+// configures. The settings and the handler are both built before either is
+// replaced, and are replaced together. Sessions live in the MCP handler,
+// which is kept, so they survive the reload.
+func (s *httpServerImpl) reload() error {
+	reloaded, err := s.loadConfig(s, s.DBConfig())
+	if err != nil {
+		return err
+	}
+	handler, err := s.newHandler(reloaded.settings.jwtConfig, reloaded.settings.apiKeys)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.applyConfig(reloaded, &s.dbConfig)
+	s.handler.set(handler)
+	return nil
+}
+
 func (s *httpServerImpl) MCP() *server.MCPServer {
 	return s.mcp
 }
 
 func (s *httpServerImpl) DBConfig() db.Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.dbConfig
 }
 
@@ -98,14 +204,29 @@ func (s *httpServerImpl) Dialect() db.Dialect {
 
 func (s *httpServerImpl) ListenAndServe(ctx context.Context) {
 	defer func() {
+		if err := s.pinger.Close(); err != nil {
+			s.logger.Error("failed to close readiness connection", zap.Error(err))
+		}
+		for name, pinger := range s.backendPingers {
+			if err := pinger.Close(); err != nil {
+				s.logger.Error("failed to close readiness connection", zap.String("backend", name), zap.Error(err))
+			}
+		}
 		if err := db.CloseDatabase(s.dbConfig); err != nil {
 			s.logger.Error("failed to close database", zap.Error(err))
 		}
+		if err := s.closeBackends(); err != nil {
+			s.logger.Error("failed to close backend database", zap.Error(err))
+		}
 	}()
-	serve(ctx, s.logger, s.handler, s.port, s.tlsConfig)
+	serve(ctx, s.logger, s.handler, s.port, s.unixSocket, s.tlsConfig, func() { s.drain(s.logger) }, s.reload)
 }
 
-func serve(ctx context.Context, logger *zap.Logger, handler http.Handler, port int, tlsConfig *tls.Config) {
+// serve serves handler on port, or on the unix domain socket at socket when
+// it is set, until a signal or ctx asks it to stop. It then stops accepting
+// new sessions and calls drain, which waits for the running tool calls,
+// before closing the connections. SIGHUP calls reload.
+func serve(ctx context.Context, logger *zap.Logger, handler http.Handler, port int, socket string, tlsConfig *tls.Config, drain func(), reload func() error) {
 	portStr := fmt.Sprintf(":%d", port)
 	srv := &http.Server{
 		Addr:      portStr,
@@ -113,20 +234,46 @@ func serve(ctx context.Context, logger *zap.Logger, handler http.Handler, port i
 		TLSConfig: tlsConfig,
 	}
 
+	var listener net.Listener
+	var err error
+	if socket != "" {
+		listener, err = listenUnix(socket)
+		portStr = socket
+	} else {
+		listener, err = net.Listen("tcp", portStr)
+	}
+	if err != nil {
+		logger.Error("error serving Dolt MCP server", zap.Error(err))
+		return
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	defer signal.Stop(quit)
 
 	shutdownOnce := sync.Once{}
+	shutdownDone := make(chan struct{})
+	stopReloading := make(chan struct{})
+	defer close(stopReloading)
+	reloadOnSIGHUP(logger, reload, stopReloading)
 
 	// Graceful shutdown logic shared by both signal and context
 	shutdown := func(reason string) {
 		shutdownOnce.Do(func() {
+			defer close(shutdownDone)
 			fmt.Println("Shutting down Dolt MCP due to:", reason)
-			ctxTimeout, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			srv.SetKeepAlivesEnabled(false)
+			drain()
+			// The tool calls are done, so only idle connections and event
+			// streams are left. Streams never go idle, so they are closed
+			// once the timeout passes.
+			ctxTimeout, cancel := context.WithTimeout(context.Background(), connectionCloseTimeout)
 			defer cancel()
 			if err := srv.Shutdown(ctxTimeout); err != nil {
-				logger.Error("failed to shutdown server", zap.Error(err))
+				if !errors.Is(err, context.DeadlineExceeded) {
+					logger.Error("failed to shutdown server", zap.Error(err))
+				}
+				_ = srv.Close()
 			}
 		})
 	}
@@ -144,23 +291,72 @@ func serve(ctx context.Context, logger *zap.Logger, handler http.Handler, port i
 	}()
 
 	// Start the server
-	var err error
 	if tlsConfig != nil {
 		logger.Info("Dolt MCP server ready. Accepting HTTPS connections.", zap.String("addr", portStr))
 		// When using custom TLSConfig with certificates already loaded,
-		// we call ListenAndServeTLS with empty cert/key strings
-		err = srv.ListenAndServeTLS("", "")
+		// we call ServeTLS with empty cert/key strings
+		err = srv.ServeTLS(listener, "", "")
 	} else {
 		logger.Info("Dolt MCP server ready. Accepting HTTP connections.", zap.String("addr", portStr))
-		err = srv.ListenAndServe()
+		err = srv.Serve(listener)
 	}
-	if err != nil && err != http.ErrServerClosed {
+	if err == http.ErrServerClosed {
+		// Serve returns as soon as shutdown begins, so wait for
+		// the drain before the database is closed.
+		<-shutdownDone
+	} else if err != nil {
 		logger.Error("error serving Dolt MCP server", zap.Error(err))
 	}
 
 	logger.Info("Successfully stopped Dolt MCP server.")
 }
 
+// listenUnix listens on the unix domain socket at path. A socket left behind
+// by a server that is no longer running is replaced.
+func listenUnix(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("cannot listen on %s: the file exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("cannot listen on %s: another server is listening on it", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// reloadableHandler serves with the handler last set, so that a config
+// reload can replace the authentication without restarting the listener.
+type reloadableHandler struct {
+	handler atomic.Pointer[http.Handler]
+}
+
+func (h *reloadableHandler) set(handler http.Handler) {
+	h.handler.Store(&handler)
+}
+
+func (h *reloadableHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	(*h.handler.Load()).ServeHTTP(w, r)
+}
+
+// withNewSessionsRefusedWhileDraining refuses requests that would start a
+// session once the server is shutting down. Requests of existing sessions
+// still reach the server, whose tool calls are refused by the tools.
+func withNewSessionsRefusedWhileDraining(next http.Handler, calls *InFlightCalls) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Draining() && r.Header.Get(server.HeaderKeySessionID) == "" {
+			w.Header().Set("Connection", "close")
+			http.Error(w, ErrServerDraining.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // withAccessLogging wraps an http.Handler to log HTTP requests at debug level
 // including method, path, status code, and duration.
 func withAccessLogging(next http.Handler, logger *zap.Logger) http.Handler {
@@ -214,3 +410,19 @@ func (w *loggingResponseWriter) Write(b []byte) (int, error) {
 	w.bytesWritten += n
 	return n, err
 }
+
+// Flush forwards to the underlying writer, which the streamable HTTP
+// transport needs to stream events.
+func (w *loggingResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.statusCode == 0 {
+			w.statusCode = http.StatusOK
+		}
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer for http.ResponseController.
+func (w *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
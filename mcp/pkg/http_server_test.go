@@ -0,0 +1,68 @@
+package pkg
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestServeOnUnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "mcp.sock")
+	// A socket left behind by a server that crashed is replaced.
+	stale, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatalf("failed to create a stale socket: %v", err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	})
+	go func() {
+		defer close(stopped)
+		serve(ctx, zap.NewNop(), handler, 0, socket, nil, func() {}, func() error { return nil })
+	}()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}}
+	var resp *http.Response
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		resp, err = client.Get("http://mcp/healthz")
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("failed to reach the server over its socket: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "ok" {
+		t.Fatalf("unexpected response %q", body)
+	}
+
+	if _, err := listenUnix(socket); err == nil {
+		t.Fatal("expected a socket in use to be refused")
+	}
+
+	cancel()
+	<-stopped
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Fatalf("expected the socket to be removed on shutdown, got %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Identity is the authenticated caller of a request.
+type Identity struct {
+	Subject string
+	Issuer  string
+	// Token is the caller's bearer token.
+	Token string
+	// Scopes limits the caller to the tools matching one of these globs.
+	// Nil allows every tool.
+	Scopes []string
+	// Claims holds every claim of the caller's token, including the ones
+	// that are not standard JWT claims.
+	Claims map[string]any
+}
+
+type identityKey struct{}
+
+// ContextWithIdentity returns a copy of ctx carrying identity.
+func ContextWithIdentity(ctx context.Context, identity *Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// IdentityFromContext returns the caller of the request ctx belongs to, or
+// nil when the request was not authenticated.
+func IdentityFromContext(ctx context.Context) *Identity {
+	identity, _ := ctx.Value(identityKey{}).(*Identity)
+	return identity
+}
+
+// ClaimValues returns the values of the named claim. Nested claims are named
+// with dots, such as "realm_access.roles". A string claim is split on
+// whitespace, so that space separated lists like "scope" are read the same
+// way as arrays.
+func (i *Identity) ClaimValues(name string) []string {
+	switch v := i.claim(name).(type) {
+	case string:
+		return strings.Fields(v)
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	case []string:
+		return v
+	}
+	return nil
+}
+
+// HasClaimValue reports whether the named claim is value or, for a claim
+// holding a list, contains value. Other claim types are compared by their
+// formatted value.
+func (i *Identity) HasClaimValue(name, value string) bool {
+	switch v := i.claim(name).(type) {
+	case nil:
+		return false
+	case string:
+		return v == value
+	case []any:
+		for _, item := range v {
+			if fmt.Sprint(item) == value {
+				return true
+			}
+		}
+		return false
+	case []string:
+		return slices.Contains(v, value)
+	default:
+		return fmt.Sprint(v) == value
+	}
+}
+
+// claim returns the value of the named claim, or nil when there is none.
+func (i *Identity) claim(name string) any {
+	if i == nil {
+		return nil
+	}
+	var value any = i.Claims
+	for _, part := range strings.Split(name, ".") {
+		claims, ok := value.(map[string]any)
+		if !ok {
+			return nil
+		}
+		value = claims[part]
+	}
+	return value
+}
+
+// AllowsTool reports whether the caller's scopes include the named tool. A
+// nil identity is not limited by scopes.
+func (i *Identity) AllowsTool(name string) bool {
+	if i == nil || i.Scopes == nil {
+		return true
+	}
+	for _, scope := range i.Scopes {
+		if ok, _ := path.Match(scope, name); ok {
+			return true
+		}
+	}
+	return false
+}
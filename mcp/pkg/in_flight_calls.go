@@ -0,0 +1,153 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultShutdownTimeout is how long running tool calls may take to
+	// finish once the server is asked to stop.
+	DefaultShutdownTimeout = 10 * time.Second
+
+	// drainCancelGrace is how long canceled tool calls are given to roll
+	// back their transactions before the server stops regardless.
+	drainCancelGrace = 5 * time.Second
+)
+
+// ErrServerDraining is returned for tool calls made while the server is
+// shutting down.
+var ErrServerDraining = errors.New("the server is shutting down and is not accepting tool calls")
+
+// InFlightCalls tracks the running tool calls, so that shutdown can wait for
+// them to finish and cancel the ones that do not.
+type InFlightCalls struct {
+	logger *zap.Logger
+
+	mu       sync.Mutex
+	next     uint64
+	calls    map[uint64]*inFlightCall
+	draining bool
+	wg       sync.WaitGroup
+}
+
+type inFlightCall struct {
+	tool    string
+	subject string
+	start   time.Time
+	cancel  context.CancelFunc
+}
+
+func NewInFlightCalls(logger *zap.Logger) *InFlightCalls {
+	return &InFlightCalls{
+		logger: logger,
+		calls:  map[uint64]*inFlightCall{},
+	}
+}
+
+// Start records a call of tool. The call runs with the returned context,
+// which is canceled if the call outlives the drain, and must call the
+// returned function when it returns. Calls are refused with
+// ErrServerDraining once Drain has been called.
+func (c *InFlightCalls) Start(ctx context.Context, tool string) (context.Context, func(), error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.draining {
+		return ctx, nil, ErrServerDraining
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	call := &inFlightCall{tool: tool, start: time.Now(), cancel: cancel}
+	if identity := IdentityFromContext(ctx); identity != nil {
+		call.subject = identity.Subject
+	}
+	id := c.next
+	c.next++
+	c.calls[id] = call
+	c.wg.Add(1)
+
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			c.mu.Lock()
+			delete(c.calls, id)
+			c.mu.Unlock()
+			cancel()
+			c.wg.Done()
+		})
+	}, nil
+}
+
+// Draining reports whether Drain has been called.
+func (c *InFlightCalls) Draining() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.draining
+}
+
+// Len returns the number of running calls.
+func (c *InFlightCalls) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.calls)
+}
+
+// Drain refuses new calls and waits for the running ones to finish until ctx
+// is done. Calls still running then are canceled, which rolls back their
+// transactions, and logged. It returns the number of calls that were
+// canceled.
+func (c *InFlightCalls) Drain(ctx context.Context) int {
+	c.mu.Lock()
+	c.draining = true
+	running := len(c.calls)
+	c.mu.Unlock()
+
+	if running > 0 {
+		c.logger.Info("waiting for running tool calls to finish", zap.Int("calls", running))
+	}
+	if c.wait(ctx) {
+		return 0
+	}
+
+	c.mu.Lock()
+	interrupted := make([]*inFlightCall, 0, len(c.calls))
+	for _, call := range c.calls {
+		interrupted = append(interrupted, call)
+	}
+	c.mu.Unlock()
+
+	now := time.Now()
+	for _, call := range interrupted {
+		c.logger.Warn("canceling tool call interrupted by shutdown",
+			zap.String("tool", call.tool),
+			zap.String("subject", call.subject),
+			zap.Duration("running", now.Sub(call.start)))
+		call.cancel()
+	}
+
+	graceCtx, cancel := context.WithTimeout(context.Background(), drainCancelGrace)
+	defer cancel()
+	if !c.wait(graceCtx) {
+		c.logger.Error("tool calls did not return after being canceled", zap.Int("calls", c.Len()))
+	}
+	return len(interrupted)
+}
+
+// wait reports whether every call finished before ctx was done.
+func (c *InFlightCalls) wait(ctx context.Context) bool {
+	done := make(chan struct{})
+	go func() {
+		c.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
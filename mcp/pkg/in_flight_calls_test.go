@@ -0,0 +1,55 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestInFlightCallsDrainWaitsForCalls(t *testing.T) {
+	calls := NewInFlightCalls(zap.NewNop())
+	_, finish, err := calls.Start(context.Background(), "query")
+	if err != nil {
+		t.Fatalf("unexpected error starting call: %v", err)
+	}
+
+	drained := make(chan int)
+	go func() { drained <- calls.Drain(context.Background()) }()
+	time.Sleep(10 * time.Millisecond)
+	if !calls.Draining() {
+		t.Fatal("expected the calls to be draining")
+	}
+	if _, _, err := calls.Start(context.Background(), "query"); !errors.Is(err, ErrServerDraining) {
+		t.Fatalf("expected new calls to be refused while draining, got %v", err)
+	}
+
+	finish()
+	if interrupted := <-drained; interrupted != 0 {
+		t.Fatalf("expected no interrupted calls, got %d", interrupted)
+	}
+}
+
+func TestInFlightCallsDrainCancelsCalls(t *testing.T) {
+	calls := NewInFlightCalls(zap.NewNop())
+	ctx, finish, err := calls.Start(context.Background(), "exec")
+	if err != nil {
+		t.Fatalf("unexpected error starting call: %v", err)
+	}
+	// The call returns once it is canceled, as a rolled back tool call would.
+	go func() {
+		<-ctx.Done()
+		finish()
+	}()
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if interrupted := calls.Drain(drainCtx); interrupted != 1 {
+		t.Fatalf("expected one interrupted call, got %d", interrupted)
+	}
+	if calls.Len() != 0 {
+		t.Fatalf("expected the canceled call to have finished, got %d running", calls.Len())
+	}
+}
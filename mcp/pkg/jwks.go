@@ -0,0 +1,144 @@
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"gopkg.in/go-jose/go-jose.v2"
+)
+
+const (
+	// DefaultJWKSRefreshInterval is how often a JWKS is read again when its
+	// issuer does not set a refresh interval.
+	DefaultJWKSRefreshInterval = 5 * time.Minute
+	// minJWKSRefreshInterval limits how often a token naming an unknown key,
+	// or a failed read, makes the JWKS be read again before it is due.
+	minJWKSRefreshInterval = 10 * time.Second
+	jwksFetchTimeout       = 10 * time.Second
+)
+
+// jwksCache holds the keys of an issuer, read from a URL or a local file,
+// and reads them again every refreshInterval. Tokens signed by a key the
+// cache does not have make it read the keys early, so that keys rotated by
+// the issuer are picked up without waiting for the interval.
+type jwksCache struct {
+	url             string
+	file            string
+	refreshInterval time.Duration
+	client          *http.Client
+
+	mu        sync.Mutex
+	keys      *jose.JSONWebKeySet
+	fetched   time.Time
+	attempted time.Time
+	// err is the error of the last failed read.
+	err error
+}
+
+// newJWKSCache returns a cache of the keys at location, which is an http(s)
+// URL, a file:// URL or a file path.
+func newJWKSCache(location string, refreshInterval time.Duration) *jwksCache {
+	if refreshInterval <= 0 {
+		refreshInterval = DefaultJWKSRefreshInterval
+	}
+	cache := &jwksCache{
+		refreshInterval: refreshInterval,
+		client:          &http.Client{Timeout: jwksFetchTimeout},
+	}
+	if u, err := url.Parse(location); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		cache.url = location
+	} else if err == nil && u.Scheme == "file" {
+		cache.file = u.Host + u.Path
+	} else {
+		cache.file = location
+	}
+	return cache
+}
+
+// GetKey implements jwtauth.KeyProvider. When the keys cannot be read again,
+// the keys read before are used until they can.
+func (c *jwksCache) GetKey(kid string) ([]jose.JSONWebKey, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	due := c.keys == nil || now.Sub(c.fetched) >= c.refreshInterval || len(c.keys.Key(kid)) == 0
+	if due && (c.keys == nil || now.Sub(c.attempted) >= minJWKSRefreshInterval) {
+		c.attempted = now
+		keys, err := c.read()
+		c.err = err
+		if err != nil && c.keys == nil {
+			return nil, err
+		}
+		if err == nil {
+			c.keys = keys
+			c.fetched = now
+		}
+	}
+	return c.keys.Key(kid), nil
+}
+
+// ready reads the keys when none have been read yet, and returns an error
+// while the cache holds no keys.
+func (c *jwksCache) ready() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.keys != nil {
+		return nil
+	}
+	now := time.Now()
+	if now.Sub(c.attempted) < minJWKSRefreshInterval && c.err != nil {
+		return c.err
+	}
+	c.attempted = now
+	keys, err := c.read()
+	c.err = err
+	if err != nil {
+		return err
+	}
+	c.keys = keys
+	c.fetched = now
+	return nil
+}
+
+func (c *jwksCache) read() (*jose.JSONWebKeySet, error) {
+	var data []byte
+	var err error
+	if c.file != "" {
+		data, err = os.ReadFile(c.file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read JWKS file %s: %w", c.file, err)
+		}
+	} else {
+		data, err = c.fetch()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch JWKS from %s: %w", c.url, err)
+		}
+	}
+
+	keys := &jose.JSONWebKeySet{}
+	if err := json.Unmarshal(data, keys); err != nil {
+		return nil, fmt.Errorf("failed to parse JWKS: %w", err)
+	}
+	return keys, nil
+}
+
+func (c *jwksCache) fetch() ([]byte, error) {
+	response, err := c.client.Get(c.url)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	if response.StatusCode/100 != 2 {
+		return nil, errors.New(response.Status)
+	}
+	return io.ReadAll(response.Body)
+}
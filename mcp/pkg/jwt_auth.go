@@ -1,8 +1,6 @@
 package pkg
 
 import (
-	"errors"
-	"fmt"
 	"net/http"
 	"strings"
 	"time"
@@ -10,15 +8,12 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/dolthub/dolt/go/libraries/utils/jwtauth"
+	"gopkg.in/go-jose/go-jose.v2/jwt"
 )
 
-// withBearerAuth enforces Authorization: Bearer <token>
-func withBearerAuth(logger *zap.Logger, next http.Handler, jwkClaimsMap map[string]string, jwksUrl string) (http.Handler, error) {
-	pr, err := getJWTProvider(jwkClaimsMap, jwksUrl)
-	if err != nil {
-		return nil, fmt.Errorf("unable to get JWT provider: %w", err)
-	}
-
+// withJWTAuth enforces Authorization: Bearer <token>, validating tokens
+// against the issuers of authenticator.
+func withJWTAuth(logger *zap.Logger, next http.Handler, authenticator *jwtAuthenticator) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// auth will be "" if the "Authorization" header is not set
 		auth := r.Header.Get("Authorization")
@@ -38,54 +33,49 @@ func withBearerAuth(logger *zap.Logger, next http.Handler, jwkClaimsMap map[stri
 			}
 
 			if token == "" {
-				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				// The caller may already be identified by a client
+				// certificate.
+				if IdentityFromContext(r.Context()) != nil {
+					next.ServeHTTP(w, r)
+					return
+				}
+				observeAuthFailure(AuthMethodJWT, authFailureMissing)
+				writeBearerChallenge(w, r, "", "")
 				return
 			}
 		}
 
 		// validate token
-		valid, _, err := validateJWT(logger, pr, token, time.Now())
-		if err != nil || !valid {
-			logger.Info("unable to authorize jwt", zap.Bool("valid", valid), zap.Error(err))
-			http.Error(w, "unauthorized", http.StatusUnauthorized)
+		identity, err := authenticator.authenticate(token, time.Now())
+		if err != nil {
+			logger.Info("unable to authorize jwt", zap.Error(err))
+			observeAuthFailure(AuthMethodJWT, authFailureInvalid)
+			writeBearerChallenge(w, r, "invalid_token", "The access token is invalid or expired")
 			return
 		}
 
-		next.ServeHTTP(w, r)
-	}), nil
-}
+		logger.Info("MCP Auth with JWT", zap.Any("id", identity.Claims["jti"]), zap.String("iss", identity.Issuer), zap.String("sub", identity.Subject), zap.Any("on_behalf_of", identity.Claims["on_behalf_of"]))
 
-func validateJWT(logger *zap.Logger, pr jwtauth.JWTProvider, token string, reqTime time.Time) (bool, *jwtauth.Claims, error) {
-	vd, err := jwtauth.NewJWTValidator(pr)
-	if err != nil {
-		logger.Info("unable to create JWTValidator", zap.Error(err))
-		return false, nil, fmt.Errorf("unable to get JWT validator: %w", err)
-	}
+		next.ServeHTTP(w, r.WithContext(ContextWithIdentity(r.Context(), identity)))
+	})
+}
 
-	privClaims, err := vd.ValidateJWT(token, reqTime)
+// newJWTIdentity builds the identity of a validated token. jwtauth.Claims
+// only holds the standard claims, so the rest are read from the payload,
+// which has already been verified.
+func newJWTIdentity(token string, claims *jwtauth.Claims) (*Identity, error) {
+	parsed, err := jwt.ParseSigned(token)
 	if err != nil {
-		logger.Info("unable to validate jwt", zap.Error(err))
-		return false, nil, fmt.Errorf("unable to validate JWT token: %w", err)
+		return nil, err
 	}
-
-	logger.Info("MCP Auth with JWT", zap.String("id", privClaims.ID), zap.String("iss", privClaims.Issuer), zap.String("sub", privClaims.Subject), zap.String("on_behalf_of", privClaims.OnBehalfOf))
-
-	return true, privClaims, nil
-}
-
-func getJWTProvider(expectedClaimsMap map[string]string, url string) (jwtauth.JWTProvider, error) {
-	pr := jwtauth.JWTProvider{URL: url}
-	for name, claim := range expectedClaimsMap {
-		switch name {
-		case "iss":
-			pr.Issuer = claim
-		case "aud":
-			pr.Audience = claim
-		case "sub":
-			pr.Subject = claim
-		default:
-			return pr, errors.New("ValidateJWT: Unexpected expected claim found in user identity")
-		}
+	allClaims := map[string]any{}
+	if err := parsed.UnsafeClaimsWithoutVerification(&allClaims); err != nil {
+		return nil, err
 	}
-	return pr, nil
+	return &Identity{
+		Subject: claims.Subject,
+		Issuer:  claims.Issuer,
+		Token:   token,
+		Claims:  allClaims,
+	}, nil
 }
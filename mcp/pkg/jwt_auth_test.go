@@ -1,32 +1,34 @@
 package pkg
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"go.uber.org/zap"
+	"gopkg.in/go-jose/go-jose.v2"
+	"gopkg.in/go-jose/go-jose.v2/jwt"
 )
 
-// TestWithBearerAuth_InvalidToken_DoesNotCallNext demonstrates that when JWT
+// TestWithJWTAuth_InvalidToken_DoesNotCallNext demonstrates that when JWT
 // token validation fails, the auth middleware must respond 401 AND stop
 // processing the request. Prior to the fix, the middleware wrote the 401 but
 // forgot to return, so the wrapped handler ran anyway — a full auth bypass.
-func TestWithBearerAuth_InvalidToken_DoesNotCallNext(t *testing.T) {
+func TestWithJWTAuth_InvalidToken_DoesNotCallNext(t *testing.T) {
 	nextCalled := false
 	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		nextCalled = true
 		w.WriteHeader(http.StatusOK)
 	})
 
-	logger := zap.NewNop()
 	// The URL is never fetched: an unparseable token fails before any JWKS
 	// lookup, so this test is deterministic and makes no network calls.
 	claims := map[string]string{"iss": "test-issuer"}
-	handler, err := withBearerAuth(logger, next, claims, "http://127.0.0.1:1/jwks")
-	if err != nil {
-		t.Fatalf("unexpected error building bearer auth handler: %v", err)
-	}
+	handler := newTestJWTAuth(t, next, claims, "http://127.0.0.1:1/jwks")
 
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/mcp", nil)
@@ -42,21 +44,17 @@ func TestWithBearerAuth_InvalidToken_DoesNotCallNext(t *testing.T) {
 	}
 }
 
-// TestWithBearerAuth_MissingToken_DoesNotCallNext is a sanity check that the
+// TestWithJWTAuth_MissingToken_DoesNotCallNext is a sanity check that the
 // no-token path (which already returned correctly) still rejects the request.
-func TestWithBearerAuth_MissingToken_DoesNotCallNext(t *testing.T) {
+func TestWithJWTAuth_MissingToken_DoesNotCallNext(t *testing.T) {
 	nextCalled := false
 	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		nextCalled = true
 		w.WriteHeader(http.StatusOK)
 	})
 
-	logger := zap.NewNop()
 	claims := map[string]string{"iss": "test-issuer"}
-	handler, err := withBearerAuth(logger, next, claims, "http://127.0.0.1:1/jwks")
-	if err != nil {
-		t.Fatalf("unexpected error building bearer auth handler: %v", err)
-	}
+	handler := newTestJWTAuth(t, next, claims, "http://127.0.0.1:1/jwks")
 
 	rec := httptest.NewRecorder()
 	req := httptest.NewRequest(http.MethodGet, "/mcp", nil)
@@ -70,3 +68,76 @@ func TestWithBearerAuth_MissingToken_DoesNotCallNext(t *testing.T) {
 		t.Fatalf("wrapped handler was called despite missing authentication")
 	}
 }
+
+// newTestJWTAuth returns next behind withJWTAuth, validating tokens against
+// the issuer given by jwkClaimsMap and jwksUrl.
+func newTestJWTAuth(t *testing.T, next http.Handler, jwkClaimsMap map[string]string, jwksUrl string) http.Handler {
+	t.Helper()
+	authenticator, err := newJWTAuthenticator(jwkClaimsMap, jwksUrl, nil)
+	if err != nil {
+		t.Fatalf("unexpected error building jwt authenticator: %v", err)
+	}
+	return withJWTAuth(zap.NewNop(), next, authenticator)
+}
+
+func newTestJWKS(t *testing.T) (*rsa.PrivateKey, *httptest.Server) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	jwks := jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "test-key", Algorithm: "RS256", Use: "sig"}}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jwks)
+	}))
+	t.Cleanup(srv.Close)
+	return key, srv
+}
+
+func signTestJWT(t *testing.T, key *rsa.PrivateKey, claims map[string]any) string {
+	t.Helper()
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, (&jose.SignerOptions{}).WithHeader("kid", "test-key"))
+	if err != nil {
+		t.Fatalf("failed to create signer: %v", err)
+	}
+	token, err := jwt.Signed(signer).Claims(claims).CompactSerialize()
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return token
+}
+
+func TestWithJWTAuth_PutsIdentityOnContext(t *testing.T) {
+	key, jwks := newTestJWKS(t)
+
+	var identity *Identity
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		identity = IdentityFromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+
+	handler := newTestJWTAuth(t, next, map[string]string{"iss": "test-issuer", "aud": "test-audience"}, jwks.URL)
+
+	token := signTestJWT(t, key, map[string]any{
+		"iss":   "test-issuer",
+		"aud":   "test-audience",
+		"sub":   "alice",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{"analyst"},
+	})
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/mcp", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if identity == nil || identity.Subject != "alice" || identity.Issuer != "test-issuer" {
+		t.Fatalf("unexpected identity: %+v", identity)
+	}
+	if roles := identity.ClaimValues("roles"); len(roles) != 1 || roles[0] != "analyst" {
+		t.Fatalf("unexpected roles: %v", roles)
+	}
+}
@@ -0,0 +1,263 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/dolthub/dolt/go/libraries/utils/jwtauth"
+	"gopkg.in/go-jose/go-jose.v2/jwt"
+)
+
+var ErrUntrustedIssuer = errors.New("token issuer is not trusted")
+
+// ScopeClaims are the claims holding the OAuth scopes of a token. scope is a
+// space separated string and scp, used by some identity providers, is an
+// array.
+var ScopeClaims = []string{"scope", "scp"}
+
+// JWTConfig configures JWT authentication for HTTP callers beyond what the
+// --jwk-url and --jwk-claims flags can express.
+type JWTConfig struct {
+	// Issuers are the trusted token issuers. A token is validated against
+	// the issuer named by its iss claim.
+	Issuers []JWTIssuer `yaml:"issuers" json:"issuers"`
+	// Scopes maps an OAuth scope to the tools it allows, as globs. When it
+	// is set, callers may only call the tools allowed by the scopes in the
+	// scope or scp claim of their token.
+	Scopes map[string][]string `yaml:"scopes" json:"scopes"`
+}
+
+// JWTIssuer is a trusted token issuer.
+type JWTIssuer struct {
+	// Issuer is the iss claim of the tokens. It is also advertised as an
+	// authorization server in the OAuth protected resource metadata.
+	Issuer string `yaml:"issuer" json:"issuer"`
+	// JWKSURL is where the issuer's signing keys are read from. file://
+	// URLs are read from the local file system.
+	JWKSURL string `yaml:"jwks_url" json:"jwks_url"`
+	// JWKSFile is a local file holding the issuer's signing keys, for
+	// deployments that cannot reach the issuer.
+	JWKSFile string `yaml:"jwks_file" json:"jwks_file"`
+	// RefreshInterval is how often the keys are read again. Defaults to
+	// five minutes.
+	RefreshInterval time.Duration `yaml:"refresh_interval" json:"refresh_interval"`
+	// Audiences lists the accepted aud claims. A token must have one of
+	// them. An empty list accepts any audience.
+	Audiences []string `yaml:"audiences" json:"audiences"`
+	// Subject, when set, is the only sub claim accepted.
+	Subject string `yaml:"subject" json:"subject"`
+	// Claims are other claims a token must have. A claim holding a list
+	// must contain the value.
+	Claims map[string]string `yaml:"claims" json:"claims"`
+}
+
+func (c *JWTConfig) Validate() error {
+	issuers := map[string]bool{}
+	for _, issuer := range c.Issuers {
+		if issuer.Issuer == "" {
+			return errors.New("jwt issuer has no issuer")
+		}
+		if issuers[issuer.Issuer] {
+			return fmt.Errorf("duplicate jwt issuer %s", issuer.Issuer)
+		}
+		issuers[issuer.Issuer] = true
+		if (issuer.JWKSURL == "") == (issuer.JWKSFile == "") {
+			return fmt.Errorf("jwt issuer %s must set exactly one of jwks_url and jwks_file", issuer.Issuer)
+		}
+		if issuer.RefreshInterval < 0 {
+			return fmt.Errorf("jwt issuer %s has a negative refresh_interval", issuer.Issuer)
+		}
+	}
+	for scope, tools := range c.Scopes {
+		for _, tool := range tools {
+			if _, err := path.Match(tool, ""); err != nil {
+				return fmt.Errorf("invalid tool pattern %q for scope %s: %w", tool, scope, err)
+			}
+		}
+	}
+	return nil
+}
+
+// jwtIssuerValidator validates the tokens of one issuer.
+type jwtIssuerValidator struct {
+	issuer JWTIssuer
+	keys   *jwksCache
+}
+
+// jwtAuthenticator validates tokens from any of its issuers and builds the
+// identity of their callers.
+type jwtAuthenticator struct {
+	issuers []*jwtIssuerValidator
+	scopes  map[string][]string
+}
+
+// newJWTAuthenticator returns an authenticator for the issuer given by the
+// --jwk-url and --jwk-claims flags, if any, and the issuers of config, which
+// may be nil. It returns nil when there are no issuers.
+func newJWTAuthenticator(jwkClaimsMap map[string]string, jwksUrl string, config *JWTConfig) (*jwtAuthenticator, error) {
+	authenticator := &jwtAuthenticator{}
+	if jwkClaimsMap != nil || jwksUrl != "" {
+		issuer, err := newJWTIssuerFromClaims(jwkClaimsMap, jwksUrl)
+		if err != nil {
+			return nil, err
+		}
+		authenticator.addIssuer(issuer)
+	}
+	if config != nil {
+		for _, issuer := range config.Issuers {
+			authenticator.addIssuer(issuer)
+		}
+		authenticator.scopes = config.Scopes
+	}
+	if len(authenticator.issuers) == 0 {
+		return nil, nil
+	}
+	return authenticator, nil
+}
+
+// newJWTIssuerFromClaims returns the issuer described by the --jwk-url and
+// --jwk-claims flags. iss, aud and sub are checked like the fields of
+// JWTIssuer, and any other claim must have the given value.
+func newJWTIssuerFromClaims(expectedClaimsMap map[string]string, url string) (JWTIssuer, error) {
+	if expectedClaimsMap == nil || url == "" {
+		return JWTIssuer{}, errors.New("if a JWK URL or claims are provided, both must be provided for bearer token authentication")
+	}
+	issuer := JWTIssuer{JWKSURL: url}
+	for name, claim := range expectedClaimsMap {
+		switch name {
+		case "iss":
+			issuer.Issuer = claim
+		case "aud":
+			issuer.Audiences = []string{claim}
+		case "sub":
+			issuer.Subject = claim
+		default:
+			if issuer.Claims == nil {
+				issuer.Claims = map[string]string{}
+			}
+			issuer.Claims[name] = claim
+		}
+	}
+	return issuer, nil
+}
+
+func (a *jwtAuthenticator) addIssuer(issuer JWTIssuer) {
+	location := issuer.JWKSURL
+	if location == "" {
+		location = issuer.JWKSFile
+	}
+	a.issuers = append(a.issuers, &jwtIssuerValidator{issuer: issuer, keys: newJWKSCache(location, issuer.RefreshInterval)})
+}
+
+// authorizationServers returns the issuers advertised in the OAuth protected
+// resource metadata.
+func (a *jwtAuthenticator) authorizationServers() []string {
+	var servers []string
+	for _, issuer := range a.issuers {
+		if issuer.issuer.Issuer != "" && !slices.Contains(servers, issuer.issuer.Issuer) {
+			servers = append(servers, issuer.issuer.Issuer)
+		}
+	}
+	return servers
+}
+
+// resource returns the resource advertised in the OAuth protected resource
+// metadata: the first accepted audience, or "" when any is accepted.
+func (a *jwtAuthenticator) resource() string {
+	for _, issuer := range a.issuers {
+		if len(issuer.issuer.Audiences) > 0 {
+			return issuer.issuer.Audiences[0]
+		}
+	}
+	return ""
+}
+
+// scopesSupported returns the scopes advertised in the OAuth protected
+// resource metadata.
+func (a *jwtAuthenticator) scopesSupported() []string {
+	var scopes []string
+	for scope := range a.scopes {
+		scopes = append(scopes, scope)
+	}
+	sort.Strings(scopes)
+	return scopes
+}
+
+// authenticate validates token and returns the identity of its caller.
+func (a *jwtAuthenticator) authenticate(token string, now time.Time) (*Identity, error) {
+	parsed, err := jwt.ParseSigned(token)
+	if err != nil {
+		return nil, err
+	}
+	unverified := jwt.Claims{}
+	if err := parsed.UnsafeClaimsWithoutVerification(&unverified); err != nil {
+		return nil, err
+	}
+
+	// The unverified issuer only picks the keys to verify the token with;
+	// the issuer is verified along with the other claims.
+	validationErr := fmt.Errorf("%w: %q", ErrUntrustedIssuer, unverified.Issuer)
+	for _, validator := range a.issuers {
+		if validator.issuer.Issuer != "" && validator.issuer.Issuer != unverified.Issuer {
+			continue
+		}
+		var identity *Identity
+		identity, validationErr = validator.authenticate(token, now)
+		if validationErr == nil {
+			a.applyScopes(identity)
+			return identity, nil
+		}
+	}
+	return nil, validationErr
+}
+
+func (v *jwtIssuerValidator) authenticate(token string, now time.Time) (*Identity, error) {
+	expected := jwt.Expected{Issuer: v.issuer.Issuer, Subject: v.issuer.Subject}
+	claims, err := jwtauth.ValidateJWT(token, now, v.keys, expected)
+	if err != nil {
+		return nil, err
+	}
+	if len(v.issuer.Audiences) > 0 && !slices.ContainsFunc(v.issuer.Audiences, claims.Audience.Contains) {
+		return nil, fmt.Errorf("%w: token audience %v is not one of %v", jwt.ErrInvalidAudience, []string(claims.Audience), v.issuer.Audiences)
+	}
+
+	identity, err := newJWTIdentity(token, claims)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range v.issuer.Claims {
+		if !identity.HasClaimValue(name, value) {
+			return nil, fmt.Errorf("token claim %s does not have the expected value %q", name, value)
+		}
+	}
+	return identity, nil
+}
+
+// applyScopes limits identity to the tools allowed by the scopes of its
+// token, when scopes are mapped to tools.
+func (a *jwtAuthenticator) applyScopes(identity *Identity) {
+	if a.scopes == nil {
+		return
+	}
+	tools := []string{}
+	for _, claim := range ScopeClaims {
+		for _, scope := range identity.ClaimValues(claim) {
+			tools = append(tools, a.scopes[scope]...)
+		}
+	}
+	identity.Scopes = tools
+}
+
+// ready returns an error until the keys of every issuer have been read.
+func (a *jwtAuthenticator) ready() error {
+	for _, validator := range a.issuers {
+		if err := validator.keys.ready(); err != nil {
+			return fmt.Errorf("keys of issuer %s: %w", validator.issuer.Issuer, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gopkg.in/go-jose/go-jose.v2"
+)
+
+func writeTestJWKSFile(t *testing.T) (*rsa.PrivateKey, string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	data, err := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &key.PublicKey, KeyID: "test-key", Algorithm: "RS256", Use: "sig"}}})
+	if err != nil {
+		t.Fatalf("failed to encode JWKS: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatalf("failed to write JWKS file: %v", err)
+	}
+	return key, path
+}
+
+func testTokenClaims(issuer string, claims map[string]any) map[string]any {
+	all := map[string]any{"iss": issuer, "sub": "alice", "exp": time.Now().Add(time.Hour).Unix()}
+	for name, value := range claims {
+		all[name] = value
+	}
+	return all
+}
+
+func TestJWTAuthenticator_MultipleIssuers(t *testing.T) {
+	remoteKey, jwks := newTestJWKS(t)
+	fileKey, jwksFile := writeTestJWKSFile(t)
+
+	config := &JWTConfig{
+		Issuers: []JWTIssuer{
+			{Issuer: "https://remote.example.com", JWKSURL: jwks.URL, Audiences: []string{"dolt-mcp", "https://mcp.example.com/mcp"}},
+			{Issuer: "https://local.example.com", JWKSFile: jwksFile, Claims: map[string]string{"team": "data"}},
+		},
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("unexpected validation error: %v", err)
+	}
+	authenticator, err := newJWTAuthenticator(nil, "", config)
+	if err != nil {
+		t.Fatalf("unexpected error building authenticator: %v", err)
+	}
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		token string
+		valid bool
+	}{
+		{"remote issuer, second audience", signTestJWT(t, remoteKey, testTokenClaims("https://remote.example.com", map[string]any{"aud": "https://mcp.example.com/mcp"})), true},
+		{"remote issuer, wrong audience", signTestJWT(t, remoteKey, testTokenClaims("https://remote.example.com", map[string]any{"aud": "other"})), false},
+		{"file issuer with required claim", signTestJWT(t, fileKey, testTokenClaims("https://local.example.com", map[string]any{"team": "data"})), true},
+		{"file issuer without required claim", signTestJWT(t, fileKey, testTokenClaims("https://local.example.com", map[string]any{"team": "web"})), false},
+		{"key of another issuer", signTestJWT(t, remoteKey, testTokenClaims("https://local.example.com", map[string]any{"team": "data"})), false},
+		{"untrusted issuer", signTestJWT(t, remoteKey, testTokenClaims("https://evil.example.com", nil)), false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			identity, err := authenticator.authenticate(test.token, now)
+			if test.valid && (err != nil || identity.Subject != "alice") {
+				t.Fatalf("expected a valid token, got identity=%+v err=%v", identity, err)
+			}
+			if !test.valid && err == nil {
+				t.Fatalf("expected an invalid token, got identity=%+v", identity)
+			}
+		})
+	}
+
+	if _, err := authenticator.authenticate(signTestJWT(t, remoteKey, testTokenClaims("https://evil.example.com", nil)), now); !errors.Is(err, ErrUntrustedIssuer) {
+		t.Fatalf("expected ErrUntrustedIssuer, got %v", err)
+	}
+	if servers := authenticator.authorizationServers(); len(servers) != 2 {
+		t.Fatalf("expected both issuers to be advertised, got %v", servers)
+	}
+}
+
+func TestJWTAuthenticator_Scopes(t *testing.T) {
+	key, jwksFile := writeTestJWKSFile(t)
+	authenticator, err := newJWTAuthenticator(nil, "", &JWTConfig{
+		Issuers: []JWTIssuer{{Issuer: "https://auth.example.com", JWKSFile: jwksFile}},
+		Scopes: map[string][]string{
+			"dolt:read":  {"query", "list_*"},
+			"dolt:write": {"exec"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error building authenticator: %v", err)
+	}
+
+	identity, err := authenticator.authenticate(signTestJWT(t, key, testTokenClaims("https://auth.example.com", map[string]any{"scope": "openid dolt:read"})), time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !identity.AllowsTool("list_dolt_branches") || identity.AllowsTool("exec") {
+		t.Fatalf("expected dolt:read tools only, got %v", identity.Scopes)
+	}
+
+	identity, err = authenticator.authenticate(signTestJWT(t, key, testTokenClaims("https://auth.example.com", map[string]any{"scp": []string{"dolt:write"}})), time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !identity.AllowsTool("exec") || identity.AllowsTool("query") {
+		t.Fatalf("expected dolt:write tools only, got %v", identity.Scopes)
+	}
+
+	identity, err = authenticator.authenticate(signTestJWT(t, key, testTokenClaims("https://auth.example.com", nil)), time.Now())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if identity.AllowsTool("query") {
+		t.Fatalf("expected a token without scopes to allow no tools, got %v", identity.Scopes)
+	}
+}
+
+func TestJWKSCache_RefreshesForUnknownKeys(t *testing.T) {
+	_, jwksFile := writeTestJWKSFile(t)
+	cache := newJWKSCache("file://"+jwksFile, time.Hour)
+
+	keys, err := cache.GetKey("test-key")
+	if err != nil || len(keys) != 1 {
+		t.Fatalf("expected the key from the file, got %v err=%v", keys, err)
+	}
+
+	rotated, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	data, _ := json.Marshal(jose.JSONWebKeySet{Keys: []jose.JSONWebKey{{Key: &rotated.PublicKey, KeyID: "rotated-key", Algorithm: "RS256", Use: "sig"}}})
+	if err := os.WriteFile(jwksFile, data, 0o600); err != nil {
+		t.Fatalf("failed to write JWKS file: %v", err)
+	}
+
+	if keys, _ := cache.GetKey("rotated-key"); len(keys) != 0 {
+		t.Fatalf("expected unknown keys not to be read again right away, got %v", keys)
+	}
+	cache.attempted = time.Time{}
+	if keys, _ := cache.GetKey("rotated-key"); len(keys) != 1 {
+		t.Fatalf("expected the rotated key to be read, got %v", keys)
+	}
+
+	if err := os.Remove(jwksFile); err != nil {
+		t.Fatalf("failed to remove JWKS file: %v", err)
+	}
+	cache.attempted = time.Time{}
+	if keys, err := cache.GetKey("rotated-key"); err != nil || len(keys) != 1 {
+		t.Fatalf("expected the keys read before to be kept, got %v err=%v", keys, err)
+	}
+}
+
+func TestJWTConfigValidate(t *testing.T) {
+	invalid := []*JWTConfig{
+		{Issuers: []JWTIssuer{{JWKSURL: "https://auth.example.com/jwks"}}},
+		{Issuers: []JWTIssuer{{Issuer: "https://auth.example.com"}}},
+		{Issuers: []JWTIssuer{{Issuer: "https://auth.example.com", JWKSURL: "https://auth.example.com/jwks", JWKSFile: "jwks.json"}}},
+		{Issuers: []JWTIssuer{{Issuer: "a", JWKSFile: "a.json"}, {Issuer: "a", JWKSFile: "b.json"}}},
+		{Scopes: map[string][]string{"dolt:read": {"[oops"}}},
+	}
+	for _, config := range invalid {
+		if err := config.Validate(); err == nil {
+			t.Fatalf("expected an error for %+v", config)
+		}
+	}
+}
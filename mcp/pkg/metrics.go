@@ -0,0 +1,145 @@
+package pkg
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsPath is where metrics are served in the Prometheus formats.
+const MetricsPath = "/metrics"
+
+const (
+	ToolCallOutcomeSuccess = "success"
+	ToolCallOutcomeError   = "error"
+
+	AuthMethodJWT    = "jwt"
+	AuthMethodAPIKey = "api_key"
+
+	authFailureMissing = "missing"
+	authFailureInvalid = "invalid"
+)
+
+var (
+	latencyBuckets = []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+	sizeBuckets    = []float64{128, 1024, 8192, 65536, 262144, 1048576, 4194304, 16777216}
+)
+
+// The server's metrics. They are collected whether or not they are served.
+var (
+	toolCallsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dolt_mcp_tool_calls_total",
+		Help: "Tool calls by tool and outcome.",
+	}, []string{"tool", "outcome"})
+	toolCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "dolt_mcp_tool_call_duration_seconds",
+		Help:    "Tool call latency by tool and outcome.",
+		Buckets: latencyBuckets,
+	}, []string{"tool", "outcome"})
+	sqlStatementDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "dolt_mcp_sql_statement_duration_seconds",
+		Help:    "Time spent running SQL statements, by tool and outcome.",
+		Buckets: latencyBuckets,
+	}, []string{"tool", "outcome"})
+	rowsReturnedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dolt_mcp_rows_returned_total",
+		Help: "Rows returned by SQL queries, by tool.",
+	}, []string{"tool"})
+	rowsAffectedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dolt_mcp_rows_affected_total",
+		Help: "Rows changed by SQL statements, by tool.",
+	}, []string{"tool"})
+	resultBytes = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "dolt_mcp_tool_result_bytes",
+		Help:    "Size of tool results, by tool.",
+		Buckets: sizeBuckets,
+	}, []string{"tool"})
+	authFailuresTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "dolt_mcp_auth_failures_total",
+		Help: "Rejected HTTP requests, by authentication method and reason.",
+	}, []string{"method", "reason"})
+)
+
+var dbConnectionsDesc = prometheus.NewDesc("dolt_mcp_db_connections",
+	"Open database connections by state.", []string{"state"}, nil)
+
+// metricsRegistry holds the server's metrics, without the default
+// registry's process and runtime metrics.
+var metricsRegistry = func() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		toolCallsTotal,
+		toolCallDuration,
+		sqlStatementDuration,
+		rowsReturnedTotal,
+		rowsAffectedTotal,
+		resultBytes,
+		authFailuresTotal,
+		dbConnectionsCollector{},
+	)
+	return registry
+}()
+
+// dbConnectionsCollector reads the connection stats of the database pools
+// when metrics are served.
+type dbConnectionsCollector struct{}
+
+func (dbConnectionsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- dbConnectionsDesc
+}
+
+func (dbConnectionsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := db.CurrentConnectionStats()
+	ch <- prometheus.MustNewConstMetric(dbConnectionsDesc, prometheus.GaugeValue, float64(stats.Open), "open")
+	ch <- prometheus.MustNewConstMetric(dbConnectionsDesc, prometheus.GaugeValue, float64(stats.InUse), "in_use")
+	ch <- prometheus.MustNewConstMetric(dbConnectionsDesc, prometheus.GaugeValue, float64(stats.Idle), "idle")
+}
+
+// ObserveToolCall records the metrics of a tool call that ran statements and
+// returned a result of resultSize bytes.
+func ObserveToolCall(tool string, failed bool, duration time.Duration, statements []db.StatementRecord, resultSize int) {
+	outcome := ToolCallOutcomeSuccess
+	if failed {
+		outcome = ToolCallOutcomeError
+	}
+	toolCallsTotal.WithLabelValues(tool, outcome).Inc()
+	toolCallDuration.WithLabelValues(tool, outcome).Observe(duration.Seconds())
+	resultBytes.WithLabelValues(tool).Observe(float64(resultSize))
+
+	for _, statement := range statements {
+		statementOutcome := ToolCallOutcomeSuccess
+		if statement.Error != "" {
+			statementOutcome = ToolCallOutcomeError
+		}
+		sqlStatementDuration.WithLabelValues(tool, statementOutcome).Observe(statement.Duration.Seconds())
+		if statement.RowsReturned != nil {
+			rowsReturnedTotal.WithLabelValues(tool).Add(float64(*statement.RowsReturned))
+		}
+		if statement.RowsAffected != nil {
+			rowsAffectedTotal.WithLabelValues(tool).Add(float64(*statement.RowsAffected))
+		}
+	}
+}
+
+func observeAuthFailure(method, reason string) {
+	authFailuresTotal.WithLabelValues(method, reason).Inc()
+}
+
+// MetricsHandler serves the server's metrics in the Prometheus exposition
+// formats.
+func MetricsHandler() http.Handler {
+	return promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{})
+}
+
+// withMetricsEndpoint serves metrics at MetricsPath, and every other path
+// with next. It is wrapped in the server's authentication, so that scrapers
+// need the credentials of any other caller.
+func withMetricsEndpoint(next http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle(MetricsPath, MetricsHandler())
+	mux.Handle("/", next)
+	return mux
+}
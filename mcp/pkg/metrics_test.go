@@ -0,0 +1,76 @@
+package pkg
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"go.uber.org/zap"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	rows := 3
+	ObserveToolCall("metrics_test_query", false, 20*time.Millisecond, []db.StatementRecord{{SQL: "SELECT 1", RowsReturned: &rows, Duration: 2 * time.Millisecond}}, 512)
+	ObserveToolCall("metrics_test_query", true, 2*time.Second, nil, 10)
+	observeAuthFailure(AuthMethodAPIKey, authFailureInvalid)
+
+	rec := httptest.NewRecorder()
+	withMetricsEndpoint(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected status %d, got %d", http.StatusOK, rec.Code)
+	}
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Fatalf("unexpected content type %q", rec.Header().Get("Content-Type"))
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE dolt_mcp_tool_calls_total counter",
+		`dolt_mcp_tool_calls_total{outcome="success",tool="metrics_test_query"} 1`,
+		`dolt_mcp_tool_calls_total{outcome="error",tool="metrics_test_query"} 1`,
+		`dolt_mcp_tool_call_duration_seconds_bucket{outcome="success",tool="metrics_test_query",le="0.025"} 1`,
+		`dolt_mcp_tool_call_duration_seconds_bucket{outcome="error",tool="metrics_test_query",le="1"} 0`,
+		`dolt_mcp_tool_call_duration_seconds_bucket{outcome="error",tool="metrics_test_query",le="+Inf"} 1`,
+		`dolt_mcp_sql_statement_duration_seconds_count{outcome="success",tool="metrics_test_query"} 1`,
+		`dolt_mcp_rows_returned_total{tool="metrics_test_query"} 3`,
+		`dolt_mcp_tool_result_bytes_sum{tool="metrics_test_query"} 522`,
+		`dolt_mcp_auth_failures_total{method="api_key",reason="invalid"}`,
+		`dolt_mcp_db_connections{state="open"} 0`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expected %q in metrics:\n%s", want, body)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	withMetricsEndpoint(http.NotFoundHandler()).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/mcp", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected other paths to reach the wrapped handler, got %d", rec.Code)
+	}
+}
+
+func TestMetricsEndpointRequiresAuthentication(t *testing.T) {
+	config := db.Config{Host: "127.0.0.1", Port: 1, User: "root", DatabaseName: "mydb", DialectType: db.DialectMySQL}
+	keys := &APIKeys{Keys: []APIKey{{Name: "prometheus", Hash: HashAPIKey("scrape-secret")}}}
+	srv, err := NewMCPHTTPServer(zap.NewNop(), config, 0, nil, "", nil, WithAPIKeys(keys), WithMetricsEndpoint())
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+	handler := srv.(*httpServerImpl).handler
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, MetricsPath, nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected metrics to require an API key, got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, MetricsPath, nil)
+	req.Header.Set("X-API-Key", "scrape-secret")
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "dolt_mcp_db_connections") {
+		t.Fatalf("expected metrics with an API key, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,101 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ProtectedResourceMetadataPath is where the OAuth 2.0 protected resource
+// metadata of RFC 9728 is served. MCP clients read it to discover the
+// authorization server that issues tokens for this server.
+const ProtectedResourceMetadataPath = "/.well-known/oauth-protected-resource"
+
+// mcpEndpointPath is the path clients send MCP requests to.
+const mcpEndpointPath = "/mcp"
+
+// protectedResourceMetadata is the metadata document of RFC 9728.
+type protectedResourceMetadata struct {
+	Resource               string   `json:"resource"`
+	AuthorizationServers   []string `json:"authorization_servers,omitempty"`
+	ScopesSupported        []string `json:"scopes_supported,omitempty"`
+	BearerMethodsSupported []string `json:"bearer_methods_supported"`
+	ResourceName           string   `json:"resource_name,omitempty"`
+}
+
+// newProtectedResourceMetadataHandler serves the metadata document. The
+// resource is the audience tokens must be issued for, or the URL of the MCP
+// endpoint when no audience is required, and the authorization servers are
+// the issuers tokens may come from.
+func newProtectedResourceMetadataHandler(authenticator *jwtAuthenticator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		metadata := protectedResourceMetadata{
+			Resource:               authenticator.resource(),
+			AuthorizationServers:   authenticator.authorizationServers(),
+			ScopesSupported:        authenticator.scopesSupported(),
+			BearerMethodsSupported: []string{"header"},
+			ResourceName:           DoltMCPServerName,
+		}
+		if metadata.Resource == "" {
+			metadata.Resource = requestBaseURL(r) + mcpEndpointPath
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		// Browser based clients fetch the document from another origin.
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		_ = json.NewEncoder(w).Encode(metadata)
+	})
+}
+
+// withProtectedResourceMetadata serves the metadata document next to
+// handler, without authentication. The document is also served under the
+// path of the MCP endpoint, where RFC 9728 clients look for it first.
+func withProtectedResourceMetadata(handler http.Handler, authenticator *jwtAuthenticator) http.Handler {
+	metadata := newProtectedResourceMetadataHandler(authenticator)
+	mux := http.NewServeMux()
+	mux.Handle(ProtectedResourceMetadataPath, metadata)
+	mux.Handle(ProtectedResourceMetadataPath+mcpEndpointPath, metadata)
+	mux.Handle("/", handler)
+	return mux
+}
+
+// writeBearerChallenge responds 401 with a WWW-Authenticate challenge
+// pointing clients at the metadata document, as the MCP authorization spec
+// requires. errorCode is an RFC 6750 error code, or "" when the request had
+// no token.
+func writeBearerChallenge(w http.ResponseWriter, r *http.Request, errorCode, errorDescription string) {
+	params := []string{}
+	if errorCode != "" {
+		params = append(params, fmt.Sprintf("error=%q", errorCode))
+		if errorDescription != "" {
+			params = append(params, fmt.Sprintf("error_description=%q", errorDescription))
+		}
+	}
+	params = append(params, fmt.Sprintf("resource_metadata=%q", requestBaseURL(r)+ProtectedResourceMetadataPath))
+	w.Header().Set("WWW-Authenticate", "Bearer "+strings.Join(params, ", "))
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+}
+
+// requestBaseURL returns the scheme and host clients reach the server at,
+// honoring the headers set by TLS terminating proxies.
+func requestBaseURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		scheme = proto
+	}
+	host := r.Host
+	if forwardedHost := r.Header.Get("X-Forwarded-Host"); forwardedHost != "" {
+		host = strings.TrimSpace(strings.Split(forwardedHost, ",")[0])
+	}
+	return scheme + "://" + host
+}
@@ -0,0 +1,81 @@
+package pkg
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProtectedResourceMetadata(t *testing.T) {
+	nextCalled := false
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		nextCalled = true
+	})
+	claims := map[string]string{"iss": "https://auth.example.com", "aud": "https://mcp.example.com/mcp"}
+	handler := newTestJWTAuth(t, next, claims, "http://127.0.0.1:1/jwks")
+	authenticator, err := newJWTAuthenticator(claims, "http://127.0.0.1:1/jwks", nil)
+	if err != nil {
+		t.Fatalf("unexpected error building authenticator: %v", err)
+	}
+	handler = withProtectedResourceMetadata(handler, authenticator)
+
+	for _, path := range []string{ProtectedResourceMetadataPath, ProtectedResourceMetadataPath + "/mcp"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected status %d for %s without a token, got %d", http.StatusOK, path, rec.Code)
+		}
+		metadata := protectedResourceMetadata{}
+		if err := json.Unmarshal(rec.Body.Bytes(), &metadata); err != nil {
+			t.Fatalf("failed to decode metadata: %v", err)
+		}
+		if metadata.Resource != "https://mcp.example.com/mcp" || len(metadata.AuthorizationServers) != 1 || metadata.AuthorizationServers[0] != "https://auth.example.com" {
+			t.Fatalf("unexpected metadata: %+v", metadata)
+		}
+	}
+	if nextCalled {
+		t.Fatalf("expected the metadata document to be served without calling the MCP handler")
+	}
+}
+
+func TestProtectedResourceMetadata_ResourceFromRequest(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "http://internal:8080"+ProtectedResourceMetadataPath, nil)
+	req.Header.Set("X-Forwarded-Proto", "https")
+	req.Header.Set("X-Forwarded-Host", "mcp.example.com")
+	authenticator, err := newJWTAuthenticator(map[string]string{"iss": "https://auth.example.com"}, "http://127.0.0.1:1/jwks", nil)
+	if err != nil {
+		t.Fatalf("unexpected error building authenticator: %v", err)
+	}
+	newProtectedResourceMetadataHandler(authenticator).ServeHTTP(rec, req)
+
+	metadata := protectedResourceMetadata{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &metadata); err != nil {
+		t.Fatalf("failed to decode metadata: %v", err)
+	}
+	if metadata.Resource != "https://mcp.example.com/mcp" {
+		t.Fatalf("unexpected resource %q", metadata.Resource)
+	}
+}
+
+func TestWithJWTAuth_Challenges(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	handler := newTestJWTAuth(t, next, map[string]string{"iss": "test-issuer"}, "http://127.0.0.1:1/jwks")
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "http://mcp.example.com/mcp", nil))
+	expected := `Bearer resource_metadata="http://mcp.example.com/.well-known/oauth-protected-resource"`
+	if challenge := rec.Header().Get("WWW-Authenticate"); challenge != expected {
+		t.Fatalf("expected challenge %q, got %q", expected, challenge)
+	}
+
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "http://mcp.example.com/mcp", nil)
+	req.Header.Set("Authorization", "Bearer not-a-valid-jwt")
+	handler.ServeHTTP(rec, req)
+	if challenge := rec.Header().Get("WWW-Authenticate"); !strings.HasPrefix(challenge, `Bearer error="invalid_token"`) || !strings.Contains(challenge, "resource_metadata=") {
+		t.Fatalf("unexpected challenge %q", challenge)
+	}
+}
@@ -0,0 +1,91 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// DefaultApplyTokenTTL is how long a planned write stays available for
+// confirmation when no TTL is configured.
+const DefaultApplyTokenTTL = 10 * time.Minute
+
+// PendingWrite identifies a planned write that is waiting for confirmation.
+// An apply token only applies the exact write it was issued for, on the
+// same backend and for the same caller.
+type PendingWrite struct {
+	Tool      string
+	Database  string
+	Branch    string
+	Statement string
+	// Backend is the name of the backend the write runs on, or "" for the
+	// server's own database.
+	Backend string
+	// Issuer and Subject identify the caller the write was planned for, and
+	// are empty for unauthenticated callers.
+	Issuer  string
+	Subject string
+}
+
+type pendingWrite struct {
+	write   PendingWrite
+	expires time.Time
+}
+
+// PendingWrites holds planned writes keyed by single-use apply tokens.
+type PendingWrites struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	writes map[string]pendingWrite
+	now    func() time.Time
+}
+
+func NewPendingWrites(ttl time.Duration) *PendingWrites {
+	if ttl <= 0 {
+		ttl = DefaultApplyTokenTTL
+	}
+	return &PendingWrites{
+		ttl:    ttl,
+		writes: make(map[string]pendingWrite),
+		now:    time.Now,
+	}
+}
+
+// Add records w and returns the apply token that confirms it.
+func (p *PendingWrites) Add(w PendingWrite) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(buf)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.expireLocked()
+	p.writes[token] = pendingWrite{write: w, expires: p.now().Add(p.ttl)}
+	return token, nil
+}
+
+// Take consumes token and reports whether it was issued for w and has not
+// expired. A token is removed on first use, even when it does not match.
+func (p *PendingWrites) Take(token string, w PendingWrite) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.expireLocked()
+	pw, ok := p.writes[token]
+	if !ok {
+		return false
+	}
+	delete(p.writes, token)
+	return pw.write == w
+}
+
+func (p *PendingWrites) expireLocked() {
+	now := p.now()
+	for token, pw := range p.writes {
+		if now.After(pw.expires) {
+			delete(p.writes, token)
+		}
+	}
+}
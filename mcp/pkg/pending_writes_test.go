@@ -0,0 +1,56 @@
+package pkg
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPendingWrites_TokenIsSingleUse(t *testing.T) {
+	pw := NewPendingWrites(time.Minute)
+	w := PendingWrite{Tool: "exec", Database: "db", Branch: "main", Statement: "DELETE FROM t;"}
+
+	token, err := pw.Add(w)
+	if err != nil {
+		t.Fatalf("unexpected error adding pending write: %v", err)
+	}
+	if !pw.Take(token, w) {
+		t.Fatalf("expected token to confirm the write it was issued for")
+	}
+	if pw.Take(token, w) {
+		t.Fatalf("expected token to be rejected on second use")
+	}
+}
+
+func TestPendingWrites_TokenOnlyMatchesItsWrite(t *testing.T) {
+	pw := NewPendingWrites(time.Minute)
+	w := PendingWrite{Tool: "exec", Database: "db", Branch: "main", Statement: "DELETE FROM t WHERE id = 1;"}
+
+	token, err := pw.Add(w)
+	if err != nil {
+		t.Fatalf("unexpected error adding pending write: %v", err)
+	}
+	other := w
+	other.Statement = "DELETE FROM t;"
+	if pw.Take(token, other) {
+		t.Fatalf("expected token to be rejected for a different statement")
+	}
+	if pw.Take(token, w) {
+		t.Fatalf("expected the token to be consumed by the mismatched attempt")
+	}
+}
+
+func TestPendingWrites_TokenExpires(t *testing.T) {
+	pw := NewPendingWrites(time.Minute)
+	now := time.Now()
+	pw.now = func() time.Time { return now }
+	w := PendingWrite{Tool: "drop_table", Database: "db", Branch: "main", Statement: "DROP TABLE t;"}
+
+	token, err := pw.Add(w)
+	if err != nil {
+		t.Fatalf("unexpected error adding pending write: %v", err)
+	}
+	now = now.Add(2 * time.Minute)
+	if pw.Take(token, w) {
+		t.Fatalf("expected expired token to be rejected")
+	}
+}
@@ -0,0 +1,350 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// RateLimitToolCalls and RateLimitWrites name the limits of RateLimits
+	// in the errors of refused calls.
+	RateLimitToolCalls = "tool_calls"
+	RateLimitWrites    = "writes"
+
+	// concurrencyRetryAfter is the retry-after of calls refused because the
+	// caller has too many calls running, which has no exact answer.
+	concurrencyRetryAfter = time.Second
+
+	// rateLimiterSweepInterval is how often the callers that went idle are
+	// forgotten.
+	rateLimiterSweepInterval = time.Minute
+)
+
+// ErrRateLimited is wrapped by the errors of tool calls refused because the
+// caller is over one of its limits.
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// RateLimits caps how fast, and how many at once, each caller may call
+// tools. Callers are told apart by their identity, such as a JWT subject or
+// an API key, or by their remote address when they are not authenticated.
+// Stdio callers share one set of limits.
+type RateLimits struct {
+	// ToolCalls limits the calls of every tool, write tools included.
+	ToolCalls *RateLimit `yaml:"tool_calls" json:"tool_calls"`
+	// Writes limits the calls of the tools that write to the database, on
+	// top of ToolCalls.
+	Writes *RateLimit `yaml:"writes" json:"writes"`
+}
+
+// RateLimit is a token bucket holding up to Burst calls and refilled at Rate
+// calls per second, and a cap on the calls running at once. Zero values are
+// unlimited.
+type RateLimit struct {
+	Rate float64 `yaml:"rate" json:"rate"`
+	// Burst defaults to Rate rounded up, and at least one call.
+	Burst         int `yaml:"burst" json:"burst"`
+	MaxConcurrent int `yaml:"max_concurrent" json:"max_concurrent"`
+}
+
+func (r *RateLimits) Validate() error {
+	if r.ToolCalls == nil && r.Writes == nil {
+		return errors.New("rate_limits must set tool_calls or writes")
+	}
+	if r.ToolCalls != nil {
+		if err := r.ToolCalls.validate(RateLimitToolCalls); err != nil {
+			return err
+		}
+	}
+	if r.Writes != nil {
+		if err := r.Writes.validate(RateLimitWrites); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *RateLimit) validate(name string) error {
+	if l.Rate < 0 || math.IsNaN(l.Rate) || math.IsInf(l.Rate, 0) {
+		return fmt.Errorf("invalid rate_limits %s rate %v: must be a positive number of calls per second", name, l.Rate)
+	}
+	if l.Burst < 0 {
+		return fmt.Errorf("invalid rate_limits %s burst %d: must not be negative", name, l.Burst)
+	}
+	if l.Burst > 0 && l.Rate == 0 {
+		return fmt.Errorf("rate_limits %s has a burst but no rate", name)
+	}
+	if l.MaxConcurrent < 0 {
+		return fmt.Errorf("invalid rate_limits %s max_concurrent %d: must not be negative", name, l.MaxConcurrent)
+	}
+	if l.Rate == 0 && l.MaxConcurrent == 0 {
+		return fmt.Errorf("rate_limits %s must set rate or max_concurrent", name)
+	}
+	return nil
+}
+
+func (l *RateLimit) burst() float64 {
+	if l.Burst > 0 {
+		return float64(l.Burst)
+	}
+	return math.Max(1, math.Ceil(l.Rate))
+}
+
+// RateLimitError is the error of a tool call refused because the caller is
+// over one of its limits.
+type RateLimitError struct {
+	// Limit is RateLimitToolCalls or RateLimitWrites.
+	Limit string
+	// Concurrent is true when the caller has too many calls running, rather
+	// than having made too many calls recently.
+	Concurrent bool
+	// RetryAfter is how long the caller should wait before calling again.
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	reason := "too many calls"
+	if e.Concurrent {
+		reason = "too many concurrent calls"
+	}
+	return fmt.Sprintf("%s: %s for the %s limit, retry after %s", ErrRateLimited, reason, e.Limit, e.RetryAfter)
+}
+
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// RateLimiter applies RateLimits to each caller.
+type RateLimiter struct {
+	limits RateLimits
+	// now is replaced by tests.
+	now func() time.Time
+
+	*callerStates
+}
+
+// callerStates is the state of every caller, which a RateLimiter replacing
+// another on reload takes over, so that the calls running then still count
+// against the limits and return their place when they finish.
+type callerStates struct {
+	mu        sync.Mutex
+	callers   map[string]*callerLimits
+	lastSweep time.Time
+}
+
+// callerLimits is the state of the limits of one caller.
+type callerLimits struct {
+	calls         tokenBucket
+	writes        tokenBucket
+	running       int
+	runningWrites int
+}
+
+func NewRateLimiter(limits RateLimits) *RateLimiter {
+	return &RateLimiter{
+		limits:       limits,
+		now:          time.Now,
+		callerStates: &callerStates{callers: map[string]*callerLimits{}},
+	}
+}
+
+// carryOver makes l take over the callers of previous, the limiter it
+// replaces: their running calls, and the tokens left in their buckets,
+// which are capped by the bursts of l.
+func (l *RateLimiter) carryOver(previous *RateLimiter) {
+	l.callerStates = previous.callerStates
+}
+
+// Acquire counts a tool call of the caller of ctx against its limits, and
+// write calls against the write limits too. The call must call the returned
+// function when it returns. Calls over a limit are refused with a
+// *RateLimitError, without counting against the other limits.
+func (l *RateLimiter) Acquire(ctx context.Context, write bool) (func(), error) {
+	key := callerKey(ctx)
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) >= rateLimiterSweepInterval {
+		for k, c := range l.callers {
+			l.prune(k, c, now)
+		}
+		l.lastSweep = now
+	}
+	caller, ok := l.callers[key]
+	if !ok {
+		caller = &callerLimits{}
+		l.callers[key] = caller
+	}
+
+	if err := l.check(caller, write, now); err != nil {
+		l.prune(key, caller, now)
+		return nil, err
+	}
+	if l.limits.ToolCalls != nil {
+		caller.calls.take(l.limits.ToolCalls)
+	}
+	caller.running++
+	if write {
+		if l.limits.Writes != nil {
+			caller.writes.take(l.limits.Writes)
+		}
+		caller.runningWrites++
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			caller.running--
+			if write {
+				caller.runningWrites--
+			}
+			l.prune(key, caller, l.now())
+		})
+	}, nil
+}
+
+// limitState is a limit of RateLimits and the state of a caller against it.
+type limitState struct {
+	name    string
+	limit   *RateLimit
+	bucket  *tokenBucket
+	running int
+}
+
+// states returns the limits a call of caller counts against.
+func (l *RateLimiter) states(caller *callerLimits, write bool) []limitState {
+	states := []limitState{{RateLimitToolCalls, l.limits.ToolCalls, &caller.calls, caller.running}}
+	if write {
+		states = append(states, limitState{RateLimitWrites, l.limits.Writes, &caller.writes, caller.runningWrites})
+	}
+	return states
+}
+
+// check returns the error refusing a call of caller, if it is over one of
+// its limits. It refills the buckets but does not take from them.
+func (l *RateLimiter) check(caller *callerLimits, write bool, now time.Time) error {
+	for _, s := range l.states(caller, write) {
+		if s.limit == nil {
+			continue
+		}
+		if s.limit.MaxConcurrent > 0 && s.running >= s.limit.MaxConcurrent {
+			return &RateLimitError{Limit: s.name, Concurrent: true, RetryAfter: concurrencyRetryAfter}
+		}
+		s.bucket.refill(s.limit, now)
+		if wait := s.bucket.wait(s.limit); wait > 0 {
+			return &RateLimitError{Limit: s.name, RetryAfter: wait}
+		}
+	}
+	return nil
+}
+
+// prune forgets caller once it has no calls running and its buckets are
+// full again, since a new caller starts the same way. Callers are pruned
+// when their calls return, and callers that went idle in between sweeps.
+func (l *RateLimiter) prune(key string, caller *callerLimits, now time.Time) {
+	if caller.running > 0 {
+		return
+	}
+	for _, s := range l.states(caller, true) {
+		if s.limit == nil || s.limit.Rate == 0 {
+			continue
+		}
+		s.bucket.refill(s.limit, now)
+		if s.bucket.tokens < s.limit.burst() {
+			return
+		}
+	}
+	delete(l.callers, key)
+}
+
+// tokenBucket holds the calls a caller may make before it has to wait.
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// refill adds the tokens earned since the last refill. A new bucket is full.
+func (b *tokenBucket) refill(limit *RateLimit, now time.Time) {
+	if limit.Rate == 0 {
+		return
+	}
+	if b.updated.IsZero() {
+		b.tokens = limit.burst()
+	} else if elapsed := now.Sub(b.updated); elapsed > 0 {
+		b.tokens = math.Min(limit.burst(), b.tokens+elapsed.Seconds()*limit.Rate)
+	}
+	b.updated = now
+}
+
+// wait returns how long until the bucket holds a token, or zero when it
+// holds one now.
+func (b *tokenBucket) wait(limit *RateLimit) time.Duration {
+	if limit.Rate == 0 || b.tokens >= 1 {
+		return 0
+	}
+	return time.Duration(math.Ceil((1 - b.tokens) / limit.Rate * float64(time.Second)))
+}
+
+func (b *tokenBucket) take(limit *RateLimit) {
+	if limit.Rate > 0 {
+		b.tokens--
+	}
+}
+
+// callerKey tells the caller of ctx apart from other callers: by identity
+// when it is authenticated, by remote address otherwise, and as the only
+// caller of a stdio server.
+func callerKey(ctx context.Context) string {
+	if identity := IdentityFromContext(ctx); identity != nil {
+		return "identity:" + identity.Issuer + "/" + identity.Subject
+	}
+	if address := RemoteAddressFromContext(ctx); address != "" {
+		return "remote:" + address
+	}
+	return ""
+}
+
+type remoteAddressKey struct{}
+
+// ContextWithRemoteAddress returns a copy of ctx carrying the address of the
+// client that made the request, without its port.
+func ContextWithRemoteAddress(ctx context.Context, address string) context.Context {
+	return context.WithValue(ctx, remoteAddressKey{}, address)
+}
+
+// RemoteAddressFromContext returns the address of the client of the request
+// ctx belongs to, or "" for stdio requests.
+func RemoteAddressFromContext(ctx context.Context) string {
+	address, _ := ctx.Value(remoteAddressKey{}).(string)
+	return address
+}
+
+// withRemoteAddress records the address of each request's client in its
+// context, so that unauthenticated callers can be told apart.
+func withRemoteAddress(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		address := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(address); err == nil {
+			address = host
+		}
+		next.ServeHTTP(w, r.WithContext(ContextWithRemoteAddress(r.Context(), address)))
+	})
+}
+
+// WithRateLimits limits how fast, and how many at once, each caller may call
+// tools.
+func WithRateLimits(limits *RateLimits) Option {
+	return func(s Server) {
+		if cs, ok := s.(configurableServer); ok {
+			cs.settings().rateLimiter = NewRateLimiter(*limits)
+		}
+	}
+}
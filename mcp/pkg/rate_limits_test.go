@@ -0,0 +1,186 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig_RateLimits(t *testing.T) {
+	config, err := LoadConfig(writeConfigFile(t, `
+rate_limits:
+  tool_calls:
+    rate: 10
+    burst: 20
+    max_concurrent: 4
+  writes:
+    rate: 0.5
+    max_concurrent: 1
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	limits := config.RateLimits
+	if limits == nil || limits.ToolCalls == nil || limits.Writes == nil {
+		t.Fatalf("unexpected rate limits %+v", limits)
+	}
+	if limits.ToolCalls.Rate != 10 || limits.ToolCalls.Burst != 20 || limits.ToolCalls.MaxConcurrent != 4 {
+		t.Fatalf("unexpected tool call limit %+v", limits.ToolCalls)
+	}
+	if limits.Writes.Rate != 0.5 || limits.Writes.burst() != 1 || limits.Writes.MaxConcurrent != 1 {
+		t.Fatalf("unexpected write limit %+v", limits.Writes)
+	}
+
+	for name, content := range map[string]string{
+		"empty":          "rate_limits: {}\n",
+		"negative rate":  "rate_limits:\n  tool_calls:\n    rate: -1\n",
+		"burst no rate":  "rate_limits:\n  writes:\n    burst: 5\n    max_concurrent: 1\n",
+		"unlimited":      "rate_limits:\n  writes:\n    burst: 0\n",
+		"negative limit": "rate_limits:\n  tool_calls:\n    max_concurrent: -1\n",
+	} {
+		if _, err := LoadConfig(writeConfigFile(t, content)); err == nil {
+			t.Errorf("%s: expected rate limits %q to be rejected", name, content)
+		}
+	}
+}
+
+// fakeClock is the time of a RateLimiter in tests.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestRateLimiter(limits RateLimits) (*RateLimiter, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	limiter := NewRateLimiter(limits)
+	limiter.now = clock.Now
+	return limiter, clock
+}
+
+func TestRateLimiterTokenBucket(t *testing.T) {
+	limiter, clock := newTestRateLimiter(RateLimits{ToolCalls: &RateLimit{Rate: 2, Burst: 3}})
+	ctx := ContextWithIdentity(context.Background(), &Identity{Subject: "agent", Issuer: APIKeyIssuer})
+
+	for i := 0; i < 3; i++ {
+		release, err := limiter.Acquire(ctx, false)
+		if err != nil {
+			t.Fatalf("call %d: expected the burst to be allowed, got %v", i, err)
+		}
+		release()
+	}
+	_, err := limiter.Acquire(ctx, false)
+	var rateLimitErr *RateLimitError
+	if !errors.As(err, &rateLimitErr) || !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("expected a rate limit error once the burst is spent, got %v", err)
+	}
+	if rateLimitErr.Limit != RateLimitToolCalls || rateLimitErr.Concurrent || rateLimitErr.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("unexpected rate limit error %+v", rateLimitErr)
+	}
+
+	clock.now = clock.now.Add(500 * time.Millisecond)
+	release, err := limiter.Acquire(ctx, false)
+	if err != nil {
+		t.Fatalf("expected a call to be allowed once a token is refilled, got %v", err)
+	}
+	release()
+
+	other := ContextWithIdentity(context.Background(), &Identity{Subject: "other", Issuer: APIKeyIssuer})
+	release, err = limiter.Acquire(other, false)
+	if err != nil {
+		t.Fatalf("expected another caller to have limits of its own, got %v", err)
+	}
+	release()
+}
+
+func TestRateLimiterConcurrency(t *testing.T) {
+	limiter, _ := newTestRateLimiter(RateLimits{
+		ToolCalls: &RateLimit{MaxConcurrent: 2},
+		Writes:    &RateLimit{MaxConcurrent: 1},
+	})
+	ctx := ContextWithRemoteAddress(context.Background(), "10.0.0.1")
+
+	releaseWrite, err := limiter.Acquire(ctx, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var rateLimitErr *RateLimitError
+	if _, err := limiter.Acquire(ctx, true); !errors.As(err, &rateLimitErr) || rateLimitErr.Limit != RateLimitWrites || !rateLimitErr.Concurrent {
+		t.Fatalf("expected a second write to be over the write limit, got %v", err)
+	}
+	releaseRead, err := limiter.Acquire(ctx, false)
+	if err != nil {
+		t.Fatalf("expected a read next to the write to be allowed, got %v", err)
+	}
+	if _, err := limiter.Acquire(ctx, false); !errors.As(err, &rateLimitErr) || rateLimitErr.Limit != RateLimitToolCalls {
+		t.Fatalf("expected a third call to be over the tool call limit, got %v", err)
+	}
+
+	releaseWrite()
+	releaseWrite()
+	releaseRead()
+	if _, err := limiter.Acquire(ctx, true); err != nil {
+		t.Fatalf("expected a write to be allowed once the others returned, got %v", err)
+	}
+}
+
+func TestRateLimiterPrunesIdleCallers(t *testing.T) {
+	limiter, clock := newTestRateLimiter(RateLimits{
+		ToolCalls: &RateLimit{Rate: 1, Burst: 2},
+		Writes:    &RateLimit{Rate: 1},
+	})
+	ctx := ContextWithRemoteAddress(context.Background(), "10.0.0.1")
+
+	release, err := limiter.Acquire(ctx, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+	if len(limiter.callers) != 1 {
+		t.Fatalf("expected the caller to be kept while its buckets refill, got %d callers", len(limiter.callers))
+	}
+
+	clock.now = clock.now.Add(rateLimiterSweepInterval)
+	release, err = limiter.Acquire(ContextWithRemoteAddress(context.Background(), "10.0.0.2"), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+	if len(limiter.callers) != 1 || limiter.callers[callerKey(ctx)] != nil {
+		t.Fatalf("expected idle callers with full buckets to be forgotten, got %d callers", len(limiter.callers))
+	}
+}
+
+func TestCallerKey(t *testing.T) {
+	apiKey := ContextWithIdentity(context.Background(), &Identity{Subject: "ci", Issuer: APIKeyIssuer})
+	jwt := ContextWithIdentity(context.Background(), &Identity{Subject: "ci", Issuer: "https://issuer.example.com"})
+	remote := ContextWithRemoteAddress(context.Background(), "10.0.0.1")
+
+	if callerKey(apiKey) == callerKey(jwt) {
+		t.Fatal("expected the same subject of different issuers to be different callers")
+	}
+	if got := callerKey(ContextWithRemoteAddress(apiKey, "10.0.0.1")); got != callerKey(apiKey) {
+		t.Fatalf("expected authenticated callers to be told apart by identity, got %q", got)
+	}
+	if callerKey(remote) == callerKey(context.Background()) {
+		t.Fatal("expected unauthenticated callers to be told apart by remote address")
+	}
+}
+
+func TestWithRemoteAddress(t *testing.T) {
+	var address string
+	handler := withRemoteAddress(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		address = RemoteAddressFromContext(r.Context())
+	}))
+	req := httptest.NewRequest(http.MethodPost, MCPPath, nil)
+	req.RemoteAddr = "192.0.2.7:51234"
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if address != "192.0.2.7" {
+		t.Fatalf("expected the remote address without its port, got %q", address)
+	}
+}
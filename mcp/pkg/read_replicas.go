@@ -0,0 +1,244 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultReplicaCheckInterval is how often read replicas are checked
+	// when the config file does not say.
+	DefaultReplicaCheckInterval = 10 * time.Second
+
+	replicaCheckTimeout = 5 * time.Second
+)
+
+// ErrNoReadReplica is returned for read-only tool calls when no read replica
+// is healthy and falling back to the primary is disabled.
+var ErrNoReadReplica = errors.New("no read replica is available")
+
+// ReadReplicas are the servers read-only tools run on, in place of the
+// primary the command line flags connect to. They are reached with the
+// primary's user, password and TLS settings.
+type ReadReplicas struct {
+	Replicas []ReadReplica `yaml:"replicas" json:"replicas"`
+	// MaxLag takes a replica out of rotation while it is further behind the
+	// primary. It needs the standby_remote of every replica. Zero disables
+	// the lag check.
+	MaxLag time.Duration `yaml:"max_lag" json:"max_lag"`
+	// FallbackToPrimary runs read-only tools on the primary while no
+	// replica is healthy. Otherwise they fail.
+	FallbackToPrimary bool `yaml:"fallback_to_primary" json:"fallback_to_primary"`
+	// CheckInterval is how often replicas are pinged and their lag read.
+	CheckInterval time.Duration `yaml:"check_interval" json:"check_interval"`
+}
+
+// ReadReplica is a Dolt sql-server replicating from the primary.
+type ReadReplica struct {
+	Host string `yaml:"host" json:"host"`
+	// Port defaults to the port of the primary.
+	Port int `yaml:"port" json:"port"`
+	// StandbyRemote is the name of the replica's remote in the primary's
+	// cluster config, which identifies it in the primary's
+	// dolt_cluster_status table.
+	StandbyRemote string `yaml:"standby_remote" json:"standby_remote"`
+}
+
+func (r *ReadReplicas) Validate() error {
+	if len(r.Replicas) == 0 {
+		return errors.New("read_replicas must list at least one replica")
+	}
+	if r.MaxLag < 0 {
+		return fmt.Errorf("invalid read_replicas max_lag %s: must not be negative", r.MaxLag)
+	}
+	if r.CheckInterval < 0 {
+		return fmt.Errorf("invalid read_replicas check_interval %s: must not be negative", r.CheckInterval)
+	}
+	for i, replica := range r.Replicas {
+		if replica.Host == "" {
+			return fmt.Errorf("read replica %d has no host", i)
+		}
+		if replica.Port < 0 {
+			return fmt.Errorf("invalid port %d for read replica %s", replica.Port, replica.Host)
+		}
+		if r.MaxLag > 0 && replica.StandbyRemote == "" {
+			return fmt.Errorf("read replica %s has no standby_remote, which max_lag needs", replica.Host)
+		}
+	}
+	return nil
+}
+
+// ValidateDBConfig checks that the primary dbConfig connects to can have
+// read replicas.
+func (r *ReadReplicas) ValidateDBConfig(dbConfig db.Config) error {
+	if dbConfig.DialectType == db.DialectDoltLite {
+		return errors.New("read replicas are not supported with --doltlite, which has no server to replicate")
+	}
+	if dbConfig.DSN != "" {
+		return db.ErrEndpointWithDSN
+	}
+	if r.MaxLag > 0 && dbConfig.DialectType != "" && dbConfig.DialectType != db.DialectMySQL {
+		return fmt.Errorf("read_replicas max_lag is not supported with the %s dialect, which has no dolt_cluster_status table", dbConfig.DialectType)
+	}
+	return nil
+}
+
+// ReplicaRouter picks the read replica each read-only tool call runs on. It
+// checks the replicas in the background and takes the ones that cannot be
+// reached, or that lag too far behind the primary, out of rotation until
+// they recover.
+type ReplicaRouter struct {
+	logger   *zap.Logger
+	primary  db.Config
+	replicas ReadReplicas
+	pingers  []*db.Pinger
+
+	mu      sync.Mutex
+	checked []bool
+	healthy []bool
+	next    int
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewReplicaRouter returns a router over the replicas of the primary that
+// primary connects to, and starts checking them. Replicas are out of
+// rotation until their first check passes. Close stops the checks.
+func NewReplicaRouter(logger *zap.Logger, primary db.Config, replicas ReadReplicas) (*ReplicaRouter, error) {
+	if err := replicas.Validate(); err != nil {
+		return nil, err
+	}
+	if err := replicas.ValidateDBConfig(primary); err != nil {
+		return nil, err
+	}
+	if replicas.CheckInterval == 0 {
+		replicas.CheckInterval = DefaultReplicaCheckInterval
+	}
+
+	r := &ReplicaRouter{
+		logger:   logger,
+		primary:  primary,
+		replicas: replicas,
+		checked:  make([]bool, len(replicas.Replicas)),
+		healthy:  make([]bool, len(replicas.Replicas)),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	for i := range replicas.Replicas {
+		config, err := primary.WithEndpoint(r.endpoint(i))
+		if err != nil {
+			return nil, err
+		}
+		r.pingers = append(r.pingers, db.NewPinger(config))
+	}
+	go r.run()
+	return r, nil
+}
+
+// Pick returns the replica the next read-only tool call runs on. ok is
+// false when no replica is healthy and the call runs on the primary. The
+// error is ErrNoReadReplica when falling back to the primary is disabled.
+func (r *ReplicaRouter) Pick() (endpoint db.Endpoint, ok bool, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for range r.healthy {
+		i := r.next
+		r.next = (r.next + 1) % len(r.healthy)
+		if r.healthy[i] {
+			return r.endpoint(i), true, nil
+		}
+	}
+	if r.replicas.FallbackToPrimary {
+		return db.Endpoint{}, false, nil
+	}
+	return db.Endpoint{}, false, ErrNoReadReplica
+}
+
+// Close stops checking the replicas and closes their connections.
+func (r *ReplicaRouter) Close() error {
+	close(r.stop)
+	<-r.done
+	var errs []error
+	for _, pinger := range r.pingers {
+		errs = append(errs, pinger.Close())
+	}
+	return errors.Join(errs...)
+}
+
+func (r *ReplicaRouter) endpoint(i int) db.Endpoint {
+	replica := r.replicas.Replicas[i]
+	port := replica.Port
+	if port == 0 {
+		port = r.primary.Port
+	}
+	return db.Endpoint{Host: replica.Host, Port: port}
+}
+
+func (r *ReplicaRouter) run() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.replicas.CheckInterval)
+	defer ticker.Stop()
+	for {
+		r.checkAll()
+		select {
+		case <-ticker.C:
+		case <-r.stop:
+			return
+		}
+	}
+}
+
+func (r *ReplicaRouter) checkAll() {
+	for i := range r.replicas.Replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), replicaCheckTimeout)
+		err := r.check(ctx, i)
+		cancel()
+		r.setHealthy(i, err)
+	}
+}
+
+// check returns why replica i should be out of rotation, if it should.
+func (r *ReplicaRouter) check(ctx context.Context, i int) error {
+	if err := r.pingers[i].Ping(ctx); err != nil {
+		return err
+	}
+	if r.replicas.MaxLag == 0 {
+		return nil
+	}
+	lag, err := db.ReplicationLag(ctx, r.primary, r.replicas.Replicas[i].StandbyRemote)
+	if err != nil {
+		return err
+	}
+	if lag > r.replicas.MaxLag {
+		return fmt.Errorf("replication lag %s exceeds max_lag %s", lag, r.replicas.MaxLag)
+	}
+	return nil
+}
+
+// setHealthy records the result of checking replica i, logging when the
+// replica enters or leaves rotation.
+func (r *ReplicaRouter) setHealthy(i int, err error) {
+	healthy := err == nil
+	r.mu.Lock()
+	changed := !r.checked[i] || r.healthy[i] != healthy
+	r.checked[i] = true
+	r.healthy[i] = healthy
+	r.mu.Unlock()
+
+	endpoint := r.endpoint(i).String()
+	switch {
+	case healthy && changed:
+		r.logger.Info("read replica is in rotation", zap.String("replica", endpoint))
+	case !healthy && changed:
+		r.logger.Warn("read replica is out of rotation", zap.String("replica", endpoint), zap.Error(err))
+	case !healthy:
+		r.logger.Debug("read replica is still out of rotation", zap.String("replica", endpoint), zap.Error(err))
+	}
+}
@@ -0,0 +1,98 @@
+package pkg
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"go.uber.org/zap"
+)
+
+func TestLoadConfig_ReadReplicas(t *testing.T) {
+	config, err := LoadConfig(writeConfigFile(t, `
+read_replicas:
+  replicas:
+    - host: replica1
+      standby_remote: standby1
+    - host: replica2
+      port: 3307
+      standby_remote: standby2
+  max_lag: 5s
+  fallback_to_primary: true
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	replicas := config.ReadReplicas
+	if replicas == nil || len(replicas.Replicas) != 2 || replicas.MaxLag != 5*time.Second || !replicas.FallbackToPrimary {
+		t.Fatalf("unexpected read replicas %+v", replicas)
+	}
+	if err := config.ApplyDBConfig(&db.Config{Host: "primary", Port: 3306, DialectType: db.DialectMySQL}); err != nil {
+		t.Fatalf("unexpected error applying to a MySQL config: %v", err)
+	}
+	if err := config.ApplyDBConfig(&db.Config{DSN: "root@tcp(primary)/", DialectType: db.DialectMySQL}); !errors.Is(err, db.ErrEndpointWithDSN) {
+		t.Fatalf("expected read replicas to be rejected with a DSN, got %v", err)
+	}
+	if err := config.ApplyDBConfig(&db.Config{Host: "primary", Port: 5432, DialectType: db.DialectPostgres}); err == nil {
+		t.Fatal("expected max_lag to be rejected with the postgres dialect")
+	}
+
+	for _, contents := range []string{
+		"read_replicas:\n  replicas: []\n",
+		"read_replicas:\n  replicas:\n    - port: 3307\n",
+		"read_replicas:\n  replicas:\n    - host: replica1\n  max_lag: 5s\n",
+		"read_replicas:\n  replicas:\n    - host: replica1\n  check_interval: -1s\n",
+	} {
+		if _, err := LoadConfig(writeConfigFile(t, contents)); err == nil {
+			t.Fatalf("expected an error for %q", contents)
+		}
+	}
+}
+
+func TestReplicaRouterPick(t *testing.T) {
+	newRouter := func(fallback bool) *ReplicaRouter {
+		replicas := ReadReplicas{
+			Replicas:          []ReadReplica{{Host: "replica1"}, {Host: "replica2", Port: 3307}},
+			FallbackToPrimary: fallback,
+		}
+		return &ReplicaRouter{
+			logger:   zap.NewNop(),
+			primary:  db.Config{Host: "primary", Port: 3306},
+			replicas: replicas,
+			checked:  make([]bool, len(replicas.Replicas)),
+			healthy:  make([]bool, len(replicas.Replicas)),
+		}
+	}
+
+	router := newRouter(true)
+	if _, ok, err := router.Pick(); ok || err != nil {
+		t.Fatalf("expected unchecked replicas to fall back to the primary, got ok=%v err=%v", ok, err)
+	}
+
+	router.setHealthy(0, nil)
+	router.setHealthy(1, nil)
+	var picked []string
+	for range 4 {
+		endpoint, ok, err := router.Pick()
+		if !ok || err != nil {
+			t.Fatalf("expected a replica, got ok=%v err=%v", ok, err)
+		}
+		picked = append(picked, endpoint.String())
+	}
+	if picked[0] != "replica1:3306" || picked[1] != "replica2:3307" || picked[2] != picked[0] || picked[3] != picked[1] {
+		t.Fatalf("expected the replicas to be picked in turn, got %v", picked)
+	}
+
+	router.setHealthy(0, errors.New("replication lag 10s exceeds max_lag 5s"))
+	for range 2 {
+		if endpoint, _, _ := router.Pick(); endpoint.Host != "replica2" {
+			t.Fatalf("expected the lagging replica to be skipped, got %s", endpoint)
+		}
+	}
+
+	router = newRouter(false)
+	if _, _, err := router.Pick(); !errors.Is(err, ErrNoReadReplica) {
+		t.Fatalf("expected ErrNoReadReplica without fallback, got %v", err)
+	}
+}
@@ -1,8 +1,14 @@
 package pkg
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
 	"github.com/mark3labs/mcp-go/server"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 const (
@@ -15,6 +21,289 @@ type Server interface {
 	MCP() *server.MCPServer
 	DBConfig() db.Config
 	Dialect() db.Dialect
+	// ToolSettings returns the settings tool calls run with. A config reload
+	// replaces some of them, so tools read them on every call rather than
+	// keep them.
+	ToolSettings() ToolSettings
+}
+
+// ToolSettings are the optional features of a server that its tools apply.
+// A nil field is a feature that is not configured.
+type ToolSettings struct {
+	// PendingWrites holds the writes awaiting confirmation. When nil, write
+	// tools commit without a confirmation step.
+	PendingWrites *PendingWrites
+	// SQLPolicy is the policy client SQL must satisfy. When nil, any
+	// statement the tool accepts may run.
+	SQLPolicy *db.SQLPolicy
+	// Authorization decides which tools a caller may call. When nil, every
+	// caller may call every tool.
+	Authorization *Authorization
+	// DatabaseCredentials maps callers to the database users they connect
+	// as. When nil, every caller connects as the configured user.
+	DatabaseCredentials *DatabaseCredentials
+	// AuditLog records every tool call. When nil, tool calls are not
+	// audited.
+	AuditLog *AuditLog
+	// InFlightCalls holds the running tool calls, which shutdown waits for.
+	// When nil, shutdown does not wait.
+	InFlightCalls *InFlightCalls
+	// ReplicaRouter picks the read replica read-only tools run on. When nil,
+	// every tool runs on the configured database.
+	ReplicaRouter *ReplicaRouter
+	// RateLimiter limits each caller's tool calls. When nil, callers are not
+	// limited.
+	RateLimiter *RateLimiter
+	// Backend is the name of the backend the tools serve, or "" for the
+	// server's own database.
+	Backend string
+}
+
+type Option func(Server)
+
+// serverSettings holds the settings shared by every server implementation
+// that are configured through options rather than constructor arguments.
+type serverSettings struct {
+	// mu guards the settings a config reload replaces, which are read
+	// while tool calls run.
+	mu            sync.RWMutex
+	pendingWrites *PendingWrites
+	sqlPolicy     *db.SQLPolicy
+	authorization *Authorization
+	credentials   *DatabaseCredentials
+	apiKeys       *APIKeys
+	// rateLimiter is replaced, and every caller starts over, on reload.
+	rateLimiter *RateLimiter
+	// clientCertificateIdentity makes verified HTTPS client certificates
+	// identify callers.
+	clientCertificateIdentity bool
+	jwtConfig                 *JWTConfig
+	auditLog                  *AuditLog
+	// auditTable is denied to client SQL. It is not replaced on reload.
+	auditTable      *AuditTable
+	metricsEndpoint bool
+	inFlightCalls   *InFlightCalls
+	replicaRouter   *ReplicaRouter
+	// shutdownTimeout is how long running tool calls may take to finish
+	// once the server is asked to stop.
+	shutdownTimeout time.Duration
+	// unixSocket is the path HTTP servers listen on in place of their port.
+	unixSocket string
+	// sse serves the legacy HTTP+SSE transport next to streamable HTTP.
+	sse bool
+	// configFile is read again when the server receives SIGHUP.
+	configFile string
+	// logLevel is set by the log_level of the config file, if any, and
+	// otherwise to defaultLogLevel.
+	logLevel        *zap.AtomicLevel
+	defaultLogLevel zapcore.Level
+	backends        []*backendServer
+	// optionErr holds the errors of options that could not be applied,
+	// which the constructors return.
+	optionErr error
+}
+
+func (s *serverSettings) settings() *serverSettings {
+	return s
+}
+
+// ToolSettings returns the settings at the time of the call, those a config
+// reload replaces included, all from the same config.
+func (s *serverSettings) ToolSettings() ToolSettings {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	settings := ToolSettings{
+		PendingWrites:       s.pendingWrites,
+		SQLPolicy:           s.sqlPolicy,
+		Authorization:       s.authorization,
+		DatabaseCredentials: s.credentials,
+		AuditLog:            s.auditLog,
+		InFlightCalls:       s.inFlightCalls,
+		ReplicaRouter:       s.replicaRouter,
+		RateLimiter:         s.rateLimiter,
+	}
+	if s.auditTable != nil {
+		settings.SQLPolicy = s.sqlPolicy.WithDeniedTables(s.auditTable.policyPattern()).WithDeniedBranches(s.auditTable.branch())
+	}
+	return settings
+}
+
+// drain waits up to the shutdown timeout for the running tool calls, then
+// cancels the rest and closes the connection pools they left open.
+func (s *serverSettings) drain(logger *zap.Logger) {
+	timeout := s.shutdownTimeout
+	if timeout <= 0 {
+		timeout = DefaultShutdownTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if interrupted := s.inFlightCalls.Drain(ctx); interrupted > 0 {
+		logger.Warn("shutdown interrupted running tool calls", zap.Int("calls", interrupted))
+	}
+	if closed, err := db.CloseOpenPools(); err != nil {
+		logger.Error("failed to close connection pools", zap.Error(err))
+	} else if closed > 0 {
+		logger.Warn("closed connection pools left open by interrupted tool calls", zap.Int("pools", closed))
+	}
+}
+
+type configurableServer interface {
+	settings() *serverSettings
+}
+
+// WithWriteConfirmation makes write tools preview their changes and wait for
+// confirmation before committing. Unconfirmed plans expire after ttl.
+func WithWriteConfirmation(ttl time.Duration) Option {
+	return func(s Server) {
+		if cs, ok := s.(configurableServer); ok {
+			cs.settings().pendingWrites = NewPendingWrites(ttl)
+		}
+	}
+}
+
+// WithSQLPolicy checks the SQL clients send to the query, exec, create_table,
+// and alter_table tools against policy before running it.
+func WithSQLPolicy(policy *db.SQLPolicy) Option {
+	return func(s Server) {
+		if cs, ok := s.(configurableServer); ok {
+			cs.settings().sqlPolicy = policy
+		}
+	}
+}
+
+// WithAuthorization limits the tools each caller may call, and the databases
+// and branches it may call them on, to the grants of its roles.
+func WithAuthorization(authorization *Authorization) Option {
+	return func(s Server) {
+		if cs, ok := s.(configurableServer); ok {
+			cs.settings().authorization = authorization
+		}
+	}
+}
+
+// WithDatabaseCredentials connects each caller to the database as the
+// database user credentials maps it to.
+func WithDatabaseCredentials(credentials *DatabaseCredentials) Option {
+	return func(s Server) {
+		if cs, ok := s.(configurableServer); ok {
+			cs.settings().credentials = credentials
+		}
+	}
 }
 
-type Option func(Server)
\ No newline at end of file
+// WithAPIKeys authenticates HTTP callers by the API keys in keys, in
+// addition to JWTs when those are configured. It has no effect on stdio
+// servers.
+func WithAPIKeys(keys *APIKeys) Option {
+	return func(s Server) {
+		if cs, ok := s.(configurableServer); ok {
+			cs.settings().apiKeys = keys
+		}
+	}
+}
+
+// WithClientCertificateIdentity makes the verified client certificate of an
+// HTTPS request identify the caller, as a JWT would. Requests with a
+// verified certificate do not need a token. It has no effect on stdio
+// servers.
+func WithClientCertificateIdentity() Option {
+	return func(s Server) {
+		if cs, ok := s.(configurableServer); ok {
+			cs.settings().clientCertificateIdentity = true
+		}
+	}
+}
+
+// WithJWTConfig authenticates HTTP callers by tokens from the issuers of
+// config, in addition to the issuer given by --jwk-url and --jwk-claims. It
+// has no effect on stdio servers.
+func WithJWTConfig(config *JWTConfig) Option {
+	return func(s Server) {
+		if cs, ok := s.(configurableServer); ok {
+			cs.settings().jwtConfig = config
+		}
+	}
+}
+
+// WithAuditLog records every tool call in auditLog.
+func WithAuditLog(auditLog *AuditLog) Option {
+	return func(s Server) {
+		if cs, ok := s.(configurableServer); ok {
+			cs.settings().auditLog = auditLog
+		}
+	}
+}
+
+// WithAuditTable denies client SQL the audit table, so that only the audit
+// log writes to it, even when no SQL policy is configured.
+func WithAuditTable(table *AuditTable) Option {
+	return func(s Server) {
+		if cs, ok := s.(configurableServer); ok {
+			cs.settings().auditTable = table
+		}
+	}
+}
+
+// WithMetricsEndpoint serves metrics at MetricsPath on the HTTP server,
+// behind the same authentication as MCP.
+func WithMetricsEndpoint() Option {
+	return func(s Server) {
+		if cs, ok := s.(configurableServer); ok {
+			cs.settings().metricsEndpoint = true
+		}
+	}
+}
+
+// WithUnixSocket makes an HTTP server listen on the unix domain socket at
+// path instead of its TCP port, so that only local clients can reach it. It
+// has no effect on stdio servers.
+func WithUnixSocket(path string) Option {
+	return func(s Server) {
+		if cs, ok := s.(configurableServer); ok {
+			cs.settings().unixSocket = path
+		}
+	}
+}
+
+// WithReadReplicas runs read-only tools on the read replica router picks,
+// and every other tool on the configured database. Backends are not routed.
+func WithReadReplicas(router *ReplicaRouter) Option {
+	return func(s Server) {
+		if cs, ok := s.(configurableServer); ok {
+			cs.settings().replicaRouter = router
+		}
+	}
+}
+
+// WithShutdownTimeout sets how long running tool calls may take to finish
+// once the server is asked to stop. Calls still running then are canceled
+// and their transactions rolled back. Defaults to DefaultShutdownTimeout.
+func WithShutdownTimeout(timeout time.Duration) Option {
+	return func(s Server) {
+		if cs, ok := s.(configurableServer); ok {
+			cs.settings().shutdownTimeout = timeout
+		}
+	}
+}
+
+// WithConfigFile reads path again when the server receives SIGHUP, replacing
+// the settings it holds without interrupting sessions or running tool
+// calls. See Config for the settings that are replaced.
+func WithConfigFile(path string) Option {
+	return func(s Server) {
+		if cs, ok := s.(configurableServer); ok {
+			cs.settings().configFile = path
+		}
+	}
+}
+
+// WithLogLevel lets a reload of the config file set level to its log_level,
+// or back to defaultLevel, such as the level of --log-level, when it has none.
+func WithLogLevel(level zap.AtomicLevel, defaultLevel zapcore.Level) Option {
+	return func(s Server) {
+		if cs, ok := s.(configurableServer); ok {
+			cs.settings().logLevel = &level
+			cs.settings().defaultLogLevel = defaultLevel
+		}
+	}
+}
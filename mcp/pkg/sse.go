@@ -0,0 +1,53 @@
+package pkg
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	// SSEPath and SSEMessagePath are where the legacy HTTP+SSE transport of
+	// the server's own database is served. A backend's are under its
+	// BackendPath.
+	SSEPath        = "/sse"
+	SSEMessagePath = "/message"
+
+	// sseKeepAliveInterval is how often idle SSE streams are pinged, so
+	// that proxies do not close them.
+	sseKeepAliveInterval = 30 * time.Second
+)
+
+// WithSSE serves the legacy HTTP+SSE transport next to streamable HTTP, for
+// clients that do not speak streamable HTTP yet. It has no effect on stdio
+// servers.
+func WithSSE() Option {
+	return func(s Server) {
+		if cs, ok := s.(configurableServer); ok {
+			cs.settings().sse = true
+		}
+	}
+}
+
+// newSSEServer returns the HTTP+SSE transport of mcp, with its endpoints
+// under basePath.
+func newSSEServer(mcp *server.MCPServer, basePath string) *server.SSEServer {
+	return server.NewSSEServer(mcp,
+		server.WithStaticBasePath(basePath),
+		server.WithKeepAliveInterval(sseKeepAliveInterval),
+	)
+}
+
+// withSSERoutes serves the SSE and message endpoints of each of servers,
+// and every other path with next. New SSE streams are refused once the
+// server starts shutting down, as new streamable HTTP sessions are.
+func withSSERoutes(next http.Handler, calls *InFlightCalls, servers ...*server.SSEServer) http.Handler {
+	mux := http.NewServeMux()
+	for _, sse := range servers {
+		mux.Handle(sse.CompleteSsePath(), withNewSessionsRefusedWhileDraining(sse.SSEHandler(), calls))
+		mux.Handle(sse.CompleteMessagePath(), sse.MessageHandler())
+	}
+	mux.Handle("/", next)
+	return mux
+}
@@ -0,0 +1,117 @@
+package pkg
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+func TestSSETransport(t *testing.T) {
+	config := db.Config{Host: "127.0.0.1", Port: 1, User: "root", DatabaseName: "mydb", DialectType: db.DialectMySQL}
+	backend := Backend{Name: "pg", Config: db.Config{Host: "127.0.0.1", Port: 1, User: "postgres", DialectType: db.DialectPostgres}}
+	addTool := func(name string) Option {
+		return func(s Server) {
+			s.MCP().AddTool(mcp.NewTool(name), func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+				return mcp.NewToolResultText("ok"), nil
+			})
+		}
+	}
+	keys := &APIKeys{Keys: []APIKey{{Name: "ci", Hash: HashAPIKey("ci-secret")}}}
+	srv, err := NewMCPHTTPServer(zap.NewNop(), config, 0, nil, "", nil, WithAPIKeys(keys), WithSSE(), addTool("default_tool"), WithBackend(backend, nil, addTool("pg_tool")))
+	if err != nil {
+		t.Fatalf("unexpected error creating server: %v", err)
+	}
+	ts := httptest.NewServer(srv.(*httpServerImpl).handler)
+	defer ts.Close()
+
+	request := func(method, path, body string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest(method, ts.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("failed to create request: %v", err)
+		}
+		req.Header.Set("X-API-Key", "ci-secret")
+		if body != "" {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		resp, err := ts.Client().Do(req)
+		if err != nil {
+			t.Fatalf("failed to %s %s: %v", method, path, err)
+		}
+		return resp
+	}
+
+	// listTools opens an SSE stream at ssePath and lists the tools over the
+	// message endpoint it announces.
+	listTools := func(ssePath string) []string {
+		t.Helper()
+		stream := request(http.MethodGet, ssePath, "")
+		defer stream.Body.Close()
+		if stream.StatusCode != http.StatusOK {
+			t.Fatalf("failed to open an SSE stream at %s: %d", ssePath, stream.StatusCode)
+		}
+		events := bufio.NewScanner(stream.Body)
+		nextData := func() string {
+			t.Helper()
+			for events.Scan() {
+				if data, ok := strings.CutPrefix(events.Text(), "data:"); ok {
+					return strings.TrimSpace(data)
+				}
+			}
+			t.Fatalf("the SSE stream at %s ended: %v", ssePath, events.Err())
+			return ""
+		}
+
+		endpoint := nextData()
+		for i, body := range []string{
+			`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"test","version":"0"}}}`,
+			`{"jsonrpc":"2.0","id":2,"method":"tools/list"}`,
+		} {
+			resp := request(http.MethodPost, endpoint, body)
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusAccepted {
+				t.Fatalf("message %d to %s was not accepted: %d", i, endpoint, resp.StatusCode)
+			}
+			if i == 0 {
+				nextData()
+			}
+		}
+
+		var response struct {
+			Result mcp.ListToolsResult `json:"result"`
+		}
+		data := nextData()
+		if err := json.Unmarshal([]byte(data), &response); err != nil {
+			t.Fatalf("failed to decode tools of %s: %v: %s", ssePath, err, data)
+		}
+		var names []string
+		for _, tool := range response.Result.Tools {
+			names = append(names, tool.Name)
+		}
+		return names
+	}
+
+	if tools := listTools(SSEPath); len(tools) != 1 || tools[0] != "default_tool" {
+		t.Fatalf("expected the default tools at %s, got %v", SSEPath, tools)
+	}
+	if tools := listTools(BackendPath("pg") + SSEPath); len(tools) != 1 || tools[0] != "pg_tool" {
+		t.Fatalf("expected the backend tools at %s, got %v", BackendPath("pg")+SSEPath, tools)
+	}
+
+	resp, err := ts.Client().Get(ts.URL + SSEPath)
+	if err != nil {
+		t.Fatalf("failed to request %s: %v", SSEPath, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected SSE streams to require authentication, got %d", resp.StatusCode)
+	}
+}
@@ -6,6 +6,9 @@ import (
 	"io"
 	"log"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
 	"github.com/mark3labs/mcp-go/server"
@@ -17,6 +20,9 @@ type stdioServerImpl struct {
 	stdioServer *server.StdioServer
 	dbConfig    db.Config
 	dialect     db.Dialect
+	logger      *zap.Logger
+
+	serverSettings
 }
 
 type StdioServer interface {
@@ -49,16 +55,23 @@ func NewMCPStdioServer(logger *zap.Logger, config db.Config, opts ...Option) (St
 		dbConfig:    config,
 		dialect:     db.NewDialect(config.DialectType),
 		stdioServer: stdioServer,
+		logger:      logger,
 	}
+	srv.inFlightCalls = NewInFlightCalls(logger)
 
 	for _, opt := range opts {
 		opt(srv)
 	}
+	if srv.optionErr != nil {
+		return nil, srv.optionErr
+	}
 
 	return srv, nil
 }
 
 func (s *stdioServerImpl) DBConfig() db.Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 	return s.dbConfig
 }
 
@@ -77,15 +90,64 @@ func (s *stdioServerImpl) ServeStdio(ctx context.Context) {
 			fmt.Fprintln(os.Stderr, "failed to close database:", err)
 		}
 	}()
-	serveStdio(ctx, s.stdioServer)
+
+	// The tool calls run with the context of Listen, so it is only canceled
+	// once they have drained.
+	listenCtx, stopListening := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopListening()
+	stdin := &closeNotifyingReader{r: os.Stdin, closed: make(chan struct{})}
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		serveStdio(listenCtx, s.stdioServer, stdin)
+	}()
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(quit)
+	stopReloading := make(chan struct{})
+	defer close(stopReloading)
+	reloadOnSIGHUP(s.logger, func() error { return s.reloadConfig(s, &s.dbConfig) }, stopReloading)
+
+	var reason string
+	select {
+	case <-stopped:
+		return
+	case <-stdin.closed:
+		reason = "end of input"
+	case <-quit:
+		reason = "signal"
+	case <-ctx.Done():
+		reason = "context cancellation"
+	}
+	s.logger.Info("Shutting down Dolt MCP", zap.String("reason", reason))
+	s.drain(s.logger)
+	stopListening()
+	<-stopped
 }
 
-func serveStdio(ctx context.Context, srv *server.StdioServer) {
+func serveStdio(ctx context.Context, srv *server.StdioServer, stdin io.Reader) {
 	// Start the server
 	fmt.Println("Serving Dolt MCP on Stdin")
-	if err := srv.Listen(ctx, os.Stdin, os.Stdout); err != nil && err != io.EOF && err != context.Canceled {
+	if err := srv.Listen(ctx, stdin, os.Stdout); err != nil && err != io.EOF && err != context.Canceled {
 		fmt.Println("error serving Dolt MCP server:", err.Error())
 	}
 
 	fmt.Println("Successfully stopped Dolt MCP server.")
 }
+
+// closeNotifyingReader closes closed once reading r fails, which is how the
+// client ending the session shows up.
+type closeNotifyingReader struct {
+	r      io.Reader
+	closed chan struct{}
+	once   sync.Once
+}
+
+func (r *closeNotifyingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil {
+		r.once.Do(func() { close(r.closed) })
+	}
+	return n, err
+}
@@ -38,6 +38,10 @@ func NewAlterTableTool() mcp.Tool {
 			mcp.Required(),
 			mcp.Description(AlterTableToolQueryArgumentDescription),
 		),
+		mcp.WithString(
+			ApplyTokenCallToolArgumentName,
+			mcp.Description(ApplyTokenCallToolArgumentDescription),
+		),
 	)
 }
 
@@ -76,8 +80,22 @@ func RegisterAlterTableTool(server pkg.Server) {
 			return
 		}
 
+		err = dialect.ValidateQueryPolicy(alterTableStatement, workingDatabase, server.ToolSettings().SQLPolicy)
+		if err != nil {
+			result = mcp.NewToolResultError(err.Error())
+			return
+		}
+
 		config := server.DBConfig()
 
+		write := pkg.PendingWrite{Tool: AlterTableToolName, Database: workingDatabase, Branch: workingBranch, Statement: alterTableStatement}
+		result = ConfirmWrite(ctx, server, request, write, func(ctx context.Context) (WritePlan, error) {
+			return PlanWrite(ctx, config, dialect, workingDatabase, workingBranch, alterTableStatement)
+		})
+		if result != nil {
+			return
+		}
+
 		var tx db.DatabaseTransaction
 		tx, err = NewDatabaseTransactionUsingDatabaseOnBranch(ctx, config, dialect, workingDatabase, workingBranch)
 		if err != nil {
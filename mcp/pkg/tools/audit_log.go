@@ -0,0 +1,81 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// auditLogMiddleware records every call of every tool in the server's audit
+// log, including calls that are refused.
+func auditLogMiddleware(s pkg.Server, tool *mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return withAuditLogHandler(s, *tool, next)
+}
+
+func withAuditLogHandler(s pkg.Server, tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		auditLog := s.ToolSettings().AuditLog
+		if auditLog == nil {
+			return next(ctx, request)
+		}
+		start := time.Now()
+		recorder := &db.StatementRecorder{}
+		result, err := next(db.ContextWithStatementRecorder(ctx, recorder), request)
+
+		record := newAuditRecord(ctx, tool, request, recorder.Statements(), result, err)
+		record.Time = start.UTC()
+		record.DurationMS = float64(time.Since(start).Microseconds()) / 1000
+		auditLog.Write(record)
+		return result, err
+	}
+}
+
+func newAuditRecord(ctx context.Context, tool mcp.Tool, request mcp.CallToolRequest, statements []db.StatementRecord, result *mcp.CallToolResult, err error) pkg.AuditRecord {
+	record := pkg.AuditRecord{
+		Caller:    pkg.NewAuditCaller(pkg.IdentityFromContext(ctx)),
+		Tool:      tool.Name,
+		Arguments: pkg.RedactAuditArguments(request.GetArguments()),
+	}
+
+	access := ToolAccessFromRequest(tool, request)
+	if len(access.Databases) > 0 {
+		record.Database = access.Databases[0]
+	}
+	if len(access.Branches) > 0 {
+		record.Branch = access.Branches[0]
+	}
+
+	for _, statement := range statements {
+		statement.SQL = pkg.RedactAuditSQL(statement.SQL)
+		statement.Error = pkg.RedactAuditSQL(statement.Error)
+		if statement.RowsAffected != nil {
+			record.RowsAffected += *statement.RowsAffected
+		}
+		if statement.CommitHash != "" {
+			record.CommitHash = statement.CommitHash
+		}
+		record.Statements = append(record.Statements, statement)
+	}
+
+	if err != nil {
+		record.Error = pkg.RedactAuditSQL(err.Error())
+	} else if result != nil && result.IsError {
+		record.Error = pkg.RedactAuditSQL(toolResultText(result))
+	}
+	return record
+}
+
+func toolResultText(result *mcp.CallToolResult) string {
+	var b strings.Builder
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			b.WriteString(text.Text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,189 @@
+package tools
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// authorizationDatabaseArguments are the arguments naming the database a
+// tool runs against.
+var authorizationDatabaseArguments = []string{
+	WorkingDatabaseCallToolArgumentName,
+	DatabaseCallToolArgumentName,
+}
+
+// authorizationBranchArguments are the arguments naming a branch a tool runs
+// on or changes.
+var authorizationBranchArguments = []string{
+	WorkingBranchCallToolArgumentName,
+	BranchCallToolArgumentName,
+	NewBranchCallToolArgumentName,
+	OldNameCallToolArgumentName,
+	NewNameCallToolArgumentName,
+}
+
+// authorizationReadBranchArguments are, per tool, the arguments naming a
+// branch the tool only reads from.
+var authorizationReadBranchArguments = map[string]string{
+	CreateDoltBranchToolName:             OriginalBranchCallToolArgumentName,
+	MergeDoltBranchToolName:              BranchCallToolArgumentName,
+	MergeDoltBranchNoFastForwardToolName: BranchCallToolArgumentName,
+}
+
+// authorizationToolDatabaseArguments are, per tool, arguments naming the
+// database a tool creates.
+var authorizationToolDatabaseArguments = map[string]string{
+	CloneDatabaseToolName: NameCallToolArgumentName,
+}
+
+// branchLocalDoltProcedures are the Dolt procedures that only work on the
+// branch they run on. SQL calling any other, such as DOLT_CHECKOUT or
+// DOLT_MERGE, may reach branches that authorization cannot tell from its
+// arguments.
+var branchLocalDoltProcedures = map[string]bool{
+	"DOLT_ADD":                true,
+	"DOLT_COMMIT":             true,
+	"DOLT_CLEAN":              true,
+	"DOLT_CONFLICTS_RESOLVE":  true,
+	"DOLT_VERIFY_CONSTRAINTS": true,
+}
+
+// readOnlyDoltFunctions are the Dolt functions and table functions that
+// change nothing. SQL calling any other Dolt procedure or function, such as
+// DOLT_BRANCH or DOLT_RESET, may write, whichever tool runs it.
+var readOnlyDoltFunctions = map[string]bool{
+	"DOLT_BRANCH_STATUS":                   true,
+	"DOLT_DIFF":                            true,
+	"DOLT_DIFF_STAT":                       true,
+	"DOLT_DIFF_SUMMARY":                    true,
+	"DOLT_HASHOF":                          true,
+	"DOLT_HASHOF_DB":                       true,
+	"DOLT_HASHOF_TABLE":                    true,
+	"DOLT_LOG":                             true,
+	"DOLT_MERGE_BASE":                      true,
+	"DOLT_PATCH":                           true,
+	"DOLT_PREVIEW_MERGE_CONFLICTS":         true,
+	"DOLT_PREVIEW_MERGE_CONFLICTS_SUMMARY": true,
+	"DOLT_QUERY_DIFF":                      true,
+	"DOLT_REFLOG":                          true,
+	"DOLT_SCHEMA_DIFF":                     true,
+	"DOLT_VERSION":                         true,
+}
+
+// ToolAccessFromRequest returns what a call of tool touches.
+func ToolAccessFromRequest(tool mcp.Tool, request mcp.CallToolRequest) pkg.ToolAccess {
+	access := pkg.ToolAccess{
+		Tool:     tool.Name,
+		ReadOnly: !isMutatingTool(tool),
+	}
+
+	databaseArguments := authorizationDatabaseArguments
+	if argument, ok := authorizationToolDatabaseArguments[tool.Name]; ok {
+		databaseArguments = append(slices.Clone(databaseArguments), argument)
+	}
+	for _, argument := range databaseArguments {
+		if database := GetStringArgumentFromCallToolRequest(request, argument); database != "" {
+			addDatabaseAccess(&access, database)
+		}
+	}
+
+	readBranchArgument := authorizationReadBranchArguments[tool.Name]
+	if branch := GetStringArgumentFromCallToolRequest(request, readBranchArgument); readBranchArgument != "" && branch != "" {
+		access.ReadBranches = append(access.ReadBranches, branch)
+	}
+	for _, argument := range authorizationBranchArguments {
+		if argument == readBranchArgument {
+			continue
+		}
+		if branch := GetStringArgumentFromCallToolRequest(request, argument); branch != "" {
+			access.Branches = append(access.Branches, branch)
+		}
+	}
+	return access
+}
+
+// addDatabaseAccess adds database to access. A revision database, such as
+// mydb/feature, runs on its branch.
+func addDatabaseAccess(access *pkg.ToolAccess, database string) {
+	if i := strings.Index(database, "/"); i >= 0 {
+		access.Branches = append(access.Branches, database[i+1:])
+		database = database[:i]
+	}
+	access.Databases = append(access.Databases, database)
+}
+
+// addStatementAccess adds what the SQL of a call, such as a query or exec
+// call, touches besides its working database and branch: the databases the
+// statements reference, the revisions they read with AS OF, and every branch
+// when they call a Dolt procedure that is not branch-local or read revisions
+// they do not name. A call whose SQL calls a Dolt procedure that may write
+// is not read-only, even from a read-only tool such as query.
+func addStatementAccess(dialect db.Dialect, request mcp.CallToolRequest, access *pkg.ToolAccess) error {
+	for _, query := range sqlArgumentsFromRequest(request) {
+		targets, err := dialect.StatementTargets(query)
+		if err != nil {
+			return err
+		}
+		for _, database := range targets.Databases {
+			addDatabaseAccess(access, database)
+		}
+		access.ReadBranches = append(access.ReadBranches, targets.Revisions...)
+		if targets.UnnamedRevisions {
+			access.AnyBranch = true
+		}
+		for _, procedure := range targets.Procedures {
+			if !branchLocalDoltProcedures[procedure] {
+				access.AnyBranch = true
+			}
+			if !readOnlyDoltFunctions[procedure] {
+				access.ReadOnly = false
+			}
+		}
+	}
+	return nil
+}
+
+// mayWrite reports whether a call of tool may write: the tool is not
+// annotated as read-only, or its SQL calls a Dolt procedure that may write.
+// SQL that does not parse may write.
+func mayWrite(dialect db.Dialect, tool mcp.Tool, request mcp.CallToolRequest) bool {
+	if isMutatingTool(tool) {
+		return true
+	}
+	access := pkg.ToolAccess{ReadOnly: true}
+	if err := addStatementAccess(dialect, request, &access); err != nil {
+		return true
+	}
+	return !access.ReadOnly
+}
+
+// authorizationMiddleware checks every call of every tool against the
+// server's authorization rules before running it, including the databases
+// and branches its SQL names. Unauthorized calls return a tool error.
+func authorizationMiddleware(s pkg.Server, tool *mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return withAuthorizationHandler(s, *tool, next)
+}
+
+func withAuthorizationHandler(s pkg.Server, tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		authorization := s.ToolSettings().Authorization
+		if authorization == nil {
+			return next(ctx, request)
+		}
+		access := ToolAccessFromRequest(tool, request)
+		if err := addStatementAccess(s.Dialect(), request, &access); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		identity := pkg.IdentityFromContext(ctx)
+		if err := authorization.Authorize(identity, access); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		return next(ctx, request)
+	}
+}
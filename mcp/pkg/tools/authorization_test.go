@@ -0,0 +1,153 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func newAuthorizationTestServer() *fakeServer {
+	authorization := &pkg.Authorization{
+		Roles: map[string][]pkg.AuthorizationGrant{
+			"analyst": {{Databases: []string{"analytics"}, ReadOnly: true}},
+		},
+	}
+	return newTestServer(&fakeServer{settings: pkg.ToolSettings{Authorization: authorization}}, okHandler, NewQueryTool(), NewExecTool())
+}
+
+func TestAuthorizationMiddleware(t *testing.T) {
+	s := newAuthorizationTestServer()
+	ctx := pkg.ContextWithIdentity(context.Background(), &pkg.Identity{Subject: "alice", Claims: map[string]any{"roles": []any{"analyst"}}})
+	args := map[string]any{WorkingDatabaseCallToolArgumentName: "analytics", WorkingBranchCallToolArgumentName: "main"}
+
+	res, err := s.call(ctx, QueryToolName, args)
+	if err != nil || res.IsError {
+		t.Fatalf("expected query to be authorized, got result=%+v err=%v", res, err)
+	}
+
+	res, err = s.call(ctx, ExecToolName, args)
+	if err != nil || !res.IsError {
+		t.Fatalf("expected exec to be unauthorized, got result=%+v err=%v", res, err)
+	}
+	if text := res.Content[0].(mcp.TextContent).Text; !strings.Contains(text, "alice") || !strings.Contains(text, ExecToolName) {
+		t.Fatalf("expected error to name the caller and tool, got %q", text)
+	}
+
+	res, err = s.call(context.Background(), QueryToolName, args)
+	if err != nil || !res.IsError {
+		t.Fatalf("expected anonymous query to be unauthorized, got result=%+v err=%v", res, err)
+	}
+
+	for _, query := range []string{"SELECT dolt_branch('-D', 'main')", "SELECT dolt_reset('--hard', 'HEAD~')"} {
+		args[QueryCallToolArgumentName] = query
+		res, err = s.call(ctx, QueryToolName, args)
+		if err != nil || !res.IsError {
+			t.Fatalf("expected %q to need write access, got result=%+v err=%v", query, res, err)
+		}
+	}
+	args[QueryCallToolArgumentName] = "SELECT dolt_hashof('main')"
+	res, err = s.call(ctx, QueryToolName, args)
+	if err != nil || res.IsError {
+		t.Fatalf("expected a read-only Dolt function to be authorized, got result=%+v err=%v", res, err)
+	}
+}
+
+func TestAuthorizationMiddlewareReadsRulesOnEveryCall(t *testing.T) {
+	s := newAuthorizationTestServer()
+	authorization := s.settings.Authorization
+	s.settings.Authorization = nil
+	args := map[string]any{WorkingDatabaseCallToolArgumentName: "analytics", WorkingBranchCallToolArgumentName: "main"}
+
+	res, err := s.call(context.Background(), ExecToolName, args)
+	if err != nil || res.IsError {
+		t.Fatalf("expected exec to run without rules, got result=%+v err=%v", res, err)
+	}
+
+	s.settings.Authorization = authorization
+	res, err = s.call(context.Background(), ExecToolName, args)
+	if err != nil || !res.IsError {
+		t.Fatalf("expected exec to be unauthorized once rules are set, got result=%+v err=%v", res, err)
+	}
+}
+
+func TestToolAccessFromRequest(t *testing.T) {
+	access := ToolAccessFromRequest(NewMergeDoltBranchTool(), callToolRequest(map[string]any{
+		WorkingDatabaseCallToolArgumentName: "staging",
+		WorkingBranchCallToolArgumentName:   "staging/load",
+		BranchCallToolArgumentName:          "main",
+	}))
+	if access.ReadOnly || len(access.Branches) != 1 || access.Branches[0] != "staging/load" || len(access.ReadBranches) != 1 || access.ReadBranches[0] != "main" {
+		t.Fatalf("unexpected merge access: %+v", access)
+	}
+
+	access = ToolAccessFromRequest(NewQueryTool(), callToolRequest(map[string]any{
+		WorkingDatabaseCallToolArgumentName: "analytics/feature",
+	}))
+	if !access.ReadOnly || access.Databases[0] != "analytics" || access.Branches[0] != "feature" {
+		t.Fatalf("unexpected revision database access: %+v", access)
+	}
+}
+
+func TestAuthorizationMiddlewareChecksSQLTargets(t *testing.T) {
+	authorization := &pkg.Authorization{
+		Roles: map[string][]pkg.AuthorizationGrant{
+			"agent": {{Databases: []string{"analytics"}, Branches: []string{"agent/*"}}},
+			"admin": {{Databases: []string{"analytics"}}},
+		},
+	}
+	s := newTestServer(&fakeServer{settings: pkg.ToolSettings{Authorization: authorization}}, okHandler, NewQueryTool(), NewExecTool(), NewExecScriptTool())
+
+	call := func(role, tool string, sql any) *mcp.CallToolResult {
+		t.Helper()
+		args := map[string]any{WorkingDatabaseCallToolArgumentName: "analytics", WorkingBranchCallToolArgumentName: "agent/load"}
+		if statements, ok := sql.([]any); ok {
+			args[StatementsCallToolArgumentName] = statements
+		} else {
+			args[QueryCallToolArgumentName] = sql
+		}
+		ctx := pkg.ContextWithIdentity(context.Background(), &pkg.Identity{Subject: "bot", Claims: map[string]any{"roles": []any{role}}})
+		res, err := s.call(ctx, tool, args)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return res
+	}
+
+	for _, test := range []struct {
+		tool string
+		sql  any
+	}{
+		{QueryToolName, "SELECT * FROM `other_db/prod`.t"},
+		{QueryToolName, "SELECT * FROM `analytics/prod`.t"},
+		{QueryToolName, "SELECT * FROM other_db.t"},
+		{QueryToolName, "SELECT * FROM t AS OF 'prod'"},
+		{QueryToolName, "SELECT * FROM dolt_diff('main', 'prod', 't')"},
+		{ExecToolName, "USE other_db"},
+		{ExecToolName, "CALL DOLT_CHECKOUT('prod')"},
+		{ExecToolName, "CALL DOLT_MERGE('prod')"},
+		{ExecScriptToolName, []any{"CALL DOLT_CHECKOUT('prod')", "DELETE FROM t"}},
+	} {
+		if res := call("agent", test.tool, test.sql); !res.IsError {
+			t.Errorf("%s %v: expected a scoped caller to be unauthorized", test.tool, test.sql)
+		}
+	}
+
+	for _, test := range []struct {
+		role string
+		tool string
+		sql  any
+	}{
+		{"agent", QueryToolName, "SELECT * FROM t"},
+		{"agent", QueryToolName, "SELECT * FROM `analytics/agent/other`.t"},
+		{"agent", ExecToolName, "CALL DOLT_COMMIT('-am', 'load')"},
+		{"admin", ExecToolName, "CALL DOLT_CHECKOUT('prod')"},
+		{"admin", QueryToolName, "SELECT * FROM t AS OF 'prod'"},
+	} {
+		if res := call(test.role, test.tool, test.sql); res.IsError {
+			t.Errorf("%s %s %v: expected the call to be authorized, got %+v", test.role, test.tool, test.sql, res.Content)
+		}
+	}
+}
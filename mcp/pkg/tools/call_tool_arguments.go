@@ -8,6 +8,7 @@ const (
 	RevisionCallToolArgumentName            = "revision"
 	MessageCallToolArgumentName             = "message"
 	QueryCallToolArgumentName               = "query"
+	StatementsCallToolArgumentName          = "statements"
 	IfNotExistsCallToolArgumentName         = "if_not_exists"
 	IfExistsCallToolArgumentName            = "if_exists"
 	BranchCallToolArgumentName              = "branch"
@@ -35,7 +36,9 @@ const (
 	AssertionComparatorCallToolArgumentName = "assertion_comparator"
 	AssertionValueCallToolArgumentName      = "assertion_value"
 	TargetCallToolArgumentName              = "target"
+	AuthorCallToolArgumentName              = "author"
 )
 
 var WorkingDatabaseCallToolArgumentDescription = "The name of the database to use prior to making the tool call."
-var WorkingBranchCallToolArgumentDescription = "The name of the working branch to checkout prior to making the tool call."
\ No newline at end of file
+var WorkingBranchCallToolArgumentDescription = "The name of the working branch to checkout prior to making the tool call."
+var AuthorCallToolArgumentDescription = "The author of the commit, as \"Name <email>\". Defaults to the authenticated caller. Only callers permitted to override the author may set it."
\ No newline at end of file
@@ -0,0 +1,70 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// clusterPrimary is the db.EndpointResolver of a Dolt cluster, such as a
+// pkg.ClusterFailover.
+type clusterPrimary interface {
+	db.EndpointResolver
+	// Refresh looks for the primary now and returns it.
+	Refresh(ctx context.Context) (db.Endpoint, error)
+}
+
+// clusterFailoverMiddleware makes every write tool that runs in one
+// transaction retry once when it fails because its server stopped being the
+// primary of its Dolt cluster, after looking for the new primary. A write
+// that fails that way was not committed, so it is safe to run again. Tools
+// that do not support dry_run are not retried, since their changes may have
+// taken effect before the failure, and neither are calls running statements
+// that commit implicitly, or calls applying a confirmed write, whose apply
+// token was spent by the first attempt.
+func clusterFailoverMiddleware(s pkg.Server, tool *mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	if !isMutatingTool(*tool) || !supportsDryRun(*tool) {
+		return next
+	}
+	return withClusterFailoverHandler(s, next)
+}
+
+func withClusterFailoverHandler(s pkg.Server, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		failover, ok := s.DBConfig().Primary.(clusterPrimary)
+		if !ok {
+			return next(ctx, request)
+		}
+		previous, _ := failover.Endpoint()
+		result, err := next(ctx, request)
+		if !failedOnClusterRoleChange(result, err) || !retriesOnFailover(s.Dialect(), request) {
+			return result, err
+		}
+		primary, refreshErr := failover.Refresh(ctx)
+		if refreshErr != nil || primary == previous {
+			return result, err
+		}
+		return next(ctx, request)
+	}
+}
+
+func failedOnClusterRoleChange(result *mcp.CallToolResult, err error) bool {
+	if err != nil {
+		return db.IsClusterRoleChange(err.Error())
+	}
+	return result != nil && result.IsError && db.IsClusterRoleChange(toolResultText(result))
+}
+
+// retriesOnFailover reports whether request may run again on the new
+// primary: it does not spend an apply token, and its statements, if any,
+// do not commit implicitly.
+func retriesOnFailover(dialect db.Dialect, request mcp.CallToolRequest) bool {
+	if GetStringArgumentFromCallToolRequest(request, ApplyTokenCallToolArgumentName) != "" {
+		return false
+	}
+	kind, err := implicitCommitStatementKind(dialect, request)
+	return err == nil && kind == ""
+}
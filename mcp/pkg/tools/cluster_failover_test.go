@@ -0,0 +1,97 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// fakeClusterPrimary fails over to next when refreshed.
+type fakeClusterPrimary struct {
+	current   db.Endpoint
+	next      db.Endpoint
+	refreshes int
+}
+
+func (f *fakeClusterPrimary) Endpoint() (db.Endpoint, bool) {
+	return f.current, true
+}
+
+func (f *fakeClusterPrimary) Refresh(ctx context.Context) (db.Endpoint, error) {
+	f.refreshes++
+	if f.next == (db.Endpoint{}) {
+		return db.Endpoint{}, errors.New("no primary")
+	}
+	f.current = f.next
+	return f.current, nil
+}
+
+func TestClusterFailoverMiddleware(t *testing.T) {
+	primary := &fakeClusterPrimary{current: db.Endpoint{Host: "dolt-1", Port: 3306}, next: db.Endpoint{Host: "dolt-2", Port: 3306}}
+	execCalls, queryCalls := 0, 0
+	roleChanged := mcp.NewToolResultError("Error 1105 (HY000): This server transitioned cluster roles. This connection can no longer be used. Please reconnect.")
+	s := newTestServer(&fakeServer{dbConfig: db.Config{Primary: primary}}, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if request.Params.Name == QueryToolName {
+			queryCalls++
+			return roleChanged, nil
+		}
+		execCalls++
+		if execCalls == 1 {
+			return roleChanged, nil
+		}
+		return mcp.NewToolResultText("ok"), nil
+	}, NewExecTool(), NewQueryTool())
+
+	res, err := s.call(context.Background(), ExecToolName, nil)
+	if err != nil || res.IsError {
+		t.Fatalf("expected the write to succeed on the new primary, got result=%+v err=%v", res, err)
+	}
+	if execCalls != 2 || primary.current.Host != "dolt-2" {
+		t.Fatalf("expected one retry on dolt-2, got %d calls on %s", execCalls, primary.current.Host)
+	}
+
+	res, err = s.call(context.Background(), QueryToolName, nil)
+	if err != nil || !res.IsError || queryCalls != 1 {
+		t.Fatalf("expected read-only tools not to be retried, got %d calls, result=%+v err=%v", queryCalls, res, err)
+	}
+
+	// Without a new primary, the failure is returned as is.
+	primary.next = db.Endpoint{}
+	execCalls = 0
+	res, err = s.call(context.Background(), ExecToolName, nil)
+	if err != nil || !res.IsError || execCalls != 1 {
+		t.Fatalf("expected no retry without a new primary, got %d calls, result=%+v err=%v", execCalls, res, err)
+	}
+}
+
+func TestClusterFailoverMiddlewareSkipsWritesThatMayHaveTakenEffect(t *testing.T) {
+	primary := &fakeClusterPrimary{current: db.Endpoint{Host: "dolt-1", Port: 3306}, next: db.Endpoint{Host: "dolt-2", Port: 3306}}
+	calls := map[string]int{}
+	roleChanged := mcp.NewToolResultError("Error 1105 (HY000): This server transitioned cluster roles. This connection can no longer be used. Please reconnect.")
+	s := newTestServer(&fakeServer{dbConfig: db.Config{Primary: primary}}, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		calls[request.Params.Name]++
+		return roleChanged, nil
+	}, NewExecTool(), NewCreateDatabaseTool(), NewDropDatabaseTool(), NewDoltPushBranchTool(), NewDoltFetchBranchTool(), NewAddDoltRemoteTool(), NewKillProcessTool(), NewCreateDoltCommitTool())
+
+	for _, name := range []string{CreateDatabaseToolName, DropDatabaseToolName, DoltPushBranchToolName, DoltFetchBranchToolName, AddDoltRemoteToolName, KillProcessToolName, CreateDoltCommitToolName} {
+		if res, err := s.call(context.Background(), name, nil); err != nil || !res.IsError || calls[name] != 1 {
+			t.Errorf("expected %s not to be retried, got %d calls, result=%+v err=%v", name, calls[name], res, err)
+		}
+	}
+
+	for _, arguments := range []map[string]any{
+		{QueryCallToolArgumentName: "INSERT INTO people VALUES (1);", ApplyTokenCallToolArgumentName: "token"},
+		{QueryCallToolArgumentName: "CREATE TABLE t (id INT PRIMARY KEY);"},
+	} {
+		calls[ExecToolName] = 0
+		if res, err := s.call(context.Background(), ExecToolName, arguments); err != nil || !res.IsError || calls[ExecToolName] != 1 {
+			t.Errorf("expected exec with %v not to be retried, got %d calls, result=%+v err=%v", arguments, calls[ExecToolName], res, err)
+		}
+	}
+	if primary.refreshes != 0 {
+		t.Fatalf("expected no primary lookups, got %d", primary.refreshes)
+	}
+}
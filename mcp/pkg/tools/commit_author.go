@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	commitAuthorNameClaim  = "name"
+	commitAuthorEmailClaim = "email"
+)
+
+var commitAuthorRegexp = regexp.MustCompile(`^\s*([^<>]*[^<>\s])\s*<([^<>\s]+)>\s*$`)
+
+// commitAuthor returns the author of the commits a tool call makes. The
+// author argument wins when the caller may override the author. Otherwise
+// an authenticated caller is the author, named by its name or sub claim and
+// its email claim. ok is false when commits are authored by the
+// connection's user.
+func commitAuthor(ctx context.Context, server pkg.Server, request mcp.CallToolRequest) (name, email string, ok bool, err error) {
+	identity := pkg.IdentityFromContext(ctx)
+
+	if author := GetStringArgumentFromCallToolRequest(request, AuthorCallToolArgumentName); author != "" {
+		if !canOverrideAuthor(server, identity) {
+			return "", "", false, status.Errorf(codes.PermissionDenied, "%s may not be set by this caller", AuthorCallToolArgumentName)
+		}
+		matches := commitAuthorRegexp.FindStringSubmatch(author)
+		if matches == nil {
+			return "", "", false, status.Errorf(codes.InvalidArgument, "%s must be formatted as \"Name <email>\"", AuthorCallToolArgumentName)
+		}
+		return matches[1], matches[2], true, nil
+	}
+
+	if identity == nil {
+		return "", "", false, nil
+	}
+	name = firstClaimValue(identity, commitAuthorNameClaim)
+	if name == "" {
+		name = identity.Subject
+	}
+	email = firstClaimValue(identity, commitAuthorEmailClaim)
+	if email == "" {
+		email = identity.Subject
+	}
+	name, email = sanitizeCommitAuthor(name), sanitizeCommitAuthor(email)
+	if name == "" || email == "" {
+		return "", "", false, nil
+	}
+	return name, email, true, nil
+}
+
+// canOverrideAuthor reports whether the caller may choose the author of its
+// commits. Unauthenticated callers, such as stdio clients, run the server
+// themselves and may. Authenticated callers need one of the authorization
+// AuthorOverrideRoles.
+func canOverrideAuthor(server pkg.Server, identity *pkg.Identity) bool {
+	if identity == nil {
+		return true
+	}
+	authorization := server.ToolSettings().Authorization
+	return authorization != nil && authorization.CanOverrideAuthor(identity)
+}
+
+// firstClaimValue returns the whole value of a string claim, which may
+// contain spaces, such as a full name.
+func firstClaimValue(identity *pkg.Identity, claim string) string {
+	if value, ok := identity.Claims[claim].(string); ok {
+		return value
+	}
+	return ""
+}
+
+func sanitizeCommitAuthor(s string) string {
+	return strings.TrimSpace(strings.NewReplacer("<", "", ">", "").Replace(s))
+}
+
+// CommitAuthorArgs prepares tx so that the commits of a tool call are
+// authored by its caller, and returns the arguments to pass to DOLT_COMMIT
+// or DOLT_MERGE.
+func CommitAuthorArgs(ctx context.Context, server pkg.Server, tx db.DatabaseTransaction, request mcp.CallToolRequest) ([]string, error) {
+	name, email, ok, err := commitAuthor(ctx, server, request)
+	if err != nil || !ok {
+		return nil, err
+	}
+	statements, args := server.Dialect().CommitAuthor(name, email)
+	for _, statement := range statements {
+		if err := tx.ExecContext(ctx, statement); err != nil {
+			return nil, fmt.Errorf("failed to set commit author: %w", err)
+		}
+	}
+	return args, nil
+}
@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+)
+
+func TestCommitAuthor(t *testing.T) {
+	alice := &pkg.Identity{Subject: "alice-id", Claims: map[string]any{"name": "Alice Smith", "email": "alice@example.com", "roles": []any{"admin"}}}
+	bot := &pkg.Identity{Subject: "etl-bot"}
+	authorization := &pkg.Authorization{AuthorOverrideRoles: []string{"admin"}}
+
+	tests := []struct {
+		description   string
+		identity      *pkg.Identity
+		authorization *pkg.Authorization
+		author        string
+		name, email   string
+		ok, err       bool
+	}{
+		{"anonymous without author", nil, nil, "", "", "", false, false},
+		{"anonymous with author", nil, nil, "Bob <bob@example.com>", "Bob", "bob@example.com", true, false},
+		{"identity claims", alice, nil, "", "Alice Smith", "alice@example.com", true, false},
+		{"subject only", bot, nil, "", "etl-bot", "etl-bot", true, false},
+		{"override not permitted", bot, authorization, "Bob <bob@example.com>", "", "", false, true},
+		{"override permitted", alice, authorization, "Bob <bob@example.com>", "Bob", "bob@example.com", true, false},
+		{"invalid author", nil, nil, "Bob", "", "", false, true},
+	}
+	for _, test := range tests {
+		ctx := context.Background()
+		if test.identity != nil {
+			ctx = pkg.ContextWithIdentity(ctx, test.identity)
+		}
+		s := &fakeServer{settings: pkg.ToolSettings{Authorization: test.authorization}}
+		args := map[string]any{}
+		if test.author != "" {
+			args[AuthorCallToolArgumentName] = test.author
+		}
+		name, email, ok, err := commitAuthor(ctx, s, callToolRequest(args))
+		if (err != nil) != test.err {
+			t.Fatalf("%s: unexpected error %v", test.description, err)
+		}
+		if name != test.name || email != test.email || ok != test.ok {
+			t.Fatalf("%s: got %q <%q> ok=%v", test.description, name, email, ok)
+		}
+	}
+}
+
+func TestCommitAuthorArgs(t *testing.T) {
+	ctx := pkg.ContextWithIdentity(context.Background(), &pkg.Identity{Subject: "alice", Claims: map[string]any{"email": "alice@example.com"}})
+	args, err := CommitAuthorArgs(ctx, &fakeServer{}, &fakeTransaction{}, callToolRequest(map[string]any{}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(args) != 2 || args[0] != "--author" || args[1] != "alice <alice@example.com>" {
+		t.Fatalf("unexpected author arguments: %v", args)
+	}
+}
@@ -38,6 +38,10 @@ func NewCreateDoltCommitTool() mcp.Tool {
 			mcp.Required(),
 			mcp.Description(CreateDoltCommitToolMessageArgumentDescription),
 		),
+		mcp.WithString(
+			AuthorCallToolArgumentName,
+			mcp.Description(AuthorCallToolArgumentDescription),
+		),
 	)
 }
 
@@ -85,7 +89,14 @@ func RegisterCreateDoltCommitTool(server pkg.Server) {
 			}
 		}()
 
-		err = tx.ExecContext(ctx, dialect.CallProcedure(db.DoltCommit, "-m", message))
+		var authorArgs []string
+		authorArgs, err = CommitAuthorArgs(ctx, server, tx, request)
+		if err != nil {
+			result = mcp.NewToolResultError(err.Error())
+			return
+		}
+
+		err = tx.ExecContext(ctx, dialect.CallProcedure(db.DoltCommit, append([]string{"-m", message}, authorArgs...)...))
 		if err != nil {
 			result = mcp.NewToolResultError(err.Error())
 			return
@@ -76,6 +76,12 @@ func RegisterCreateTableTool(server pkg.Server) {
 			return
 		}
 
+		err = dialect.ValidateQueryPolicy(createTableStatement, workingDatabase, server.ToolSettings().SQLPolicy)
+		if err != nil {
+			result = mcp.NewToolResultError(err.Error())
+			return
+		}
+
 		config := server.DBConfig()
 
 		var tx db.DatabaseTransaction
@@ -0,0 +1,34 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// databaseCredentialsMiddleware makes every tool connect to the database as
+// the caller's database user. Calls from callers without one return a tool
+// error when database users are required.
+func databaseCredentialsMiddleware(s pkg.Server, tool *mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return withDatabaseCredentialsHandler(s, next)
+}
+
+func withDatabaseCredentialsHandler(s pkg.Server, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		credentials := s.ToolSettings().DatabaseCredentials
+		if credentials == nil {
+			return next(ctx, request)
+		}
+		userCredentials, ok, err := credentials.Credentials(pkg.IdentityFromContext(ctx))
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if ok {
+			ctx = db.ContextWithCredentials(ctx, userCredentials)
+		}
+		return next(ctx, request)
+	}
+}
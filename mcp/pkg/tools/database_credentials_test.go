@@ -0,0 +1,36 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestDatabaseCredentialsMiddleware(t *testing.T) {
+	credentials := &pkg.DatabaseCredentials{
+		Users:    map[string]pkg.DatabaseUser{"alice": {Issuer: pkg.APIKeyIssuer, User: "alice_db", Password: "secret"}},
+		Required: true,
+	}
+	var seen db.Credentials
+	s := newTestServer(&fakeServer{settings: pkg.ToolSettings{DatabaseCredentials: credentials}}, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		seen, _ = db.CredentialsFromContext(ctx)
+		return mcp.NewToolResultText("ok"), nil
+	}, NewQueryTool())
+
+	ctx := pkg.ContextWithIdentity(context.Background(), &pkg.Identity{Subject: "alice", Issuer: pkg.APIKeyIssuer})
+	res, err := s.call(ctx, QueryToolName, nil)
+	if err != nil || res.IsError {
+		t.Fatalf("expected call to succeed, got result=%+v err=%v", res, err)
+	}
+	if seen.User != "alice_db" || seen.Password != "secret" {
+		t.Fatalf("expected alice's database credentials, got %+v", seen)
+	}
+
+	res, err = s.call(context.Background(), QueryToolName, nil)
+	if err != nil || !res.IsError {
+		t.Fatalf("expected anonymous call to fail, got result=%+v err=%v", res, err)
+	}
+}
@@ -6,14 +6,33 @@ import (
 	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
 )
 
+// CommitTransactionOrRollbackOnError commits tx if err is nil and rolls it
+// back otherwise. During a dry run a successful transaction is rolled back
+// too, after its pending changes are recorded as the call's preview.
 func CommitTransactionOrRollbackOnError(ctx context.Context, tx db.DatabaseTransaction, err error) error {
 	if err == nil {
+		if dr := dryRunFromContext(ctx); dr != nil {
+			return rollbackDryRun(ctx, tx, dr)
+		}
 		return tx.Commit(ctx)
 	}
 	tx.Rollback(ctx)
 	return err
 }
 
+func rollbackDryRun(ctx context.Context, tx db.DatabaseTransaction, dr *dryRun) error {
+	sections, err := previewPendingChanges(ctx, tx)
+	rerr := tx.Rollback(ctx)
+	if err != nil {
+		return err
+	}
+	if rerr != nil {
+		return rerr
+	}
+	dr.plan = &WritePlan{Sections: sections}
+	return nil
+}
+
 func NewDatabaseTransactionOnBranch(ctx context.Context, config db.Config, dialect db.Dialect, branch string) (db.DatabaseTransaction, error) {
 	tx, err := db.NewDatabaseTransaction(ctx, config)
 	if err != nil {
@@ -0,0 +1,31 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// deniedBranchesMiddleware refuses calls that may write and run on or change
+// a branch the SQL policy denies, such as the audit branch. The SQL of a
+// call is checked against the denied branches with the rest of the policy.
+func deniedBranchesMiddleware(s pkg.Server, tool *mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return withDeniedBranchesHandler(s, *tool, next)
+}
+
+func withDeniedBranchesHandler(s pkg.Server, tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		policy := s.ToolSettings().SQLPolicy
+		if policy == nil || !mayWrite(s.Dialect(), tool, request) {
+			return next(ctx, request)
+		}
+		for _, branch := range ToolAccessFromRequest(tool, request).Branches {
+			if err := policy.CheckBranch(branch); err != nil {
+				return mcp.NewToolResultError(err.Error()), nil
+			}
+		}
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,41 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+)
+
+func TestDeniedBranchesMiddleware(t *testing.T) {
+	s := newTestServer(&fakeServer{settings: pkg.ToolSettings{
+		SQLPolicy: (&db.SQLPolicy{}).WithDeniedBranches("mcp_audit"),
+	}}, okHandler, NewDeleteDoltBranchTool(), NewMoveDoltBranchTool(), NewDoltResetHardTool(), NewListDoltBranchesTool(), NewExecTool())
+	ctx := context.Background()
+
+	for _, test := range []struct {
+		tool string
+		args map[string]any
+	}{
+		{DeleteDoltBranchToolName, map[string]any{WorkingBranchCallToolArgumentName: "main", BranchCallToolArgumentName: "mcp_audit"}},
+		{MoveDoltBranchToolName, map[string]any{WorkingBranchCallToolArgumentName: "main", OldNameCallToolArgumentName: "mcp_audit", NewNameCallToolArgumentName: "old"}},
+		{MoveDoltBranchToolName, map[string]any{WorkingBranchCallToolArgumentName: "main", OldNameCallToolArgumentName: "feature", NewNameCallToolArgumentName: "mcp_audit"}},
+		{DoltResetHardToolName, map[string]any{WorkingBranchCallToolArgumentName: "mcp_audit"}},
+		{ExecToolName, map[string]any{WorkingDatabaseCallToolArgumentName: "mydb/mcp_audit", WorkingBranchCallToolArgumentName: "main"}},
+	} {
+		res, err := s.call(ctx, test.tool, test.args)
+		if err != nil || !res.IsError {
+			t.Fatalf("expected %s %v to be refused, got result=%+v err=%v", test.tool, test.args, res, err)
+		}
+	}
+
+	res, err := s.call(ctx, ListDoltBranchesToolName, map[string]any{WorkingBranchCallToolArgumentName: "mcp_audit"})
+	if err != nil || res.IsError {
+		t.Fatalf("expected reads on the branch to be allowed, got result=%+v err=%v", res, err)
+	}
+	res, err = s.call(ctx, DoltResetHardToolName, map[string]any{WorkingBranchCallToolArgumentName: "main"})
+	if err != nil || res.IsError {
+		t.Fatalf("expected other branches to be allowed, got result=%+v err=%v", res, err)
+	}
+}
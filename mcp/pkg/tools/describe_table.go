@@ -70,6 +70,12 @@ func RegisterDescribeTableTool(server pkg.Server) {
 			return
 		}
 
+		err = server.ToolSettings().SQLPolicy.CheckTable(workingDatabase, tableToDescribe, true)
+		if err != nil {
+			result = mcp.NewToolResultError(err.Error())
+			return
+		}
+
 		dialect := server.Dialect()
 		config := server.DBConfig()
 
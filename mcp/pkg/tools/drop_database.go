@@ -15,6 +15,9 @@ const (
 	DropDatabaseToolSQLQueryFormatString         = "DROP DATABASE %s;"
 	DropDatabaseIfExistsToolSQLQueryFormatString = "DROP DATABASE IF EXISTS %s;"
 	DropDatabaseToolDescription                  = "Drops a database in the Dolt server."
+	DropDatabaseToolPlanSummaryFormatString      = "database %s and all of its branches will be dropped"
+	DropDatabaseToolPlanTablesTitle              = "tables on the default branch"
+	DropDatabaseToolPlanStatusTitle              = "uncommitted changes that will be lost"
 	DropDatabaseToolCallSuccessFormatString      = "successfully dropped database: %s"
 	DropDatabaseToolIfExistsArgumentDescription  = "If true will only drop the specified database if it exists in the Dolt server."
 )
@@ -36,6 +39,10 @@ func NewDropDatabaseTool() mcp.Tool {
 			IfExistsCallToolArgumentName,
 			mcp.Description(DropDatabaseToolIfExistsArgumentDescription),
 		),
+		mcp.WithString(
+			ApplyTokenCallToolArgumentName,
+			mcp.Description(ApplyTokenCallToolArgumentDescription),
+		),
 	)
 }
 
@@ -63,6 +70,15 @@ func RegisterDropDatabaseTool(server pkg.Server) {
 		}
 
 		config := server.DBConfig()
+
+		write := pkg.PendingWrite{Tool: DropDatabaseToolName, Database: databaseToDrop, Statement: query}
+		result = ConfirmWrite(ctx, server, request, write, func(ctx context.Context) (WritePlan, error) {
+			return PlanDropDatabase(ctx, config, dialect, databaseToDrop)
+		})
+		if result != nil {
+			return
+		}
+
 		var tx db.DatabaseTransaction
 		tx, err = db.NewDatabaseTransaction(ctx, config)
 		if err != nil {
@@ -87,3 +103,37 @@ func RegisterDropDatabaseTool(server pkg.Server) {
 		return
 	})
 }
+
+// PlanDropDatabase previews dropping database. Dropping a database cannot be
+// rolled back, so instead of running the statement it reports the tables and
+// uncommitted changes that would be lost.
+func PlanDropDatabase(ctx context.Context, config db.Config, dialect db.Dialect, database string) (plan WritePlan, err error) {
+	var tx db.DatabaseTransaction
+	tx, err = NewDatabaseTransactionUsingDatabase(ctx, config, dialect, database)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		tx.Rollback(ctx)
+	}()
+
+	var tables string
+	tables, err = tx.QueryContext(ctx, dialect.ShowTablesQuery(), db.ResultFormatMarkdown)
+	if err != nil {
+		return
+	}
+
+	var status string
+	status, err = tx.QueryContext(ctx, DoltStatusSQLQuery, db.ResultFormatMarkdown)
+	if err != nil {
+		return
+	}
+
+	plan.Summary = fmt.Sprintf(DropDatabaseToolPlanSummaryFormatString, database)
+	plan.Sections = []WritePlanSection{
+		{Title: DropDatabaseToolPlanTablesTitle, Table: tables},
+		{Title: DropDatabaseToolPlanStatusTitle, Table: status},
+	}
+	return
+}
@@ -46,6 +46,10 @@ func NewDropTableTool() mcp.Tool {
 			IfExistsCallToolArgumentName,
 			mcp.Description(DropTableToolIfExistsArgumentDescription),
 		),
+		mcp.WithString(
+			ApplyTokenCallToolArgumentName,
+			mcp.Description(ApplyTokenCallToolArgumentDescription),
+		),
 	)
 }
 
@@ -86,8 +90,22 @@ func RegisterDropTableTool(server pkg.Server) {
 			query = fmt.Sprintf(DropTableToolSQLQueryFormatString, dialect.QuoteIdentifier(tableToDrop))
 		}
 
+		err = dialect.ValidateQueryPolicy(query, workingDatabase, server.ToolSettings().SQLPolicy)
+		if err != nil {
+			result = mcp.NewToolResultError(err.Error())
+			return
+		}
+
 		config := server.DBConfig()
 
+		write := pkg.PendingWrite{Tool: DropTableToolName, Database: workingDatabase, Branch: workingBranch, Statement: query}
+		result = ConfirmWrite(ctx, server, request, write, func(ctx context.Context) (WritePlan, error) {
+			return PlanWrite(ctx, config, dialect, workingDatabase, workingBranch, query)
+		})
+		if result != nil {
+			return
+		}
+
 		var tx db.DatabaseTransaction
 		tx, err = NewDatabaseTransactionUsingDatabaseOnBranch(ctx, config, dialect, workingDatabase, workingBranch)
 		if err != nil {
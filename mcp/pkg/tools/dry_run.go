@@ -0,0 +1,141 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	DryRunCallToolArgumentName        = "dry_run"
+	DryRunCallToolArgumentDescription = "If true, the tool does all of its work in a transaction, reports the resulting dolt_status and working set diff, and then rolls back instead of committing."
+
+	dryRunResultFormatString         = "dry run: no changes were committed.\n\n%s"
+	dryRunUnsupportedFormatString    = "%s does not support dry_run: its changes cannot be rolled back"
+	dryRunStatementFormatString      = "%s does not support dry_run of %s statements: their changes cannot be rolled back"
+	dryRunNotCompletedFormatString   = "dry run: %s did not reach its commit, no preview is available"
+	dryRunPreviewSummaryFormatString = "%s would make the following changes"
+)
+
+// nonTransactionalTools lists mutating tools whose effects are not undone by
+// rolling back their transaction, such as commits, changes to branch refs,
+// remotes, or whole databases, and DDL, which commits implicitly. Tools that
+// reach outside the server (OpenWorldHint) are excluded as well; all of them
+// reject dry_run rather than run without a preview.
+var nonTransactionalTools = map[string]bool{
+	CreateDatabaseToolName:               true,
+	DropDatabaseToolName:                 true,
+	KillProcessToolName:                  true,
+	CreateDoltBranchToolName:             true,
+	CreateDoltBranchFromHeadToolName:     true,
+	MoveDoltBranchToolName:               true,
+	DeleteDoltBranchToolName:             true,
+	AddDoltRemoteToolName:                true,
+	RemoveDoltRemoteToolName:             true,
+	CreateDoltCommitToolName:             true,
+	MergeDoltBranchToolName:              true,
+	MergeDoltBranchNoFastForwardToolName: true,
+	DoltResetSoftToolName:                true,
+	DoltResetHardToolName:                true,
+	CreateTableToolName:                  true,
+	AlterTableToolName:                   true,
+	DropTableToolName:                    true,
+}
+
+type dryRunKey struct{}
+
+// dryRun collects the preview of a dry-run tool call.
+type dryRun struct {
+	plan *WritePlan
+}
+
+func withDryRun(ctx context.Context) (context.Context, *dryRun) {
+	dr := &dryRun{}
+	return context.WithValue(ctx, dryRunKey{}, dr), dr
+}
+
+func dryRunFromContext(ctx context.Context) *dryRun {
+	dr, _ := ctx.Value(dryRunKey{}).(*dryRun)
+	return dr
+}
+
+// IsDryRun reports whether ctx belongs to a tool call made with dry_run.
+func IsDryRun(ctx context.Context) bool {
+	return dryRunFromContext(ctx) != nil
+}
+
+func isMutatingTool(tool mcp.Tool) bool {
+	return tool.Annotations.ReadOnlyHint == nil || !*tool.Annotations.ReadOnlyHint
+}
+
+func supportsDryRun(tool mcp.Tool) bool {
+	if nonTransactionalTools[tool.Name] {
+		return false
+	}
+	return tool.Annotations.OpenWorldHint == nil || !*tool.Annotations.OpenWorldHint
+}
+
+// dryRunMiddleware adds the dry_run argument to every tool that is not
+// read-only. A dry-run call runs the tool unchanged, and
+// CommitTransactionOrRollbackOnError rolls its transaction back instead of
+// committing it. Tools whose changes cannot be rolled back reject dry_run, and
+// so do tools running SQL, such as exec, when a statement commits implicitly.
+func dryRunMiddleware(s pkg.Server, tool *mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	if !isMutatingTool(*tool) {
+		return next
+	}
+	mcp.WithBoolean(
+		DryRunCallToolArgumentName,
+		mcp.Description(DryRunCallToolArgumentDescription),
+	)(tool)
+	return withDryRunHandler(s, *tool, next)
+}
+
+func withDryRunHandler(s pkg.Server, tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if !GetBooleanArgumentFromCallToolRequest(request, DryRunCallToolArgumentName) {
+			return next(ctx, request)
+		}
+		if !supportsDryRun(tool) {
+			return mcp.NewToolResultError(fmt.Sprintf(dryRunUnsupportedFormatString, tool.Name)), nil
+		}
+		if kind, err := implicitCommitStatementKind(s.Dialect(), request); err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		} else if kind != "" {
+			return mcp.NewToolResultError(fmt.Sprintf(dryRunStatementFormatString, tool.Name, kind)), nil
+		}
+
+		ctx, dr := withDryRun(ctx)
+		result, err := next(ctx, request)
+		if err != nil || result == nil || result.IsError {
+			return result, err
+		}
+		if dr.plan == nil {
+			return mcp.NewToolResultError(fmt.Sprintf(dryRunNotCompletedFormatString, tool.Name)), nil
+		}
+		dr.plan.Summary = fmt.Sprintf(dryRunPreviewSummaryFormatString, tool.Name)
+		return mcp.NewToolResultText(fmt.Sprintf(dryRunResultFormatString, dr.plan.String())), nil
+	}
+}
+
+// implicitCommitStatementKind returns the kind of the first statement in the
+// query or statements argument of request that commits implicitly, or "" when
+// there is none.
+func implicitCommitStatementKind(dialect db.Dialect, request mcp.CallToolRequest) (string, error) {
+	for _, query := range sqlArgumentsFromRequest(request) {
+		kinds, err := dialect.StatementKinds(query)
+		if err != nil {
+			return "", err
+		}
+		for _, kind := range kinds {
+			if db.CommitsImplicitly(kind) {
+				return kind, nil
+			}
+		}
+	}
+	return "", nil
+}
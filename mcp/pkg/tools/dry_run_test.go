@@ -0,0 +1,120 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func newDryRunTestServer(tx *fakeTransaction) *fakeServer {
+	handler := func(ctx context.Context, request mcp.CallToolRequest) (result *mcp.CallToolResult, serverErr error) {
+		var err error
+		defer func() {
+			rerr := CommitTransactionOrRollbackOnError(ctx, tx, err)
+			if rerr != nil {
+				result = mcp.NewToolResultError(rerr.Error())
+			}
+		}()
+		result = mcp.NewToolResultText("successfully wrote")
+		return
+	}
+	return newTestServer(&fakeServer{}, handler,
+		mcp.NewTool("write", mcp.WithReadOnlyHintAnnotation(false), mcp.WithOpenWorldHintAnnotation(false)),
+		mcp.NewTool("read", mcp.WithReadOnlyHintAnnotation(true)),
+		mcp.NewTool("push", mcp.WithReadOnlyHintAnnotation(false), mcp.WithOpenWorldHintAnnotation(true)),
+		mcp.NewTool(CreateDoltBranchToolName, mcp.WithReadOnlyHintAnnotation(false), mcp.WithOpenWorldHintAnnotation(false)),
+		NewCreateDoltCommitTool(),
+		NewMergeDoltBranchTool(),
+		NewDoltResetHardTool(),
+		NewAlterTableTool(),
+		NewExecTool(),
+		NewExecScriptTool(),
+	)
+}
+
+func TestDryRunMiddleware_OnlyMutatingTools(t *testing.T) {
+	s := newDryRunTestServer(&fakeTransaction{})
+	if _, ok := s.mcp.GetTool("write").Tool.InputSchema.Properties[DryRunCallToolArgumentName]; !ok {
+		t.Fatalf("expected mutating tool to accept %s", DryRunCallToolArgumentName)
+	}
+	if _, ok := s.mcp.GetTool("read").Tool.InputSchema.Properties[DryRunCallToolArgumentName]; ok {
+		t.Fatalf("expected read-only tool not to accept %s", DryRunCallToolArgumentName)
+	}
+}
+
+func TestDryRun_RollsBackAndReportsChanges(t *testing.T) {
+	tx := &fakeTransaction{}
+	s := newDryRunTestServer(tx)
+
+	res, err := s.call(context.Background(), "write", map[string]any{DryRunCallToolArgumentName: true})
+	if err != nil || res == nil || res.IsError {
+		t.Fatalf("expected dry run to succeed, got result=%+v err=%v", res, err)
+	}
+	if tx.committed || !tx.rolledBack {
+		t.Fatalf("expected dry run to roll back without committing, committed=%v rolledBack=%v", tx.committed, tx.rolledBack)
+	}
+	text := res.Content[0].(mcp.TextContent).Text
+	if !strings.HasPrefix(text, "dry run:") || !strings.Contains(text, "people | modified") {
+		t.Fatalf("expected dry run preview, got %q", text)
+	}
+}
+
+func TestDryRun_CommitsWhenNotRequested(t *testing.T) {
+	tx := &fakeTransaction{}
+	s := newDryRunTestServer(tx)
+
+	res, err := s.call(context.Background(), "write", nil)
+	if err != nil || res == nil || res.IsError {
+		t.Fatalf("expected call to succeed, got result=%+v err=%v", res, err)
+	}
+	if !tx.committed {
+		t.Fatalf("expected transaction to be committed")
+	}
+}
+
+func TestDryRun_RejectedForToolsThatCannotRollBack(t *testing.T) {
+	for _, name := range []string{"push", CreateDoltBranchToolName, CreateDoltCommitToolName, MergeDoltBranchToolName, DoltResetHardToolName, AlterTableToolName} {
+		t.Run(name, func(t *testing.T) {
+			tx := &fakeTransaction{}
+			s := newDryRunTestServer(tx)
+
+			res, err := s.call(context.Background(), name, map[string]any{DryRunCallToolArgumentName: true})
+			if err != nil || res == nil || !res.IsError {
+				t.Fatalf("expected dry run to be rejected, got result=%+v err=%v", res, err)
+			}
+			if tx.committed || tx.rolledBack {
+				t.Fatalf("expected tool not to run")
+			}
+		})
+	}
+}
+
+func TestDryRun_RejectedForStatementsThatCommitImplicitly(t *testing.T) {
+	for name, arguments := range map[string]map[string]any{
+		ExecToolName:       {QueryCallToolArgumentName: "CALL DOLT_COMMIT('-am', 'sneaky')"},
+		ExecScriptToolName: {StatementsCallToolArgumentName: []any{"INSERT INTO people VALUES (1)", "ALTER TABLE people ADD COLUMN age INT"}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tx := &fakeTransaction{}
+			s := newDryRunTestServer(tx)
+
+			arguments[DryRunCallToolArgumentName] = true
+			res, err := s.call(context.Background(), name, arguments)
+			if err != nil || res == nil || !res.IsError {
+				t.Fatalf("expected dry run to be rejected, got result=%+v err=%v", res, err)
+			}
+			if tx.committed || tx.rolledBack {
+				t.Fatalf("expected tool not to run")
+			}
+		})
+	}
+
+	tx := &fakeTransaction{}
+	s := newDryRunTestServer(tx)
+	res, err := s.call(context.Background(), ExecToolName, map[string]any{QueryCallToolArgumentName: "UPDATE people SET name = 'a'", DryRunCallToolArgumentName: true})
+	if err != nil || res == nil || res.IsError || !tx.rolledBack {
+		t.Fatalf("expected a dry run of DML to be rolled back, got result=%+v err=%v", res, err)
+	}
+}
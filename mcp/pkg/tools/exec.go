@@ -38,6 +38,10 @@ func NewExecTool() mcp.Tool {
 			mcp.Required(),
 			mcp.Description(ExecToolQueryArgumentDescription),
 		),
+		mcp.WithString(
+			ApplyTokenCallToolArgumentName,
+			mcp.Description(ApplyTokenCallToolArgumentDescription),
+		),
 	)
 }
 
@@ -76,8 +80,22 @@ func RegisterExecTool(server pkg.Server) {
 			return
 		}
 
+		err = dialect.ValidateQueryPolicy(query, workingDatabase, server.ToolSettings().SQLPolicy)
+		if err != nil {
+			result = mcp.NewToolResultError(err.Error())
+			return
+		}
+
 		config := server.DBConfig()
 
+		write := pkg.PendingWrite{Tool: ExecToolName, Database: workingDatabase, Branch: workingBranch, Statement: query}
+		result = ConfirmWrite(ctx, server, request, write, func(ctx context.Context) (WritePlan, error) {
+			return PlanWrite(ctx, config, dialect, workingDatabase, workingBranch, query)
+		})
+		if result != nil {
+			return
+		}
+
 		var tx db.DatabaseTransaction
 		tx, err = NewDatabaseTransactionUsingDatabaseOnBranch(ctx, config, dialect, workingDatabase, workingBranch)
 		if err != nil {
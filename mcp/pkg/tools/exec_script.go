@@ -0,0 +1,161 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	ExecScriptToolName                          = "exec_script"
+	ExecScriptToolStatementsArgumentDescription = "The statements to run, in order. Each entry must be a single statement."
+	ExecScriptToolDescription                   = "Executes a list of statements in a single transaction. Either every statement is committed or none are. Reports the result of each statement."
+	ExecScriptToolCallSuccessMessage            = "successfully executed script"
+
+	execScriptInvalidStatementFormatString = "statement %d: %w"
+	execScriptFailedStatementFormatString  = "statement %d failed, no changes were committed: %w"
+	execScriptResultFormatString           = "statement %d: %s\n%s"
+)
+
+func NewExecScriptTool() mcp.Tool {
+	return mcp.NewTool(
+		ExecScriptToolName,
+		mcp.WithDescription(ExecScriptToolDescription),
+		mcp.WithReadOnlyHintAnnotation(false),
+		mcp.WithDestructiveHintAnnotation(true),
+		mcp.WithIdempotentHintAnnotation(false),
+		mcp.WithOpenWorldHintAnnotation(false),
+		mcp.WithString(
+			WorkingDatabaseCallToolArgumentName,
+			mcp.Required(),
+			mcp.Description(WorkingDatabaseCallToolArgumentDescription),
+		),
+		mcp.WithString(
+			WorkingBranchCallToolArgumentName,
+			mcp.Required(),
+			mcp.Description(WorkingBranchCallToolArgumentDescription),
+		),
+		mcp.WithArray(
+			StatementsCallToolArgumentName,
+			mcp.Required(),
+			mcp.Description(ExecScriptToolStatementsArgumentDescription),
+			mcp.WithStringItems(),
+		),
+		mcp.WithString(
+			ApplyTokenCallToolArgumentName,
+			mcp.Description(ApplyTokenCallToolArgumentDescription),
+		),
+	)
+}
+
+// scriptStatement is a validated statement of a script. Reads report their
+// rows and writes report the number of rows they affected.
+type scriptStatement struct {
+	query string
+	read  bool
+}
+
+// validateScript checks every statement of a script before any of them run.
+func validateScript(server pkg.Server, workingDatabase string, statements []string) ([]scriptStatement, error) {
+	dialect := server.Dialect()
+	script := make([]scriptStatement, len(statements))
+	for i, query := range statements {
+		read := dialect.ValidateReadQuery(query) == nil
+		if !read {
+			if err := dialect.ValidateWriteQuery(query); err != nil {
+				return nil, fmt.Errorf(execScriptInvalidStatementFormatString, i+1, err)
+			}
+		}
+		if err := dialect.ValidateQueryPolicy(query, workingDatabase, server.ToolSettings().SQLPolicy); err != nil {
+			return nil, fmt.Errorf(execScriptInvalidStatementFormatString, i+1, err)
+		}
+		script[i] = scriptStatement{query: query, read: read}
+	}
+	return script, nil
+}
+
+func RegisterExecScriptTool(server pkg.Server) {
+	mcpServer := server.MCP()
+	execScriptTool := NewExecScriptTool()
+
+	mcpServer.AddTool(execScriptTool, func(ctx context.Context, request mcp.CallToolRequest) (result *mcp.CallToolResult, serverErr error) {
+		var err error
+		var workingBranch string
+		workingBranch, err = GetRequiredStringArgumentFromCallToolRequest(request, WorkingBranchCallToolArgumentName)
+		if err != nil {
+			result = mcp.NewToolResultError(err.Error())
+			return
+		}
+
+		var workingDatabase string
+		workingDatabase, err = GetRequiredStringArgumentFromCallToolRequest(request, WorkingDatabaseCallToolArgumentName)
+		if err != nil {
+			result = mcp.NewToolResultError(err.Error())
+			return
+		}
+
+		var statements []string
+		statements, err = GetRequiredStringArrayArgumentFromCallToolRequest(request, StatementsCallToolArgumentName)
+		if err != nil {
+			result = mcp.NewToolResultError(err.Error())
+			return
+		}
+
+		var script []scriptStatement
+		script, err = validateScript(server, workingDatabase, statements)
+		if err != nil {
+			result = mcp.NewToolResultError(err.Error())
+			return
+		}
+
+		dialect := server.Dialect()
+		config := server.DBConfig()
+
+		write := pkg.PendingWrite{Tool: ExecScriptToolName, Database: workingDatabase, Branch: workingBranch, Statement: strings.Join(statements, "\n")}
+		result = ConfirmWrite(ctx, server, request, write, func(ctx context.Context) (WritePlan, error) {
+			return PlanWrite(ctx, config, dialect, workingDatabase, workingBranch, statements...)
+		})
+		if result != nil {
+			return
+		}
+
+		var tx db.DatabaseTransaction
+		tx, err = NewDatabaseTransactionUsingDatabaseOnBranch(ctx, config, dialect, workingDatabase, workingBranch)
+		if err != nil {
+			result = mcp.NewToolResultError(err.Error())
+			return
+		}
+
+		defer func() {
+			rerr := CommitTransactionOrRollbackOnError(ctx, tx, err)
+			if rerr != nil {
+				result = mcp.NewToolResultError(rerr.Error())
+			}
+		}()
+
+		results := make([]string, len(script))
+		for i, stmt := range script {
+			var output string
+			if stmt.read {
+				output, err = tx.QueryContext(ctx, stmt.query, db.ResultFormatMarkdown)
+			} else {
+				var rowsAffected int64
+				rowsAffected, err = tx.ExecContextRowsAffected(ctx, stmt.query)
+				output = fmt.Sprintf(writePlanRowsAffectedFormat, rowsAffected) + "\n"
+			}
+			if err != nil {
+				err = fmt.Errorf(execScriptFailedStatementFormatString, i+1, err)
+				result = mcp.NewToolResultError(err.Error())
+				return
+			}
+			results[i] = fmt.Sprintf(execScriptResultFormatString, i+1, stmt.query, output)
+		}
+
+		result = mcp.NewToolResultText(ExecScriptToolCallSuccessMessage + "\n\n" + strings.Join(results, "\n"))
+		return
+	})
+}
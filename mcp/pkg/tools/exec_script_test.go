@@ -0,0 +1,42 @@
+package tools
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+)
+
+func TestValidateScript_ClassifiesStatements(t *testing.T) {
+	script, err := validateScript(&fakeServer{}, "db", []string{
+		"INSERT INTO t VALUES (1)",
+		"SELECT * FROM t",
+		"UPDATE t SET id = 2",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error validating script: %v", err)
+	}
+	if script[0].read || !script[1].read || script[2].read {
+		t.Fatalf("unexpected statement classification: %+v", script)
+	}
+}
+
+func TestValidateScript_RejectsMultipleStatementsInOneEntry(t *testing.T) {
+	_, err := validateScript(&fakeServer{}, "db", []string{
+		"INSERT INTO t VALUES (1)",
+		"SELECT 1; DROP TABLE t",
+	})
+	if !errors.Is(err, db.ErrMultipleSQLStatements) {
+		t.Fatalf("expected ErrMultipleSQLStatements, got %v", err)
+	}
+	if !strings.HasPrefix(err.Error(), "statement 2:") {
+		t.Fatalf("expected error to name the offending statement, got %q", err)
+	}
+}
+
+func TestValidateScript_RejectsInvalidSQL(t *testing.T) {
+	if _, err := validateScript(&fakeServer{}, "db", []string{"this is not sql"}); err == nil {
+		t.Fatalf("expected invalid SQL to be rejected")
+	}
+}
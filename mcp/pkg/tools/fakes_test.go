@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+type fakeServer struct {
+	mcp      *server.MCPServer
+	dbConfig db.Config
+	settings pkg.ToolSettings
+}
+
+func (f *fakeServer) MCP() *server.MCPServer         { return f.mcp }
+func (f *fakeServer) DBConfig() db.Config            { return f.dbConfig }
+func (f *fakeServer) Dialect() db.Dialect            { return db.NewDialect(db.DialectMySQL) }
+func (f *fakeServer) ToolSettings() pkg.ToolSettings { return f.settings }
+
+// newTestServer serves tools on s, each with handler, wrapped in the tool
+// middlewares as RegisterToolMiddlewares wraps them for clients. Handlers
+// tell the tools apart by the name of the request.
+func newTestServer(s *fakeServer, handler server.ToolHandlerFunc, tools ...mcp.Tool) *fakeServer {
+	if s.mcp == nil {
+		s.mcp = server.NewMCPServer("test", "0.0.0")
+	}
+	for _, tool := range tools {
+		s.mcp.AddTool(tool, handler)
+	}
+	RegisterToolMiddlewares(s)
+	return s
+}
+
+// call calls the tool named name with args, as a client would.
+func (f *fakeServer) call(ctx context.Context, name string, args map[string]any) (*mcp.CallToolResult, error) {
+	request := callToolRequest(args)
+	request.Params.Name = name
+	return f.mcp.GetTool(name).Handler(ctx, request)
+}
+
+// okHandler is the handler of tools that always succeed.
+func okHandler(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	return mcp.NewToolResultText("ok"), nil
+}
+
+type fakeTransaction struct {
+	committed  bool
+	rolledBack bool
+}
+
+func (f *fakeTransaction) QueryContext(ctx context.Context, query string, resultFormat db.ResultFormat) (string, error) {
+	return "table_name | status\n--- | ---\npeople | modified\n", nil
+}
+
+func (f *fakeTransaction) ExecContext(ctx context.Context, query string) error {
+	return nil
+}
+
+func (f *fakeTransaction) ExecContextRowsAffected(ctx context.Context, query string) (int64, error) {
+	return 1, nil
+}
+
+func (f *fakeTransaction) Rollback(ctx context.Context) error {
+	f.rolledBack = true
+	return nil
+}
+
+func (f *fakeTransaction) Commit(ctx context.Context) error {
+	f.committed = true
+	return nil
+}
+
+func callToolRequest(args map[string]any) mcp.CallToolRequest {
+	return mcp.CallToolRequest{Params: mcp.CallToolParams{Arguments: args}}
+}
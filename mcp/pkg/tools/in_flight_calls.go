@@ -0,0 +1,31 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// inFlightCallsMiddleware tracks every call of every tool in the server's
+// in-flight calls, so that shutdown waits for it, and refuses calls once the
+// server is shutting down.
+func inFlightCallsMiddleware(s pkg.Server, tool *mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return withInFlightCallsHandler(s, *tool, next)
+}
+
+func withInFlightCallsHandler(s pkg.Server, tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		calls := s.ToolSettings().InFlightCalls
+		if calls == nil {
+			return next(ctx, request)
+		}
+		ctx, finish, err := calls.Start(ctx, tool.Name)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		defer finish()
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,33 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+func TestInFlightCallsMiddleware(t *testing.T) {
+	calls := pkg.NewInFlightCalls(zap.NewNop())
+	running := 0
+	s := newTestServer(&fakeServer{settings: pkg.ToolSettings{InFlightCalls: calls}}, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		running = calls.Len()
+		return mcp.NewToolResultText("ok"), nil
+	}, NewQueryTool())
+
+	res, err := s.call(context.Background(), QueryToolName, nil)
+	if err != nil || res.IsError {
+		t.Fatalf("expected the wrapped tool result, got result=%+v err=%v", res, err)
+	}
+	if running != 1 || calls.Len() != 0 {
+		t.Fatalf("expected the call to be tracked while it ran, got %d while running and %d after", running, calls.Len())
+	}
+
+	calls.Drain(context.Background())
+	res, err = s.call(context.Background(), QueryToolName, nil)
+	if err != nil || !res.IsError {
+		t.Fatalf("expected calls to be refused while draining, got result=%+v err=%v", res, err)
+	}
+}
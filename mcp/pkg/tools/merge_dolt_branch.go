@@ -43,6 +43,10 @@ func NewMergeDoltBranchTool() mcp.Tool {
 			MessageCallToolArgumentName,
 			mcp.Description(MergeDoltBranchToolMessageArgumentDescription),
 		),
+		mcp.WithString(
+			AuthorCallToolArgumentName,
+			mcp.Description(AuthorCallToolArgumentDescription),
+		),
 	)
 }
 
@@ -92,14 +96,21 @@ func RegisterMergeDoltBranchTool(server pkg.Server) {
 			}
 		}()
 
+		var authorArgs []string
+		authorArgs, err = CommitAuthorArgs(ctx, server, tx, request)
+		if err != nil {
+			result = mcp.NewToolResultError(err.Error())
+			return
+		}
+
 		if commitMessage != "" {
-			err = tx.ExecContext(ctx, dialect.CallProcedure(db.DoltMerge, branch, "-m", commitMessage))
+			err = tx.ExecContext(ctx, dialect.CallProcedure(db.DoltMerge, append([]string{branch, "-m", commitMessage}, authorArgs...)...))
 			if err != nil {
 				result = mcp.NewToolResultError(err.Error())
 				return
 			}
 		} else {
-			err = tx.ExecContext(ctx, dialect.CallProcedure(db.DoltMerge, branch))
+			err = tx.ExecContext(ctx, dialect.CallProcedure(db.DoltMerge, append([]string{branch}, authorArgs...)...))
 			if err != nil {
 				result = mcp.NewToolResultError(err.Error())
 				return
@@ -40,6 +40,10 @@ func NewMergeDoltBranchNoFastForwardTool() mcp.Tool {
 			MessageCallToolArgumentName,
 			mcp.Description(MergeDoltBranchToolMessageArgumentDescription),
 		),
+		mcp.WithString(
+			AuthorCallToolArgumentName,
+			mcp.Description(AuthorCallToolArgumentDescription),
+		),
 	)
 }
 
@@ -89,14 +93,21 @@ func RegisterMergeDoltBranchNoFastForwardTool(server pkg.Server) {
 			}
 		}()
 
+		var authorArgs []string
+		authorArgs, err = CommitAuthorArgs(ctx, server, tx, request)
+		if err != nil {
+			result = mcp.NewToolResultError(err.Error())
+			return
+		}
+
 		if commitMessage != "" {
-			err = tx.ExecContext(ctx, dialect.CallProcedure(db.DoltMerge, branch, "--no-ff", "-m", commitMessage))
+			err = tx.ExecContext(ctx, dialect.CallProcedure(db.DoltMerge, append([]string{branch, "--no-ff", "-m", commitMessage}, authorArgs...)...))
 			if err != nil {
 				result = mcp.NewToolResultError(err.Error())
 				return
 			}
 		} else {
-			err = tx.ExecContext(ctx, dialect.CallProcedure(db.DoltMerge, branch, "--no-ff"))
+			err = tx.ExecContext(ctx, dialect.CallProcedure(db.DoltMerge, append([]string{branch, "--no-ff"}, authorArgs...)...))
 			if err != nil {
 				result = mcp.NewToolResultError(err.Error())
 				return
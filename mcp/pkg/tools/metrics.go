@@ -0,0 +1,43 @@
+package tools
+
+import (
+	"context"
+	"time"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// metricsMiddleware records the latency, outcome, statements, and result
+// size of every call of every tool in the server's metrics.
+func metricsMiddleware(s pkg.Server, tool *mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return withMetricsHandler(*tool, next)
+}
+
+func withMetricsHandler(tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		start := time.Now()
+		recorder := &db.StatementRecorder{}
+		result, err := next(db.ContextWithStatementRecorder(ctx, recorder), request)
+
+		failed := err != nil || (result != nil && result.IsError)
+		pkg.ObserveToolCall(tool.Name, failed, time.Since(start), recorder.Statements(), toolResultSize(result))
+		return result, err
+	}
+}
+
+// toolResultSize returns the size of the text content of result.
+func toolResultSize(result *mcp.CallToolResult) int {
+	if result == nil {
+		return 0
+	}
+	size := 0
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			size += len(text.Text)
+		}
+	}
+	return size
+}
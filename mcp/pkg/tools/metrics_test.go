@@ -0,0 +1,22 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	s := newTestServer(&fakeServer{}, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("| a |"), nil
+	}, NewQueryTool())
+
+	res, err := s.call(context.Background(), QueryToolName, nil)
+	if err != nil || res.IsError {
+		t.Fatalf("expected the wrapped tool result, got result=%+v err=%v", res, err)
+	}
+	if size := toolResultSize(res); size != 5 {
+		t.Fatalf("expected a result size of 5, got %d", size)
+	}
+}
@@ -0,0 +1,60 @@
+package tools
+
+import (
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// toolMiddleware wraps the handler of tool, or returns next as it is when it
+// does not apply to tool. It may change tool, such as to add an argument.
+type toolMiddleware func(s pkg.Server, tool *mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc
+
+// toolMiddlewares wrap every registered tool, innermost first. A middleware
+// sees the calls the ones before it refuse, and none of the calls the ones
+// after it refuse.
+var toolMiddlewares = []toolMiddleware{
+	// Dry runs preview the transaction of the tool alone.
+	dryRunMiddleware,
+	// Database credentials, read replicas, and cluster failover choose the
+	// connection the tool runs on. A failover retries the tool alone, once
+	// the call is authorized and counted.
+	databaseCredentialsMiddleware,
+	readReplicasMiddleware,
+	clusterFailoverMiddleware,
+	// Authorization, scopes, and denied branches refuse calls before they
+	// reach the database.
+	authorizationMiddleware,
+	scopesMiddleware,
+	deniedBranchesMiddleware,
+	// Unauthorized calls count against the rate limits too, so that callers
+	// cannot make them without limit.
+	rateLimitsMiddleware,
+	// Tracing, metrics, and the audit log see every call, refused calls
+	// included.
+	tracingMiddleware,
+	metricsMiddleware,
+	auditLogMiddleware,
+	// Shutdown waits for the whole chain, such as for the audit record of an
+	// interrupted call.
+	inFlightCallsMiddleware,
+}
+
+// RegisterToolMiddlewares wraps every registered tool in toolMiddlewares, and
+// leaves the tools outside a caller's scopes out of the tools it lists. It
+// must be called once, after every tool is registered.
+func RegisterToolMiddlewares(s pkg.Server) {
+	mcpServer := s.MCP()
+	var wrapped []server.ServerTool
+	for _, st := range mcpServer.ListTools() {
+		tool, handler := st.Tool, st.Handler
+		for _, middleware := range toolMiddlewares {
+			handler = middleware(s, &tool, handler)
+		}
+		wrapped = append(wrapped, server.ServerTool{Tool: tool, Handler: handler})
+	}
+	if len(wrapped) > 0 {
+		mcpServer.AddTools(wrapped...)
+	}
+	server.WithToolFilter(filterToolsByScopes)(mcpServer)
+}
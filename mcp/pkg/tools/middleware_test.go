@@ -0,0 +1,56 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"go.uber.org/zap"
+)
+
+func TestToolMiddlewaresOrder(t *testing.T) {
+	var buf bytes.Buffer
+	s := newTestServer(&fakeServer{settings: pkg.ToolSettings{
+		Authorization: &pkg.Authorization{
+			Roles: map[string][]pkg.AuthorizationGrant{
+				"analyst": {{Databases: []string{"analytics"}, ReadOnly: true}},
+			},
+		},
+		RateLimiter:   pkg.NewRateLimiter(pkg.RateLimits{ToolCalls: &pkg.RateLimit{Rate: 0.1, Burst: 1}}),
+		AuditLog:      pkg.NewAuditLog(zap.NewNop(), pkg.NewAuditWriter(&buf)),
+		InFlightCalls: pkg.NewInFlightCalls(zap.NewNop()),
+	}}, okHandler, NewQueryTool(), NewExecTool())
+	ctx := pkg.ContextWithIdentity(context.Background(), &pkg.Identity{Subject: "alice", Claims: map[string]any{"roles": []any{"analyst"}}})
+	args := map[string]any{WorkingDatabaseCallToolArgumentName: "analytics", WorkingBranchCallToolArgumentName: "main"}
+
+	if res, err := s.call(ctx, ExecToolName, args); err != nil || !res.IsError {
+		t.Fatalf("expected exec to be unauthorized, got result=%+v err=%v", res, err)
+	}
+	res, err := s.call(ctx, QueryToolName, args)
+	if err != nil || !res.IsError || res.StructuredContent == nil {
+		t.Fatalf("expected the unauthorized call to count against the rate limits, got result=%+v err=%v", res, err)
+	}
+
+	var tools []string
+	for decoder := json.NewDecoder(&buf); decoder.More(); {
+		var record pkg.AuditRecord
+		if err := decoder.Decode(&record); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		tools = append(tools, record.Tool)
+	}
+	if len(tools) != 2 || tools[0] != ExecToolName || tools[1] != QueryToolName {
+		t.Fatalf("expected refused calls to be audited too, got records of %v", tools)
+	}
+
+	s.settings.InFlightCalls.Drain(context.Background())
+	res, err = s.call(ctx, QueryToolName, args)
+	if err != nil || !res.IsError {
+		t.Fatalf("expected calls to be refused while draining, got result=%+v err=%v", res, err)
+	}
+	if res.StructuredContent != nil {
+		t.Fatalf("expected the call to be refused before the rate limits, got %+v", res.StructuredContent)
+	}
+}
@@ -1,6 +1,7 @@
 package tools
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
@@ -150,4 +151,30 @@ func TestPostgresValidateAlterTableQuery_RejectsNonAlterTable(t *testing.T) {
 			}
 		})
 	}
-}
\ No newline at end of file
+}
+func TestPostgresValidateQueries_RejectMultipleStatements(t *testing.T) {
+	dialect := db.NewDialect(db.DialectPostgres)
+	validators := map[string]func(string) error{
+		"read":   dialect.ValidateReadQuery,
+		"write":  dialect.ValidateWriteQuery,
+		"create": dialect.ValidateCreateTableQuery,
+		"alter":  dialect.ValidateAlterTableQuery,
+	}
+	cases := []string{
+		"SELECT 1; DELETE FROM t",
+		"SELECT 1; SELECT 2;",
+		"INSERT INTO t VALUES (1); DROP TABLE t",
+		"CREATE TABLE t (id int); DROP TABLE u",
+		"ALTER TABLE t ADD COLUMN c int; DROP TABLE t",
+	}
+
+	for name, validate := range validators {
+		for _, sql := range cases {
+			t.Run(name+"/"+sql, func(t *testing.T) {
+				if err := validate(sql); !errors.Is(err, db.ErrMultipleSQLStatements) {
+					t.Fatalf("expected ErrMultipleSQLStatements, got err=%v", err)
+				}
+			})
+		}
+	}
+}
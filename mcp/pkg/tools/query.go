@@ -75,6 +75,12 @@ func RegisterQueryTool(server pkg.Server) {
 			return
 		}
 
+		err = dialect.ValidateQueryPolicy(query, workingDatabase, server.ToolSettings().SQLPolicy)
+		if err != nil {
+			result = mcp.NewToolResultError(err.Error())
+			return
+		}
+
 		config := server.DBConfig()
 
 		var tx db.DatabaseTransaction
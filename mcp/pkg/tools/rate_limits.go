@@ -0,0 +1,58 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// rateLimitedResult is the structured content of the result of a call
+// refused by the rate limits, so that clients can back off without parsing
+// the message.
+type rateLimitedResult struct {
+	Error             string  `json:"error"`
+	Limit             string  `json:"limit"`
+	Concurrent        bool    `json:"concurrent"`
+	RetryAfterSeconds float64 `json:"retry_after_seconds"`
+}
+
+// rateLimitsMiddleware counts every call of every tool against the rate
+// limits of its caller, and calls that may write against the write limits
+// too, such as a query calling DOLT_BRANCH. Calls over a limit fail with a
+// result saying when to retry.
+func rateLimitsMiddleware(s pkg.Server, tool *mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return withRateLimitsHandler(s, *tool, next)
+}
+
+func withRateLimitsHandler(s pkg.Server, tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		limiter := s.ToolSettings().RateLimiter
+		if limiter == nil {
+			return next(ctx, request)
+		}
+		release, err := limiter.Acquire(ctx, mayWrite(s.Dialect(), tool, request))
+		if err != nil {
+			return rateLimitedToolResult(err), nil
+		}
+		defer release()
+		return next(ctx, request)
+	}
+}
+
+func rateLimitedToolResult(err error) *mcp.CallToolResult {
+	result := mcp.NewToolResultError(err.Error())
+	var rateLimitErr *pkg.RateLimitError
+	if errors.As(err, &rateLimitErr) {
+		result.StructuredContent = rateLimitedResult{
+			Error:             "rate_limited",
+			Limit:             rateLimitErr.Limit,
+			Concurrent:        rateLimitErr.Concurrent,
+			RetryAfterSeconds: math.Ceil(rateLimitErr.RetryAfter.Seconds()*1000) / 1000,
+		}
+	}
+	return result
+}
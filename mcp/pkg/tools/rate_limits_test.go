@@ -0,0 +1,37 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+)
+
+func TestRateLimitsMiddleware(t *testing.T) {
+	s := newTestServer(&fakeServer{}, okHandler, NewQueryTool(), NewExecTool())
+	ctx := pkg.ContextWithIdentity(context.Background(), &pkg.Identity{Subject: "agent", Issuer: pkg.APIKeyIssuer})
+
+	if res, err := s.call(ctx, ExecToolName, nil); err != nil || res.IsError {
+		t.Fatalf("expected calls to run without rate limits, got result=%+v err=%v", res, err)
+	}
+
+	s.settings.RateLimiter = pkg.NewRateLimiter(pkg.RateLimits{Writes: &pkg.RateLimit{Rate: 0.1, Burst: 1}})
+	if res, err := s.call(ctx, ExecToolName, nil); err != nil || res.IsError {
+		t.Fatalf("expected the first write to be allowed, got result=%+v err=%v", res, err)
+	}
+	res, err := s.call(ctx, ExecToolName, nil)
+	if err != nil || !res.IsError {
+		t.Fatalf("expected the second write to be refused, got result=%+v err=%v", res, err)
+	}
+	structured, ok := res.StructuredContent.(rateLimitedResult)
+	if !ok || structured.Error != "rate_limited" || structured.Limit != pkg.RateLimitWrites || structured.RetryAfterSeconds != 10 {
+		t.Fatalf("unexpected structured content %+v", res.StructuredContent)
+	}
+	if res, err := s.call(ctx, QueryToolName, nil); err != nil || res.IsError {
+		t.Fatalf("expected read-only tools to be outside the write limit, got result=%+v err=%v", res, err)
+	}
+	res, err = s.call(ctx, QueryToolName, map[string]any{QueryCallToolArgumentName: "SELECT dolt_branch('-D', 'main')"})
+	if err != nil || !res.IsError {
+		t.Fatalf("expected a query calling a writing Dolt procedure to count as a write, got result=%+v err=%v", res, err)
+	}
+}
@@ -0,0 +1,37 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// readReplicasMiddleware makes every read-only tool run on the read replica
+// the server's router picks. Tools that may write, and calls whose SQL calls
+// a Dolt procedure that may write, keep running on the configured database.
+func readReplicasMiddleware(s pkg.Server, tool *mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	if isMutatingTool(*tool) {
+		return next
+	}
+	return withReadReplicaHandler(s, *tool, next)
+}
+
+func withReadReplicaHandler(s pkg.Server, tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		router := s.ToolSettings().ReplicaRouter
+		if router == nil || mayWrite(s.Dialect(), tool, request) {
+			return next(ctx, request)
+		}
+		endpoint, ok, err := router.Pick()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		if ok {
+			ctx = db.ContextWithEndpoint(ctx, endpoint)
+		}
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.uber.org/zap"
+)
+
+func TestReadReplicasMiddleware(t *testing.T) {
+	primary := db.Config{Host: "127.0.0.1", Port: 1, User: "root", DialectType: db.DialectMySQL}
+	router, err := pkg.NewReplicaRouter(zap.NewNop(), primary, pkg.ReadReplicas{
+		Replicas:      []pkg.ReadReplica{{Host: "127.0.0.1"}},
+		CheckInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error creating router: %v", err)
+	}
+	defer router.Close()
+
+	var execEndpoint bool
+	s := newTestServer(&fakeServer{settings: pkg.ToolSettings{ReplicaRouter: router}}, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if request.Params.Name == ExecToolName {
+			_, execEndpoint = db.EndpointFromContext(ctx)
+		}
+		return mcp.NewToolResultText("ok"), nil
+	}, NewQueryTool(), NewExecTool())
+
+	// The replica cannot be reached and there is no fallback, so read-only
+	// tools fail while write tools keep running on the primary.
+	res, err := s.call(context.Background(), QueryToolName, nil)
+	if err != nil || !res.IsError {
+		t.Fatalf("expected the read-only tool to fail without a replica, got result=%+v err=%v", res, err)
+	}
+	res, err = s.call(context.Background(), ExecToolName, nil)
+	if err != nil || res.IsError {
+		t.Fatalf("expected the write tool to run, got result=%+v err=%v", res, err)
+	}
+	if execEndpoint {
+		t.Fatal("expected the write tool to run on the primary")
+	}
+}
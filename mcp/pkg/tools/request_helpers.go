@@ -19,6 +19,24 @@ func GetRequiredStringArgumentFromCallToolRequest(request mcp.CallToolRequest, a
 	return value, nil
 }
 
+func GetRequiredStringArrayArgumentFromCallToolRequest(request mcp.CallToolRequest, argument string) ([]string, error) {
+	values, ok := request.GetArguments()[argument].([]any)
+	if !ok || len(values) == 0 {
+		err := status.Errorf(codes.InvalidArgument, "%s not defined", argument)
+		return nil, err
+	}
+	strs := make([]string, len(values))
+	for i, value := range values {
+		str, ok := value.(string)
+		if !ok || str == "" {
+			err := status.Errorf(codes.InvalidArgument, "%s must be a list of non-empty strings", argument)
+			return nil, err
+		}
+		strs[i] = str
+	}
+	return strs, nil
+}
+
 func GetStringArgumentFromCallToolRequest(request mcp.CallToolRequest, argument string) string {
 	value, ok := request.GetArguments()[argument].(string)
 	if !ok {
@@ -34,3 +52,16 @@ func GetBooleanArgumentFromCallToolRequest(request mcp.CallToolRequest, argument
 	}
 	return value
 }
+
+// sqlArgumentsFromRequest returns the SQL a call runs, from its query or
+// statements argument.
+func sqlArgumentsFromRequest(request mcp.CallToolRequest) []string {
+	var queries []string
+	if query := GetStringArgumentFromCallToolRequest(request, QueryCallToolArgumentName); query != "" {
+		queries = append(queries, query)
+	}
+	if statements, err := GetRequiredStringArrayArgumentFromCallToolRequest(request, StatementsCallToolArgumentName); err == nil {
+		queries = append(queries, statements...)
+	}
+	return queries
+}
@@ -0,0 +1,38 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// scopesMiddleware limits callers whose identity has scopes, such as API
+// keys with scopes, to the tools their scopes include. Other tools fail when
+// called, and filterToolsByScopes leaves them out of the tools they list.
+func scopesMiddleware(s pkg.Server, tool *mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return withScopesHandler(*tool, next)
+}
+
+func filterToolsByScopes(ctx context.Context, tools []mcp.Tool) []mcp.Tool {
+	identity := pkg.IdentityFromContext(ctx)
+	filtered := make([]mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		if identity.AllowsTool(tool.Name) {
+			filtered = append(filtered, tool)
+		}
+	}
+	return filtered
+}
+
+func withScopesHandler(tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		identity := pkg.IdentityFromContext(ctx)
+		if !identity.AllowsTool(tool.Name) {
+			return mcp.NewToolResultError(fmt.Errorf("%w: the scopes of %s do not include %s", pkg.ErrUnauthorized, identity.Subject, tool.Name).Error()), nil
+		}
+		return next(ctx, request)
+	}
+}
@@ -0,0 +1,32 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestScopesMiddleware(t *testing.T) {
+	s := newTestServer(&fakeServer{}, okHandler, NewQueryTool(), NewExecTool())
+
+	ctx := pkg.ContextWithIdentity(context.Background(), &pkg.Identity{Subject: "ci", Scopes: []string{QueryToolName}})
+	res, err := s.call(ctx, QueryToolName, nil)
+	if err != nil || res.IsError {
+		t.Fatalf("expected query to be in scope, got result=%+v err=%v", res, err)
+	}
+	res, err = s.call(ctx, ExecToolName, nil)
+	if err != nil || !res.IsError {
+		t.Fatalf("expected exec to be out of scope, got result=%+v err=%v", res, err)
+	}
+	res, err = s.call(context.Background(), ExecToolName, nil)
+	if err != nil || res.IsError {
+		t.Fatalf("expected callers without scopes to call any tool, got result=%+v err=%v", res, err)
+	}
+
+	tools := filterToolsByScopes(ctx, []mcp.Tool{NewQueryTool(), NewExecTool()})
+	if len(tools) != 1 || tools[0].Name != QueryToolName {
+		t.Fatalf("expected only query to be listed, got %v", tools)
+	}
+}
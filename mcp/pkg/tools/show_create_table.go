@@ -70,6 +70,12 @@ func RegisterShowCreateTableTool(server pkg.Server) {
 			return
 		}
 
+		err = server.ToolSettings().SQLPolicy.CheckTable(workingDatabase, table, true)
+		if err != nil {
+			result = mcp.NewToolResultError(err.Error())
+			return
+		}
+
 		dialect := server.Dialect()
 		config := server.DBConfig()
 
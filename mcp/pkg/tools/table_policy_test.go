@@ -0,0 +1,55 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestTableTools_EnforceSQLPolicy(t *testing.T) {
+	s := &fakeServer{
+		mcp: server.NewMCPServer("test", "0.0.0"),
+		settings: pkg.ToolSettings{
+			SQLPolicy: &db.SQLPolicy{DeniedTables: []string{"secrets"}, DeniedColumns: []string{"people.ssn"}},
+		},
+	}
+	RegisterDescribeTableTool(s)
+	RegisterShowCreateTableTool(s)
+	RegisterListDoltDiffChangesByTableNameTool(s)
+	RegisterStageTableForDoltCommitTool(s)
+	RegisterUnstageTableTool(s)
+	RegisterDropTableTool(s)
+
+	for _, test := range []struct {
+		tool  string
+		table string
+	}{
+		{DescribeTableToolName, "secrets"},
+		{DescribeTableToolName, "people"},
+		{ShowCreateTableToolName, "people"},
+		{ListDoltDiffChangesByTableNameToolName, "people"},
+		{StageTableForDoltCommitToolName, "secrets"},
+		{UnstageTableToolName, "secrets"},
+		{DropTableToolName, "secrets"},
+	} {
+		request := callToolRequest(map[string]any{
+			WorkingDatabaseCallToolArgumentName: "mydb",
+			WorkingBranchCallToolArgumentName:   "main",
+			TableCallToolArgumentName:           test.table,
+			FromCommitCallToolArgumentName:      "HEAD~1",
+			ToCommitCallToolArgumentName:        "HEAD",
+		})
+		res, err := s.mcp.GetTool(test.tool).Handler(context.Background(), request)
+		if err != nil || res == nil || !res.IsError {
+			t.Fatalf("%s %s: expected the policy to reject the call, got result=%+v err=%v", test.tool, test.table, res, err)
+		}
+		if text := res.Content[0].(mcp.TextContent).Text; !strings.Contains(text, db.ErrSQLPolicyViolation.Error()) {
+			t.Fatalf("%s %s: expected a policy violation, got %q", test.tool, test.table, text)
+		}
+	}
+}
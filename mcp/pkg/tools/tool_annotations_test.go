@@ -101,6 +101,7 @@ func TestWriteToolAnnotations(t *testing.T) {
 		ro, destr, idem, open bool
 	}{
 		{"exec", NewExecTool, false, true, false, false},
+		{"exec_script", NewExecScriptTool, false, true, false, false},
 		{"kill_process", NewKillProcessTool, false, true, false, false},
 		{"create_table", NewCreateTableTool, false, false, false, false},
 		{"alter_table", NewAlterTableTool, false, false, false, false},
@@ -0,0 +1,50 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Span attributes of tool calls.
+const (
+	toolNameAttribute     = attribute.Key("mcp.tool.name")
+	toolDatabaseAttribute = attribute.Key("db.namespace")
+	toolBranchAttribute   = attribute.Key("dolt.branch")
+)
+
+// tracingMiddleware starts a span for every call of every tool, with the
+// tool, database, and branch as attributes. The spans of the statements the
+// call runs are its children.
+func tracingMiddleware(s pkg.Server, tool *mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return withTracingHandler(*tool, next)
+}
+
+func withTracingHandler(tool mcp.Tool, next server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		attributes := []attribute.KeyValue{toolNameAttribute.String(tool.Name)}
+		access := ToolAccessFromRequest(tool, request)
+		if len(access.Databases) > 0 {
+			attributes = append(attributes, toolDatabaseAttribute.String(access.Databases[0]))
+		}
+		if len(access.Branches) > 0 {
+			attributes = append(attributes, toolBranchAttribute.String(access.Branches[0]))
+		}
+		ctx, span := pkg.Tracer().Start(ctx, "tool "+tool.Name, trace.WithAttributes(attributes...))
+		defer span.End()
+
+		result, err := next(ctx, request)
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		} else if result != nil && result.IsError {
+			span.SetStatus(codes.Error, toolResultText(result))
+		}
+		return result, err
+	}
+}
@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"context"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	s := newTestServer(&fakeServer{}, func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultError("exec failed"), nil
+	}, NewExecTool())
+
+	_, err := s.call(context.Background(), ExecToolName, map[string]any{
+		WorkingDatabaseCallToolArgumentName: "mydb",
+		WorkingBranchCallToolArgumentName:   "main",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 || spans[0].Name() != "tool "+ExecToolName {
+		t.Fatalf("expected one tool span, got %v", spans)
+	}
+	attributes := map[string]string{}
+	for _, attr := range spans[0].Attributes() {
+		attributes[string(attr.Key)] = attr.Value.AsString()
+	}
+	if attributes["mcp.tool.name"] != ExecToolName || attributes["db.namespace"] != "mydb" || attributes["dolt.branch"] != "main" {
+		t.Fatalf("unexpected span attributes %v", attributes)
+	}
+	if spans[0].Status().Code != codes.Error || spans[0].Status().Description != "exec failed" {
+		t.Fatalf("expected the tool error as the span status, got %+v", spans[0].Status())
+	}
+}
@@ -69,6 +69,12 @@ func RegisterUnstageTableTool(server pkg.Server) {
 			return
 		}
 
+		err = server.ToolSettings().SQLPolicy.CheckTable(workingDatabase, table, false)
+		if err != nil {
+			result = mcp.NewToolResultError(err.Error())
+			return
+		}
+
 		dialect := server.Dialect()
 		config := server.DBConfig()
 
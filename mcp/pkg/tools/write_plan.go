@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+const (
+	ApplyTokenCallToolArgumentName        = "apply_token"
+	ApplyTokenCallToolArgumentDescription = "The apply token returned when this write was planned. When the server requires confirmation for writes, pass it to apply the planned write exactly as previewed."
+
+	DoltStatusSQLQuery = "SELECT * FROM dolt_status;"
+
+	writePlanConfirmArgumentName = "confirm"
+	writePlanConfirmDescription  = "Apply the previewed changes and commit them."
+	writePlanElicitationFormat   = "%s wants to apply the following changes to %s.\n\n%s"
+	writePlanPendingFormat       = "write was not applied. Review the preview below, then call %s again with the same arguments and %s=%q to apply it.\n\n%s"
+	writePlanDeclinedMessage     = "write was not applied: the user did not confirm it"
+	writePlanNoChangesMessage    = "none"
+	writePlanRowsAffectedFormat  = "rows affected: %d"
+	writePlanStatusTitle         = "dolt_status"
+	writePlanDiffTitle           = "working set diff"
+	writePlanNotPreviewedFormat  = "not previewed: %s takes effect even when its transaction is rolled back, so it only runs once the write is confirmed. Statements to run:\n\n%s"
+)
+
+var ErrInvalidApplyToken = errors.New("invalid apply token: it has expired, was already used, or was issued for a different write")
+
+// WritePlan is the previewed effect of a write, gathered from a transaction
+// that is always rolled back.
+type WritePlan struct {
+	Summary  string
+	Sections []WritePlanSection
+}
+
+// WritePlanSection is a titled markdown table in a WritePlan.
+type WritePlanSection struct {
+	Title string
+	Table string
+}
+
+func (p WritePlan) String() string {
+	var b strings.Builder
+	b.WriteString(p.Summary)
+	b.WriteString("\n")
+	for _, section := range p.Sections {
+		b.WriteString("\n")
+		b.WriteString(section.Title)
+		b.WriteString(":\n")
+		// A markdown table with only a header and separator row is empty.
+		if strings.Count(section.Table, "\n") <= 2 {
+			b.WriteString(writePlanNoChangesMessage)
+			b.WriteString("\n")
+		} else {
+			b.WriteString(section.Table)
+		}
+	}
+	return b.String()
+}
+
+// PlanWrite runs queries on the database and branch in a transaction that is
+// always rolled back, and reports the changes they would have made. Queries
+// holding a statement that commits implicitly, such as DDL, are not run,
+// since the rollback would not undo it; the plan lists them instead.
+func PlanWrite(ctx context.Context, config db.Config, dialect db.Dialect, database, branch string, queries ...string) (plan WritePlan, err error) {
+	for _, query := range queries {
+		var kinds []string
+		kinds, err = dialect.StatementKinds(query)
+		if err != nil {
+			return
+		}
+		for _, kind := range kinds {
+			if db.CommitsImplicitly(kind) {
+				plan.Summary = fmt.Sprintf(writePlanNotPreviewedFormat, kind, strings.Join(queries, "\n"))
+				return
+			}
+		}
+	}
+
+	var tx db.DatabaseTransaction
+	tx, err = NewDatabaseTransactionUsingDatabaseOnBranch(ctx, config, dialect, database, branch)
+	if err != nil {
+		return
+	}
+
+	defer func() {
+		tx.Rollback(ctx)
+	}()
+
+	var rowsAffected int64
+	for _, query := range queries {
+		var n int64
+		n, err = tx.ExecContextRowsAffected(ctx, query)
+		if err != nil {
+			return
+		}
+		rowsAffected += n
+	}
+	plan.Summary = fmt.Sprintf(writePlanRowsAffectedFormat, rowsAffected)
+
+	plan.Sections, err = previewPendingChanges(ctx, tx)
+	return
+}
+
+// previewPendingChanges reports the uncommitted changes in tx.
+func previewPendingChanges(ctx context.Context, tx db.DatabaseTransaction) ([]WritePlanSection, error) {
+	status, err := tx.QueryContext(ctx, DoltStatusSQLQuery, db.ResultFormatMarkdown)
+	if err != nil {
+		return nil, err
+	}
+
+	diff, err := tx.QueryContext(ctx, ListDoltDiffChangesInWorkingSetToolSQLQuery, db.ResultFormatMarkdown)
+	if err != nil {
+		return nil, err
+	}
+
+	return []WritePlanSection{
+		{Title: writePlanStatusTitle, Table: status},
+		{Title: writePlanDiffTitle, Table: diff},
+	}, nil
+}
+
+// ConfirmWrite decides whether a write may be applied when the server
+// requires confirmation for writes. A nil result means the caller should go
+// ahead and commit the write; otherwise the result is returned to the client
+// as is. Dry runs never commit, so they need no confirmation.
+//
+// A call carrying an apply token is confirmed when the token was issued for
+// this exact write, on the same backend and to the same caller. Otherwise the write is planned, and the user is asked to
+// confirm the preview through elicitation. Clients that do not support
+// elicitation receive the preview together with an apply token to pass back.
+func ConfirmWrite(ctx context.Context, s pkg.Server, request mcp.CallToolRequest, write pkg.PendingWrite, plan func(ctx context.Context) (WritePlan, error)) *mcp.CallToolResult {
+	settings := s.ToolSettings()
+	pending := settings.PendingWrites
+	if pending == nil || IsDryRun(ctx) {
+		return nil
+	}
+	write.Backend = settings.Backend
+	if identity := pkg.IdentityFromContext(ctx); identity != nil {
+		write.Issuer, write.Subject = identity.Issuer, identity.Subject
+	}
+
+	if token := GetStringArgumentFromCallToolRequest(request, ApplyTokenCallToolArgumentName); token != "" {
+		if !pending.Take(token, write) {
+			return mcp.NewToolResultError(ErrInvalidApplyToken.Error())
+		}
+		return nil
+	}
+
+	p, err := plan(ctx)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error())
+	}
+	preview := p.String()
+
+	if clientSupportsElicitation(ctx) {
+		confirmed, err := elicitWriteConfirmation(ctx, s.MCP(), write, preview)
+		if err != nil {
+			return mcp.NewToolResultError(err.Error())
+		}
+		if !confirmed {
+			return mcp.NewToolResultText(writePlanDeclinedMessage + "\n\n" + preview)
+		}
+		return nil
+	}
+
+	token, err := pending.Add(write)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error())
+	}
+	return mcp.NewToolResultText(fmt.Sprintf(writePlanPendingFormat, write.Tool, ApplyTokenCallToolArgumentName, token, preview))
+}
+
+func clientSupportsElicitation(ctx context.Context) bool {
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo)
+	if !ok {
+		return false
+	}
+	if _, ok := session.(server.SessionWithElicitation); !ok {
+		return false
+	}
+	return session.GetClientCapabilities().Elicitation != nil
+}
+
+func elicitWriteConfirmation(ctx context.Context, mcpServer *server.MCPServer, write pkg.PendingWrite, preview string) (bool, error) {
+	target := write.Database
+	if write.Branch != "" {
+		target = fmt.Sprintf("%s on branch %s", write.Database, write.Branch)
+	}
+
+	res, err := mcpServer.RequestElicitation(ctx, mcp.ElicitationRequest{
+		Params: mcp.ElicitationParams{
+			Message: fmt.Sprintf(writePlanElicitationFormat, write.Tool, target, preview),
+			RequestedSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					writePlanConfirmArgumentName: map[string]any{
+						"type":        "boolean",
+						"description": writePlanConfirmDescription,
+					},
+				},
+				"required": []string{writePlanConfirmArgumentName},
+			},
+		},
+	})
+	if err != nil {
+		return false, err
+	}
+	if res.Action != mcp.ElicitationResponseActionAccept {
+		return false, nil
+	}
+	content, ok := res.Content.(map[string]any)
+	if !ok {
+		return false, nil
+	}
+	confirmed, _ := content[writePlanConfirmArgumentName].(bool)
+	return confirmed, nil
+}
@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dolthub/dolt-mcp/mcp/pkg"
+	"github.com/dolthub/dolt-mcp/mcp/pkg/db"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+func TestWritePlanString(t *testing.T) {
+	plan := WritePlan{
+		Summary: "rows affected: 1",
+		Sections: []WritePlanSection{
+			{Title: "dolt_status", Table: "table_name | staged | status\n--- | --- | ---\nt | false | modified\n"},
+			{Title: "working set diff", Table: "table_name\n---\n"},
+		},
+	}
+	expected := "rows affected: 1\n\ndolt_status:\ntable_name | staged | status\n--- | --- | ---\nt | false | modified\n\nworking set diff:\nnone\n"
+	if got := plan.String(); got != expected {
+		t.Fatalf("unexpected plan preview:\n%s", got)
+	}
+}
+
+func TestConfirmWrite_DisabledProceeds(t *testing.T) {
+	s := &fakeServer{}
+	res := ConfirmWrite(context.Background(), s, callToolRequest(nil), pkg.PendingWrite{Tool: ExecToolName}, func(context.Context) (WritePlan, error) {
+		t.Fatalf("plan should not run when confirmation is disabled")
+		return WritePlan{}, nil
+	})
+	if res != nil {
+		t.Fatalf("expected nil result, got %+v", res)
+	}
+}
+
+func TestConfirmWrite_ReturnsApplyTokenWithoutElicitation(t *testing.T) {
+	s := &fakeServer{settings: pkg.ToolSettings{PendingWrites: pkg.NewPendingWrites(time.Minute)}}
+	write := pkg.PendingWrite{Tool: ExecToolName, Database: "db", Branch: "main", Statement: "DELETE FROM t;"}
+	plan := func(context.Context) (WritePlan, error) {
+		return WritePlan{Summary: "rows affected: 3"}, nil
+	}
+
+	res := ConfirmWrite(context.Background(), s, callToolRequest(nil), write, plan)
+	if res == nil || res.IsError {
+		t.Fatalf("expected a preview result, got %+v", res)
+	}
+	text := res.Content[0].(mcp.TextContent).Text
+	if !strings.Contains(text, "rows affected: 3") {
+		t.Fatalf("expected preview in result, got %q", text)
+	}
+	start := strings.Index(text, ApplyTokenCallToolArgumentName+"=\"")
+	if start < 0 {
+		t.Fatalf("expected apply token in result, got %q", text)
+	}
+	token := text[start+len(ApplyTokenCallToolArgumentName)+2:]
+	token = token[:strings.Index(token, "\"")]
+
+	applyRequest := callToolRequest(map[string]any{ApplyTokenCallToolArgumentName: token})
+	if res := ConfirmWrite(context.Background(), s, applyRequest, write, plan); res != nil {
+		t.Fatalf("expected apply token to confirm the write, got %+v", res)
+	}
+	if res := ConfirmWrite(context.Background(), s, applyRequest, write, plan); res == nil || !res.IsError {
+		t.Fatalf("expected reused apply token to be rejected, got %+v", res)
+	}
+}
+
+func TestConfirmWrite_ApplyTokenIsBoundToBackendAndCaller(t *testing.T) {
+	pending := pkg.NewPendingWrites(time.Minute)
+	write := pkg.PendingWrite{Tool: ExecToolName, Database: "db", Branch: "main", Statement: "DELETE FROM t;"}
+	plan := func(context.Context) (WritePlan, error) {
+		return WritePlan{Summary: "rows affected: 3"}, nil
+	}
+	alice := pkg.ContextWithIdentity(context.Background(), &pkg.Identity{Subject: "alice", Issuer: pkg.APIKeyIssuer})
+	issue := func(s *fakeServer) mcp.CallToolRequest {
+		t.Helper()
+		res := ConfirmWrite(alice, s, callToolRequest(nil), write, plan)
+		text := res.Content[0].(mcp.TextContent).Text
+		token := text[strings.Index(text, ApplyTokenCallToolArgumentName+"=\"")+len(ApplyTokenCallToolArgumentName)+2:]
+		return callToolRequest(map[string]any{ApplyTokenCallToolArgumentName: token[:strings.Index(token, "\"")]})
+	}
+
+	backendA := &fakeServer{settings: pkg.ToolSettings{PendingWrites: pending, Backend: "a"}}
+	backendB := &fakeServer{settings: pkg.ToolSettings{PendingWrites: pending, Backend: "b"}}
+	if res := ConfirmWrite(alice, backendB, issue(backendA), write, plan); res == nil || !res.IsError {
+		t.Fatalf("expected a token of another backend to be rejected, got %+v", res)
+	}
+
+	bob := pkg.ContextWithIdentity(context.Background(), &pkg.Identity{Subject: "bob", Issuer: pkg.APIKeyIssuer})
+	if res := ConfirmWrite(bob, backendA, issue(backendA), write, plan); res == nil || !res.IsError {
+		t.Fatalf("expected a token of another caller to be rejected, got %+v", res)
+	}
+
+	if res := ConfirmWrite(alice, backendA, issue(backendA), write, plan); res != nil {
+		t.Fatalf("expected the token to confirm the write of its caller and backend, got %+v", res)
+	}
+}
+
+func TestPlanWrite_DoesNotRunImplicitCommits(t *testing.T) {
+	dialect := db.NewDialect(db.DialectMySQL)
+	for _, query := range []string{
+		"ALTER TABLE people ADD COLUMN age INT;",
+		"DROP TABLE people;",
+		"CALL DOLT_COMMIT('-Am', 'message');",
+		"SELECT DOLT_RESET('--hard');",
+	} {
+		// The config connects nowhere, so running the query would fail.
+		plan, err := PlanWrite(context.Background(), db.Config{}, dialect, "db", "main", query)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", query, err)
+		}
+		if !strings.HasPrefix(plan.Summary, "not previewed") || !strings.Contains(plan.Summary, query) || len(plan.Sections) != 0 {
+			t.Fatalf("%s: expected the statement to be listed without running it, got %+v", query, plan)
+		}
+	}
+}
@@ -1,11 +1,17 @@
 package toolsets
 
 import (
+	"slices"
+
 	"github.com/dolthub/dolt-mcp/mcp/pkg"
 	"github.com/dolthub/dolt-mcp/mcp/pkg/tools"
 )
 
-type PrimitiveToolSetV1 struct{}
+type PrimitiveToolSetV1 struct {
+	// OptionalTools names opt-in tools to register along with the default
+	// tools, such as exec_script.
+	OptionalTools []string
+}
 
 var _ ToolSet = &PrimitiveToolSetV1{}
 
@@ -64,6 +70,12 @@ var toolRegistrations = []toolRegistration{
 	{tools.RemoveDoltTestToolName, tools.RegisterRemoveDoltTestTool},
 }
 
+// optionalToolRegistrations are only registered when named in
+// PrimitiveToolSetV1.OptionalTools.
+var optionalToolRegistrations = []toolRegistration{
+	{tools.ExecScriptToolName, tools.RegisterExecScriptTool},
+}
+
 func (v *PrimitiveToolSetV1) RegisterTools(server pkg.Server) {
 	dialect := server.Dialect()
 	for _, t := range toolRegistrations {
@@ -71,4 +83,10 @@ func (v *PrimitiveToolSetV1) RegisterTools(server pkg.Server) {
 			t.register(server)
 		}
 	}
-}
\ No newline at end of file
+	for _, t := range optionalToolRegistrations {
+		if slices.Contains(v.OptionalTools, t.name) && dialect.SupportsTool(t.name) {
+			t.register(server)
+		}
+	}
+	tools.RegisterToolMiddlewares(server)
+}
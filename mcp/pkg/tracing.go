@@ -0,0 +1,123 @@
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.41.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// The --trace-exporter values.
+const (
+	// TraceExporterOTLP sends spans over OTLP/HTTP, configured by the
+	// standard OTEL_EXPORTER_OTLP_* environment variables.
+	TraceExporterOTLP = "otlp"
+	// TraceExporterStdout writes spans to standard output as JSON.
+	TraceExporterStdout = "stdout"
+	// TraceExporterFile writes spans as JSON to the --trace-file.
+	TraceExporterFile = "file"
+)
+
+const tracerName = "github.com/dolthub/dolt-mcp/mcp/pkg"
+
+// Tracer returns the tracer of the server's spans.
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// SetupTracing installs a tracer provider exporting spans with exporter, and
+// the W3C trace context propagator. The returned function flushes pending
+// spans and stops the exporter.
+func SetupTracing(ctx context.Context, exporter, file string) (func(context.Context) error, error) {
+	var spanExporter sdktrace.SpanExporter
+	var closer io.Closer
+	var err error
+	switch exporter {
+	case TraceExporterOTLP:
+		spanExporter, err = otlptracehttp.New(ctx)
+	case TraceExporterStdout:
+		spanExporter, err = stdouttrace.New()
+	case TraceExporterFile:
+		if file == "" {
+			return nil, errors.New("the file trace exporter needs a trace file")
+		}
+		var f *os.File
+		f, err = os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file %s: %w", file, err)
+		}
+		closer = f
+		spanExporter, err = stdouttrace.New(stdouttrace.WithWriter(f))
+	default:
+		return nil, fmt.Errorf("invalid trace exporter %q: expected %s, %s, or %s", exporter, TraceExporterOTLP, TraceExporterStdout, TraceExporterFile)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s trace exporter: %w", exporter, err)
+	}
+
+	// OTEL_SERVICE_NAME and OTEL_RESOURCE_ATTRIBUTES override the defaults.
+	res, err := resource.Merge(
+		resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(DoltMCPServerName),
+			semconv.ServiceVersion(DoltMCPServerVersion),
+		),
+		resource.Environment(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(spanExporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closer != nil {
+			err = errors.Join(err, closer.Close())
+		}
+		return err
+	}, nil
+}
+
+// withTracing starts a span for every HTTP request, continuing the trace of
+// the W3C traceparent header when the client sends one.
+func withTracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		ctx, span := Tracer().Start(ctx, r.Method+" "+r.URL.Path,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(r.Method),
+				semconv.URLPath(r.URL.Path),
+			),
+		)
+		defer span.End()
+
+		lrw := &loggingResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(lrw, r.WithContext(ctx))
+
+		if lrw.statusCode == 0 {
+			lrw.statusCode = http.StatusOK
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(lrw.statusCode))
+		if lrw.statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(lrw.statusCode))
+		}
+	})
+}
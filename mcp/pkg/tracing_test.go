@@ -0,0 +1,101 @@
+package pkg
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// useTestTracerProvider records spans in memory for the rest of the test.
+func useTestTracerProvider(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return recorder
+}
+
+func TestWithTracing_ContinuesClientTrace(t *testing.T) {
+	recorder := useTestTracerProvider(t)
+
+	var handlerSpan trace.SpanContext
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handlerSpan = trace.SpanContextFromContext(r.Context())
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	req := httptest.NewRequest(http.MethodPost, "/mcp", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rec := httptest.NewRecorder()
+	withTracing(next).ServeHTTP(rec, req)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("expected one span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name() != "POST /mcp" || span.SpanKind() != trace.SpanKindServer {
+		t.Fatalf("unexpected span %q of kind %v", span.Name(), span.SpanKind())
+	}
+	if span.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || span.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Fatalf("expected the span to continue the client trace, got trace %s parent %s", span.SpanContext().TraceID(), span.Parent().SpanID())
+	}
+	if handlerSpan.SpanID() != span.SpanContext().SpanID() {
+		t.Fatal("expected the request span on the handler's context")
+	}
+	found := false
+	for _, attr := range span.Attributes() {
+		if attr.Key == "http.response.status_code" && attr.Value.AsInt64() == http.StatusAccepted {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected the response status in %v", span.Attributes())
+	}
+}
+
+func TestSetupTracing(t *testing.T) {
+	previousProvider, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previousProvider)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	if _, err := SetupTracing(context.Background(), "zipkin", ""); err == nil {
+		t.Fatal("expected an unknown exporter to be rejected")
+	}
+	if _, err := SetupTracing(context.Background(), TraceExporterFile, ""); err == nil {
+		t.Fatal("expected the file exporter to need a file")
+	}
+
+	path := filepath.Join(t.TempDir(), "traces.json")
+	shutdown, err := SetupTracing(context.Background(), TraceExporterFile, path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, span := Tracer().Start(context.Background(), "test")
+	span.End()
+	if err := shutdown(context.Background()); err != nil {
+		t.Fatalf("unexpected error flushing traces: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), `"Name":"test"`) {
+		t.Fatalf("expected the span in the trace file, got %q: %v", data, err)
+	}
+}